@@ -8,3 +8,5 @@ require (
 )
 
 require github.com/mattn/go-sqlite3 v1.14.32
+
+require github.com/lib/pq v1.10.9
@@ -0,0 +1,73 @@
+package llm
+
+import (
+	"adventBot/internal/config"
+	"context"
+	"fmt"
+	"net/http"
+)
+
+const (
+	ProviderYandex = "yandex"
+	ProviderOpenAI = "openai"
+)
+
+// Message — сообщение диалога в формате, общем для всех провайдеров.
+type Message struct {
+	Role string `json:"role"`
+	Text string `json:"text"`
+}
+
+type Options struct {
+	Temperature float64 `json:"temperature"`
+	MaxTokens   int     `json:"maxTokens"`
+}
+
+// Request — запрос на генерацию. Model — короткое имя модели
+// (например "yandexgpt-5-pro/latest"), полный URI строит клиент.
+type Request struct {
+	Model    string    `json:"model"`
+	Messages []Message `json:"messages"`
+	Options  Options   `json:"options"`
+}
+
+type Usage struct {
+	InputTokens      int
+	CompletionTokens int
+	TotalTokens      int
+}
+
+type Response struct {
+	Text         string
+	ModelVersion string
+	Usage        Usage
+}
+
+// Client — провайдер-независимый клиент языковой модели.
+type Client interface {
+	Complete(ctx context.Context, req Request) (Response, error)
+	Tokenize(ctx context.Context, model string, text string) (int, error)
+	ModelURI(model string) string
+}
+
+func NewClient(cfg *config.Config, httpClient *http.Client) (Client, error) {
+	switch cfg.LlmProvider {
+	case "", ProviderYandex:
+		c := NewYandexClient(cfg.ApiKey, cfg.FolderId, httpClient)
+		if cfg.LlmBaseUrl != "" {
+			c.BaseURL = cfg.LlmBaseUrl
+		}
+		return c, nil
+	case ProviderOpenAI:
+		if cfg.LlmBaseUrl == "" {
+			return nil, fmt.Errorf("LLM_BASE_URL is required for provider %s", ProviderOpenAI)
+		}
+		return NewOpenAIClient(cfg.LlmBaseUrl, cfg.LlmApiKey, cfg.LlmModel, httpClient), nil
+	default:
+		return nil, fmt.Errorf("unknown LLM_PROVIDER: %s", cfg.LlmProvider)
+	}
+}
+
+func isRequestSuccessful(status int) bool {
+	return status >= 200 && status < 300
+}
@@ -0,0 +1,150 @@
+package llm_test
+
+import (
+	"adventBot/internal/ai_model/llm"
+	"adventBot/internal/ai_model/llm/llmtest"
+	"context"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func request(text string) llm.Request {
+	return llm.Request{
+		Model:    "yandexgpt-lite",
+		Messages: []llm.Message{{Role: "system", Text: "rule"}, {Role: "user", Text: text}},
+		Options:  llm.Options{Temperature: 0.3, MaxTokens: 100},
+	}
+}
+
+func TestYandexClientComplete(t *testing.T) {
+	srv := llmtest.NewServer(llmtest.Ask("Во сколько?", "dateTime"))
+	defer srv.Close()
+
+	resp, err := srv.YandexClient().Complete(context.Background(), request("врач завтра"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text != llmtest.Ask("Во сколько?", "dateTime") {
+		t.Errorf("Text = %q", resp.Text)
+	}
+	if resp.ModelVersion != "llmtest" || resp.Usage.InputTokens == 0 || resp.Usage.CompletionTokens == 0 {
+		t.Errorf("ModelVersion = %q, Usage = %+v", resp.ModelVersion, resp.Usage)
+	}
+
+	reqs := srv.Requests()
+	if len(reqs) != 1 {
+		t.Fatalf("got %d requests, want 1", len(reqs))
+	}
+	if want := "gpt://" + llmtest.FolderID + "/yandexgpt-lite"; reqs[0].Model != want {
+		t.Errorf("Model = %q, want %q", reqs[0].Model, want)
+	}
+	if len(reqs[0].Messages) != 2 || reqs[0].Messages[1].Text != "врач завтра" {
+		t.Errorf("Messages = %+v", reqs[0].Messages)
+	}
+}
+
+func TestYandexClientTokenize(t *testing.T) {
+	srv := llmtest.NewServer()
+	defer srv.Close()
+	srv.TokensCount = 42
+
+	n, err := srv.YandexClient().Tokenize(context.Background(), "yandexgpt-lite", "текст")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 42 {
+		t.Errorf("Tokenize = %d, want 42", n)
+	}
+}
+
+func TestYandexClientErrors(t *testing.T) {
+	srv := llmtest.NewServer()
+	defer srv.Close()
+
+	// Ответы не заскриптованы — сервер отвечает 500
+	if _, err := srv.YandexClient().Complete(context.Background(), request("x")); err == nil || !strings.Contains(err.Error(), "500") {
+		t.Errorf("Complete without answers: err = %v", err)
+	}
+
+	c := srv.YandexClient()
+	c.ApiKey = "wrong"
+	if _, err := c.Tokenize(context.Background(), "m", "x"); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("Tokenize with wrong key: err = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	srv.Script(llmtest.Ask("?", "task"))
+	if _, err := srv.YandexClient().Complete(ctx, request("x")); !errors.Is(err, context.Canceled) {
+		t.Errorf("Complete with cancelled ctx: err = %v", err)
+	}
+}
+
+func TestOpenAIClientComplete(t *testing.T) {
+	srv := llmtest.NewServer(llmtest.Final("Врач", "2030-01-01T10:00:00+03:00", "Клиника"))
+	defer srv.Close()
+
+	c := llm.NewOpenAIClient(srv.URL+"/v1/", llmtest.ApiKey, "local-model", srv.Client())
+	resp, err := c.Complete(context.Background(), request("врач"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(resp.Text, `"mode":"final"`) || resp.Usage.TotalTokens == 0 {
+		t.Errorf("resp = %+v", resp)
+	}
+
+	reqs := srv.Requests()
+	if len(reqs) != 1 || reqs[0].Path != "/v1/chat/completions" || reqs[0].Model != "local-model" {
+		t.Errorf("requests = %+v", reqs)
+	}
+
+	n, err := c.Tokenize(context.Background(), "m", "12345678")
+	if err != nil || n != 2 {
+		t.Errorf("Tokenize = %d, %v; want 2", n, err)
+	}
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	srv := llmtest.NewServer(llmtest.Fenced(llmtest.Delete(7)))
+	defer srv.Close()
+
+	// Записываем разговор с фейковым сервером
+	recorder := llmtest.NewRecordingTransport(srv.Client().Transport)
+	c := srv.YandexClient()
+	c.Client = &http.Client{Transport: recorder}
+	want, err := c.Complete(context.Background(), request("удали"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "fixtures.json")
+	if err := recorder.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// Проигрываем без сервера: адрес никуда не ведёт
+	replay, err := llmtest.LoadReplayTransport(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	offline := llm.NewYandexClient(llmtest.ApiKey, llmtest.FolderID, &http.Client{Transport: replay})
+	offline.BaseURL = "http://llm.invalid"
+
+	got, err := offline.Complete(context.Background(), request("удали"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("replayed %+v, recorded %+v", got, want)
+	}
+	if replay.Remaining() != 0 {
+		t.Errorf("Remaining = %d, want 0", replay.Remaining())
+	}
+
+	if _, err := offline.Tokenize(context.Background(), "m", "x"); err == nil {
+		t.Error("request beyond fixtures: want error")
+	}
+}
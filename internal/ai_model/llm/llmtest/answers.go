@@ -0,0 +1,76 @@
+package llmtest
+
+import "encoding/json"
+
+// Ask — ответ модели в режиме ask (см. response.go в пакете yandex).
+func Ask(question string, property string) string {
+	return mustJSON(map[string]string{
+		"mode":     "ask",
+		"question": question,
+		"property": property,
+	})
+}
+
+// Final — ответ модели в режиме final.
+func Final(task string, dateTime string, location string) string {
+	return mustJSON(map[string]string{
+		"mode":     "final",
+		"task":     task,
+		"dateTime": dateTime,
+		"location": location,
+	})
+}
+
+// FinalTask — одна задача для FinalBatch.
+type FinalTask struct {
+	Task     string `json:"task"`
+	DateTime string `json:"dateTime"`
+	Location string `json:"location"`
+}
+
+// FinalBatch — ответ модели в режиме final с несколькими задачами.
+func FinalBatch(tasks ...FinalTask) string {
+	return mustJSON(map[string]any{
+		"mode":  "final",
+		"tasks": tasks,
+	})
+}
+
+// Update — ответ модели в режиме update: changes — изменённые поля задачи taskID.
+func Update(taskID int64, changes map[string]any) string {
+	answer := map[string]any{"mode": "update", "taskId": taskID}
+	for k, v := range changes {
+		answer[k] = v
+	}
+	return mustJSON(answer)
+}
+
+// Delete — ответ модели в режиме delete.
+func Delete(taskID int64) string {
+	return mustJSON(map[string]any{
+		"mode":   "delete",
+		"taskId": taskID,
+	})
+}
+
+// Finalized — ответ финализатора.
+func Finalized(message string) string {
+	return mustJSON(map[string]string{
+		"mode":      "finalized",
+		"message":   message,
+		"reasoning": "",
+	})
+}
+
+// Fenced оборачивает ответ в markdown-блок, как это иногда делает YandexGPT.
+func Fenced(answer string) string {
+	return "```json\n" + answer + "\n```"
+}
+
+func mustJSON(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return string(b)
+}
@@ -0,0 +1,162 @@
+package llmtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+)
+
+// Fixture — записанная пара запрос/ответ.
+type Fixture struct {
+	Method  string          `json:"method"`
+	Path    string          `json:"path"`
+	Request json.RawMessage `json:"request,omitempty"`
+	Status  int             `json:"status"`
+	Body    json.RawMessage `json:"body"`
+}
+
+// RecordingTransport проксирует запросы в Base и запоминает ответы,
+// чтобы потом сохранить их через Save и проигрывать ReplayTransport.
+type RecordingTransport struct {
+	Base http.RoundTripper
+
+	mu       sync.Mutex
+	fixtures []Fixture
+}
+
+func NewRecordingTransport(base http.RoundTripper) *RecordingTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &RecordingTransport{Base: base}
+}
+
+func (t *RecordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		b, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		reqBody = b
+		req.Body = io.NopCloser(bytes.NewReader(b))
+	}
+
+	resp, err := t.Base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	t.mu.Lock()
+	t.fixtures = append(t.fixtures, Fixture{
+		Method:  req.Method,
+		Path:    req.URL.Path,
+		Request: rawOrString(reqBody),
+		Status:  resp.StatusCode,
+		Body:    rawOrString(respBody),
+	})
+	t.mu.Unlock()
+
+	return resp, nil
+}
+
+// Save пишет записанные фикстуры в файл в формате JSON.
+func (t *RecordingTransport) Save(path string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	b, err := json.MarshalIndent(t.fixtures, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0o644)
+}
+
+// ReplayTransport отдаёт записанные ответы по порядку, не выходя в сеть.
+// Запрос, не совпадающий с очередной фикстурой по методу и пути, — ошибка.
+type ReplayTransport struct {
+	mu       sync.Mutex
+	fixtures []Fixture
+}
+
+func NewReplayTransport(fixtures ...Fixture) *ReplayTransport {
+	return &ReplayTransport{fixtures: fixtures}
+}
+
+func LoadReplayTransport(path string) (*ReplayTransport, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var fixtures []Fixture
+	if err := json.Unmarshal(b, &fixtures); err != nil {
+		return nil, fmt.Errorf("decode fixtures %s: %w", path, err)
+	}
+	return NewReplayTransport(fixtures...), nil
+}
+
+func (t *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+
+	if len(t.fixtures) == 0 {
+		return nil, fmt.Errorf("llmtest: unexpected request %s %s: no fixtures left", req.Method, req.URL.Path)
+	}
+
+	f := t.fixtures[0]
+	if (f.Method != "" && f.Method != req.Method) || f.Path != req.URL.Path {
+		return nil, fmt.Errorf("llmtest: unexpected request %s %s, want %s %s", req.Method, req.URL.Path, f.Method, f.Path)
+	}
+	t.fixtures = t.fixtures[1:]
+
+	status := f.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(f.Body)),
+		ContentLength: int64(len(f.Body)),
+		Request:       req,
+	}, nil
+}
+
+// Remaining — количество неиспользованных фикстур.
+func (t *ReplayTransport) Remaining() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.fixtures)
+}
+
+func rawOrString(b []byte) json.RawMessage {
+	if len(b) == 0 {
+		return nil
+	}
+	if json.Valid(b) {
+		return b
+	}
+	s, _ := json.Marshal(string(b))
+	return s
+}
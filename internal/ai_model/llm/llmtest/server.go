@@ -0,0 +1,247 @@
+package llmtest
+
+import (
+	"adventBot/internal/ai_model/llm"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+const (
+	ApiKey   = "test-api-key"
+	FolderID = "test-folder"
+
+	completionPath       = "/foundationModels/v1/completion"
+	tokenizePath         = "/foundationModels/v1/tokenize"
+	openAICompletionPath = "/chat/completions"
+	modelVersion         = "llmtest"
+)
+
+// Server — фейковый LLM API поверх httptest. Отвечает на запросы completion
+// заранее заскриптованными ответами по очереди, tokenize считает ~4 символа на токен.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	answers  []string
+	requests []Received
+	// TokensCount, если задан, возвращается на любой запрос tokenize вместо оценки.
+	TokensCount int
+}
+
+// Received — запрос, пришедший на фейковый сервер.
+type Received struct {
+	Path     string
+	Model    string
+	Messages []llm.Message
+}
+
+type yandexRequest struct {
+	ModelURI string        `json:"modelUri"`
+	Messages []llm.Message `json:"messages"`
+	Text     string        `json:"text"`
+}
+
+type openAIRequest struct {
+	Model    string `json:"model"`
+	Messages []struct {
+		Role    string `json:"role"`
+		Content string `json:"content"`
+	} `json:"messages"`
+}
+
+func NewServer(answers ...string) *Server {
+	s := &Server{answers: answers}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// Script добавляет ответы модели в конец очереди.
+func (s *Server) Script(answers ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.answers = append(s.answers, answers...)
+}
+
+// Pending — количество ещё не выданных ответов.
+func (s *Server) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.answers)
+}
+
+func (s *Server) Requests() []Received {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Received(nil), s.requests...)
+}
+
+// YandexClient — клиент, направленный на фейковый сервер.
+func (s *Server) YandexClient() *llm.YandexClient {
+	c := llm.NewYandexClient(ApiKey, FolderID, s.Server.Client())
+	c.BaseURL = s.URL
+	return c
+}
+
+// OpenAIClient — OpenAI-совместимый клиент, направленный на фейковый сервер.
+func (s *Server) OpenAIClient() *llm.OpenAIClient {
+	return llm.NewOpenAIClient(s.URL, ApiKey, "", s.Server.Client())
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case r.URL.Path == tokenizePath:
+		s.handleTokenize(w, r, body)
+	case r.URL.Path == completionPath:
+		s.handleYandexCompletion(w, r, body)
+	case strings.HasSuffix(r.URL.Path, openAICompletionPath):
+		s.handleOpenAICompletion(w, r, body)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) handleTokenize(w http.ResponseWriter, r *http.Request, body []byte) {
+	if !s.authorized(r, "Api-Key ") {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req yandexRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	count := s.TokensCount
+	if count == 0 {
+		count = (utf8.RuneCountInString(req.Text) + 3) / 4
+	}
+
+	type token struct {
+		ID      string `json:"id"`
+		Text    string `json:"text"`
+		Special bool   `json:"special"`
+	}
+	tokens := make([]token, count)
+	for i := range tokens {
+		tokens[i] = token{ID: strconv.Itoa(i)}
+	}
+
+	writeJSON(w, map[string]any{"tokens": tokens, "modelVersion": modelVersion})
+}
+
+func (s *Server) handleYandexCompletion(w http.ResponseWriter, r *http.Request, body []byte) {
+	if !s.authorized(r, "Api-Key ") {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req yandexRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	answer, ok := s.next(Received{Path: r.URL.Path, Model: req.ModelURI, Messages: req.Messages})
+	if !ok {
+		http.Error(w, "llmtest: no scripted answers left", http.StatusInternalServerError)
+		return
+	}
+
+	in, out := countTokens(req.Messages), (utf8.RuneCountInString(answer)+3)/4
+	writeJSON(w, map[string]any{
+		"result": map[string]any{
+			"alternatives": []map[string]any{{
+				"message": llm.Message{Role: "assistant", Text: answer},
+				"status":  "ALTERNATIVE_STATUS_FINAL",
+			}},
+			"usage": map[string]string{
+				"inputTextTokens":  strconv.Itoa(in),
+				"completionTokens": strconv.Itoa(out),
+				"totalTokens":      strconv.Itoa(in + out),
+			},
+			"modelVersion": modelVersion,
+		},
+	})
+}
+
+func (s *Server) handleOpenAICompletion(w http.ResponseWriter, r *http.Request, body []byte) {
+	if !s.authorized(r, "Bearer ") {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req openAIRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	msgs := make([]llm.Message, 0, len(req.Messages))
+	for _, m := range req.Messages {
+		msgs = append(msgs, llm.Message{Role: m.Role, Text: m.Content})
+	}
+
+	answer, ok := s.next(Received{Path: r.URL.Path, Model: req.Model, Messages: msgs})
+	if !ok {
+		http.Error(w, "llmtest: no scripted answers left", http.StatusInternalServerError)
+		return
+	}
+
+	in, out := countTokens(msgs), (utf8.RuneCountInString(answer)+3)/4
+	writeJSON(w, map[string]any{
+		"model": modelVersion,
+		"choices": []map[string]any{{
+			"message":       map[string]string{"role": "assistant", "content": answer},
+			"finish_reason": "stop",
+		}},
+		"usage": map[string]int{
+			"prompt_tokens":     in,
+			"completion_tokens": out,
+			"total_tokens":      in + out,
+		},
+	})
+}
+
+func (s *Server) next(rec Received) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = append(s.requests, rec)
+	if len(s.answers) == 0 {
+		return "", false
+	}
+	answer := s.answers[0]
+	s.answers = s.answers[1:]
+	return answer, true
+}
+
+func (s *Server) authorized(r *http.Request, scheme string) bool {
+	return r.Header.Get("Authorization") == fmt.Sprintf("%s%s", scheme, ApiKey)
+}
+
+func countTokens(msgs []llm.Message) int {
+	n := 0
+	for _, m := range msgs {
+		n += (utf8.RuneCountInString(m.Text) + 3) / 4
+	}
+	return n
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,137 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+const openAICompletionPath = "/chat/completions"
+
+// OpenAIClient — клиент OpenAI-совместимого chat-completions API
+// (llama.cpp, vLLM, Ollama и т.п.). BaseURL указывается вместе с версией: http://localhost:8080/v1
+type OpenAIClient struct {
+	BaseURL string
+	ApiKey  string
+	Model   string // если задана, используется вместо модели из запроса
+	Client  *http.Client
+}
+
+type openAIMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type openAIRequest struct {
+	Model       string          `json:"model"`
+	Messages    []openAIMessage `json:"messages"`
+	Temperature float64         `json:"temperature"`
+	MaxTokens   int             `json:"max_tokens,omitempty"`
+	Stream      bool            `json:"stream"`
+}
+
+type openAIResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Message      openAIMessage `json:"message"`
+		FinishReason string        `json:"finish_reason"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+		TotalTokens      int `json:"total_tokens"`
+	} `json:"usage"`
+}
+
+func NewOpenAIClient(baseURL string, apiKey string, model string, client *http.Client) *OpenAIClient {
+	return &OpenAIClient{
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		ApiKey:  apiKey,
+		Model:   model,
+		Client:  client,
+	}
+}
+
+func (c *OpenAIClient) ModelURI(model string) string {
+	if c.Model != "" {
+		return c.Model
+	}
+	return model
+}
+
+func (c *OpenAIClient) Complete(ctx context.Context, req Request) (Response, error) {
+	r := openAIRequest{
+		Model:       c.ModelURI(req.Model),
+		Messages:    make([]openAIMessage, 0, len(req.Messages)),
+		Temperature: req.Options.Temperature,
+		MaxTokens:   req.Options.MaxTokens,
+	}
+	for _, m := range req.Messages {
+		r.Messages = append(r.Messages, openAIMessage{Role: m.Role, Content: m.Text})
+	}
+
+	b, err := json.Marshal(r)
+	if err != nil {
+		return Response{}, fmt.Errorf("encode request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+openAICompletionPath, bytes.NewReader(b))
+	if err != nil {
+		return Response{}, fmt.Errorf("create request: %w", err)
+	}
+	if c.ApiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.ApiKey)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.Client.Do(httpReq)
+	if err != nil {
+		return Response{}, fmt.Errorf("make request: %w", err)
+	}
+	defer func(Body io.ReadCloser) {
+		if cerr := Body.Close(); cerr != nil {
+			log.Printf("[OpenAIClient.Complete] Body.Close(): %v", cerr)
+		}
+	}(resp.Body)
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Response{}, fmt.Errorf("read body: %w", err)
+	}
+	log.Printf("[OpenAIClient.Complete] HTTP status: %d, RAW response:\n%s", resp.StatusCode, string(raw))
+
+	if !isRequestSuccessful(resp.StatusCode) {
+		return Response{}, fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(raw))
+	}
+
+	var or openAIResponse
+	if err := json.Unmarshal(raw, &or); err != nil {
+		return Response{}, fmt.Errorf("decode response: %w", err)
+	}
+	if len(or.Choices) == 0 {
+		return Response{}, errors.New("no choices in response")
+	}
+
+	return Response{
+		Text:         or.Choices[0].Message.Content,
+		ModelVersion: or.Model,
+		Usage: Usage{
+			InputTokens:      or.Usage.PromptTokens,
+			CompletionTokens: or.Usage.CompletionTokens,
+			TotalTokens:      or.Usage.TotalTokens,
+		},
+	}, nil
+}
+
+// Tokenize — у chat-completions API нет эндпоинта токенизации,
+// поэтому возвращается оценка: ~4 символа на токен.
+func (c *OpenAIClient) Tokenize(_ context.Context, _ string, text string) (int, error) {
+	return (utf8.RuneCountInString(text) + 3) / 4, nil
+}
@@ -0,0 +1,166 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+const (
+	yandexBaseURL        = "https://llm.api.cloud.yandex.net"
+	yandexCompletionPath = "/foundationModels/v1/completion"
+	yandexTokenizePath   = "/foundationModels/v1/tokenize"
+)
+
+type YandexClient struct {
+	ApiKey   string
+	FolderID string
+	BaseURL  string
+	Client   *http.Client
+}
+
+type yandexRequest struct {
+	ModelURI          string `json:"modelUri"`
+	CompletionOptions struct {
+		Stream      bool    `json:"stream"`
+		Temperature float64 `json:"temperature"`
+		MaxTokens   int     `json:"maxTokens"`
+	} `json:"completionOptions"`
+	Messages []Message `json:"messages"`
+}
+
+type yandexResponse struct {
+	Result struct {
+		Alternatives []struct {
+			Message struct {
+				Role string `json:"role"`
+				Text string `json:"text"`
+			} `json:"message"`
+			Status string `json:"status"`
+		} `json:"alternatives"`
+		Usage struct {
+			InputTextTokens  string `json:"inputTextTokens"`
+			CompletionTokens string `json:"completionTokens"`
+			TotalTokens      string `json:"totalTokens"`
+		} `json:"usage"`
+		ModelVersion string `json:"modelVersion"`
+	} `json:"result"`
+}
+
+type yandexTokenizeRequest struct {
+	ModelURI string `json:"modelUri"`
+	Text     string `json:"text"`
+}
+
+type yandexTokenizeResponse struct {
+	Tokens []struct {
+		ID      string `json:"id"`
+		Text    string `json:"text"`
+		Special bool   `json:"special"`
+	} `json:"tokens"`
+	ModelVersion string `json:"modelVersion"`
+}
+
+func NewYandexClient(apiKey string, folderID string, client *http.Client) *YandexClient {
+	return &YandexClient{
+		ApiKey:   apiKey,
+		FolderID: folderID,
+		BaseURL:  yandexBaseURL,
+		Client:   client,
+	}
+}
+
+func (c *YandexClient) ModelURI(model string) string {
+	return fmt.Sprintf("gpt://%s/%s", c.FolderID, model)
+}
+
+func (c *YandexClient) Complete(ctx context.Context, req Request) (Response, error) {
+	r := yandexRequest{
+		ModelURI: c.ModelURI(req.Model),
+		Messages: req.Messages,
+	}
+	r.CompletionOptions.Stream = false
+	r.CompletionOptions.Temperature = req.Options.Temperature
+	r.CompletionOptions.MaxTokens = req.Options.MaxTokens
+
+	var yr yandexResponse
+	if err := c.post(ctx, yandexCompletionPath, r, &yr); err != nil {
+		return Response{}, err
+	}
+
+	if len(yr.Result.Alternatives) == 0 {
+		return Response{}, errors.New("no alternatives in response")
+	}
+
+	return Response{
+		Text:         yr.Result.Alternatives[0].Message.Text,
+		ModelVersion: yr.Result.ModelVersion,
+		Usage: Usage{
+			InputTokens:      atoi(yr.Result.Usage.InputTextTokens),
+			CompletionTokens: atoi(yr.Result.Usage.CompletionTokens),
+			TotalTokens:      atoi(yr.Result.Usage.TotalTokens),
+		},
+	}, nil
+}
+
+func (c *YandexClient) Tokenize(ctx context.Context, model string, text string) (int, error) {
+	var tr yandexTokenizeResponse
+	if err := c.post(ctx, yandexTokenizePath, yandexTokenizeRequest{ModelURI: c.ModelURI(model), Text: text}, &tr); err != nil {
+		return 0, err
+	}
+	return len(tr.Tokens), nil
+}
+
+func (c *YandexClient) post(ctx context.Context, path string, body any, out any) error {
+	if c.ApiKey == "" || c.FolderID == "" {
+		return errors.New("yandex api key or folder id is empty")
+	}
+
+	b, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+path, bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Api-Key "+c.ApiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("make request: %w", err)
+	}
+	defer func(Body io.ReadCloser) {
+		if cerr := Body.Close(); cerr != nil {
+			log.Printf("[YandexClient.post] Body.Close(): %v", cerr)
+		}
+	}(resp.Body)
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read body: %w", err)
+	}
+	log.Printf("[YandexClient.post] %s HTTP status: %d, RAW response:\n%s", path, resp.StatusCode, string(raw))
+
+	if !isRequestSuccessful(resp.StatusCode) {
+		return fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(raw))
+	}
+
+	if err := json.Unmarshal(raw, out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
@@ -2,15 +2,35 @@ package ai_model
 
 import (
 	"adventBot/internal/db/message"
+	"adventBot/internal/db/pending"
+	"adventBot/internal/db/task"
 	"context"
+	"errors"
+	"time"
 )
 
+// ErrDraftNotFound — черновика, к которому относится кнопка, уже нет: он сохранён, отменён или заменён новым.
+var ErrDraftNotFound = errors.New("draft not found")
+
 type InputForm struct {
 	History []message.Message `json:"messages_history"`
 }
 
+// Reply — ответ модели пользователю. Tasks — задачи, сохранённые по этому ответу; Pending — черновик,
+// который ждёт подтверждения. Оба пусты, если модель задала уточняющий вопрос или запрос не удался.
+type Reply struct {
+	Text    string
+	Tasks   []task.Task
+	Pending *pending.Pending
+}
+
 type AiModel interface {
-	AskGpt(ctx context.Context, chatId int64, inputForm InputForm, isCot bool) (reply string)
-	AskWithTemperature(text string, temperature float64) (reply string, tmp float64)
+	AskGpt(ctx context.Context, chatId int64, inputForm InputForm, isCot bool) (reply Reply)
+	// SavePending, ChangePending и CancelPending завершают черновик чата, созданный в createdAt.
+	// Text ответа готов к показу и при ошибке; ErrDraftNotFound — черновик уже неактуален.
+	SavePending(ctx context.Context, chatId int64, createdAt time.Time) (Reply, error)
+	ChangePending(ctx context.Context, chatId int64, createdAt time.Time) (Reply, error)
+	CancelPending(ctx context.Context, chatId int64, createdAt time.Time) (Reply, error)
+	AskWithTemperature(ctx context.Context, text string, temperature float64) (reply string, tmp float64)
 	GetUserRole() Role
 }
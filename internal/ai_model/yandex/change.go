@@ -0,0 +1,130 @@
+package yandex
+
+import (
+	"adventBot/internal/ai_model"
+	"adventBot/internal/ai_model/llm"
+	"adventBot/internal/db/store"
+	"adventBot/internal/db/task"
+	"adventBot/internal/recurrence"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// upcomingTasksLimit — сколько ближайших задач чата передавать модели, чтобы она могла выбрать задачу для update и delete.
+const upcomingTasksLimit = 30
+
+const taskNotFoundReply = "Не нашёл такую задачу. Посмотреть список: /tasks"
+
+var errTaskNotFound = errors.New("task not found")
+
+// upcomingTask — элемент списка upcoming_tasks в запросе к модели.
+type upcomingTask struct {
+	ID         int64  `json:"id"`
+	Task       string `json:"task"`
+	DateTime   string `json:"dateTime"`
+	Location   string `json:"location"`
+	Recurrence string `json:"recurrence,omitempty"`
+}
+
+// upcomingTasks возвращает системное сообщение с открытыми задачами чата и их ID. Сообщение пустое,
+// если задач нет или их не удалось загрузить — тогда модель может только создавать задачи.
+func (a *AiModelYandex) upcomingTasks(ctx context.Context, chatId int64) llm.Message {
+	tasks, err := a.Store.Tasks.GetAll(ctx, chatId)
+	if err != nil {
+		log.Printf("[AiModelYandex.upcomingTasks] GetAll chatID=%d err=%v", chatId, err)
+		return llm.Message{}
+	}
+	if len(tasks) == 0 {
+		return llm.Message{}
+	}
+
+	items := make([]upcomingTask, 0, min(len(tasks), upcomingTasksLimit))
+	for _, t := range tasks[:min(len(tasks), upcomingTasksLimit)] {
+		items = append(items, upcomingTask{ID: t.ID, Task: t.Task, DateTime: t.DateTime, Location: t.Location, Recurrence: t.Recurrence})
+	}
+
+	b, err := json.Marshal(items)
+	if err != nil {
+		log.Printf("[AiModelYandex.upcomingTasks] marshal error: %v", err)
+		return llm.Message{}
+	}
+	return llm.Message{Role: "system", Text: "upcoming_tasks: " + string(b)}
+}
+
+// applyChange выполняет ответ update или delete. Задача ищется по ID только среди задач чата, поэтому
+// модель не может задеть чужие. Изменение и очистка истории диалога фиксируются вместе, как в final.
+func (a *AiModelYandex) applyChange(ctx context.Context, chatId int64, parsed response, resp llm.Response) ai_model.Reply {
+	if parsed.TaskID == 0 {
+		log.Printf("[AiModelYandex.applyChange] %s without taskId", parsed.Mode)
+		return ai_model.Reply{Text: failureRequestReply}
+	}
+
+	var changed task.Task
+	err := a.Store.WithTx(ctx, func(tx store.Repositories) error {
+		t, found, err := tx.Tasks.GetByID(ctx, chatId, parsed.TaskID)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return errTaskNotFound
+		}
+
+		if parsed.Mode == modeDelete {
+			_, err = tx.Tasks.Delete(ctx, chatId, t.ID)
+		} else {
+			if err = parsed.applyTo(&t); err != nil {
+				return err
+			}
+			_, err = tx.Tasks.Update(ctx, t)
+		}
+		if err != nil {
+			return err
+		}
+		changed = t
+
+		_, err = tx.Messages.DeleteById(ctx, chatId)
+		return err
+	})
+	if errors.Is(err, errTaskNotFound) {
+		log.Printf("[AiModelYandex.applyChange] %s: task id=%d not found in chatID=%d", parsed.Mode, parsed.TaskID, chatId)
+		return ai_model.Reply{Text: taskNotFoundReply}
+	}
+	if err != nil {
+		log.Printf("[AiModelYandex.applyChange] %s id=%d err=%v", parsed.Mode, parsed.TaskID, err)
+		return ai_model.Reply{Text: failureRequestReply}
+	}
+
+	batch := response{Mode: parsed.Mode, Tasks: []finalTask{toFinalTask(changed)}, Reasoning: parsed.Reasoning}
+	return ai_model.Reply{Text: a.confirm(ctx, batch, &resp)}
+}
+
+// applyTo переносит в t поля, которые модель изменила в ответе update. Пустое поле означает «без изменений».
+func (r response) applyTo(t *task.Task) error {
+	if r.DateTime != "" {
+		if _, err := time.Parse(time.RFC3339, r.DateTime); err != nil {
+			return fmt.Errorf("invalid dateTime %q: %w", r.DateTime, err)
+		}
+		t.DateTime = r.DateTime
+	}
+	if r.Recurrence != "" {
+		rule, err := recurrence.Parse(r.Recurrence)
+		if err != nil {
+			return fmt.Errorf("invalid recurrence %q: %w", r.Recurrence, err)
+		}
+		t.Recurrence = rule.String()
+	}
+	if r.Task != "" {
+		t.Task = r.Task
+	}
+	if r.Location != "" {
+		t.Location = r.Location
+	}
+	if r.RemindBefore != nil {
+		t.RemindBefore = r.RemindBefore
+	}
+	return nil
+}
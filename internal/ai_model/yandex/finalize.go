@@ -2,140 +2,73 @@ package yandex
 
 import (
 	"adventBot/internal/ai_model"
+	"adventBot/internal/ai_model/llm"
 	"adventBot/internal/config"
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
 	"strings"
 )
 
-type ModelVersion string
-
 type FinalizerModel struct {
-	modelVersion ModelVersion
-	ApiKey       string
-	FolderID     string
-	ruleText     string
-}
-
-type finalizerRequest struct {
-	ModelURI          string             `json:"modelUri"`
-	Messages          []MessageYandexGpt `json:"messages"`
-	CompletionOptions struct {
-		Stream      bool    `json:"stream"`
-		Temperature float64 `json:"temperature"`
-		MaxTokens   int     `json:"maxTokens"`
-	} `json:"completionOptions"`
+	Client   llm.Client
+	Model    string
+	ruleText string
 }
 
 type finalizerResponse struct {
 	Mode      string `json:"mode"`
-	Message   string `json:"MessageYandexGpt"`
+	Message   string `json:"message"`
 	Reasoning string `json:"reasoning"`
 }
 
-type finalizerYaResponse struct {
-	Result struct {
-		Alternatives []struct {
-			Message struct {
-				Role string `json:"role"`
-				Text string `json:"text"`
-			} `json:"MessageYandexGpt"`
-			Status string `json:"status"`
-		} `json:"alternatives"`
-		ModelVersion string `json:"modelVersion"`
-	} `json:"result"`
-}
-
-func NewFinalizerModel(cfg *config.Config, folderId string, modelEndpoint string) *FinalizerModel {
+func NewFinalizerModel(cfg *config.Config, client llm.Client, model string) *FinalizerModel {
 	return &FinalizerModel{
-		modelVersion: ModelVersion(modelEndpoint),
-		ApiKey:       cfg.ApiKey,
-		FolderID:     folderId,
-		ruleText:     ai_model.MustReadFile(cfg.RulePathFinalizer),
+		Client:   client,
+		Model:    model,
+		ruleText: ai_model.MustReadFile(cfg.RulePathFinalizer),
 	}
 }
 
-func (f *FinalizerModel) Finalize(rawJson string) string {
+func (f *FinalizerModel) Finalize(ctx context.Context, rawJson string) string {
 	log.Printf("[FinalizerModel.Finalize] processing raw JSON: %s", rawJson)
 
-	if f.ApiKey == "" || f.FolderID == "" {
-		log.Println("[FinalizerModel.Finalize] IamToken or FolderID is empty")
-		return "Не удалось обработать ответ модели"
-	}
-
-	// Проверяем, что это действительно final ответ
+	// Проверяем, что это final ответ или подтверждение update/delete
 	var finalResp response
 	if err := json.Unmarshal([]byte(rawJson), &finalResp); err != nil {
 		log.Printf("[FinalizerModel.Finalize] cannot parse final JSON: %v", err)
 		return "Не удалось обработать ответ модели"
 	}
 
-	if finalResp.Mode != modeFinal {
-		log.Printf("[FinalizerModel.Finalize] expected final mode, got: %s", finalResp.Mode)
+	if finalResp.Mode != modeFinal && finalResp.Mode != modeUpdate && finalResp.Mode != modeDelete {
+		log.Printf("[FinalizerModel.Finalize] expected final, update or delete mode, got: %s", finalResp.Mode)
 		return "Не удалось обработать ответ модели"
 	}
 
-	// Проверяем наличие всех обязательных полей
-	if finalResp.Task == "" || finalResp.DateTime == "" {
-		log.Println("[FinalizerModel.Finalize] missing required fields in final response")
+	// Проверяем наличие всех обязательных полей у каждой задачи
+	items := finalResp.finalTasks()
+	if len(items) == 0 {
+		log.Println("[FinalizerModel.Finalize] no tasks in final response")
 		return "Не удалось обработать ответ модели"
 	}
-
-	// Формируем запрос к модели для финализации
-	reqBody, err := f.prepareFinalizerRequest(rawJson)
-	if err != nil {
-		log.Printf("[FinalizerModel.Finalize] Failed to prepare finalizer request: %v", err)
-		return "Не удалось обработать ответ модели"
+	for _, it := range items {
+		if it.Task == "" || it.DateTime == "" {
+			log.Println("[FinalizerModel.Finalize] missing required fields in final response")
+			return "Не удалось обработать ответ модели"
+		}
 	}
 
-	log.Printf("[FinalizerModel.Finalize] REQUEST body:\n%s", string(reqBody))
-
-	httpClient := &http.Client{Timeout: clientTimeout}
-	req, _ := http.NewRequest(http.MethodPost, url, bytes.NewReader(reqBody))
-	f.prepareHttpRequest(req)
+	// Формируем запрос к модели для финализации
+	req := f.prepareFinalizerRequest(rawJson)
 
-	resp, err := httpClient.Do(req)
+	resp, err := f.Client.Complete(ctx, req)
 	if err != nil {
 		log.Printf("[FinalizerModel.Finalize] Error while making request: %v", err)
 		return "Не удалось обработать ответ модели"
 	}
-	defer func(Body io.ReadCloser) {
-		if cerr := Body.Close(); cerr != nil {
-			log.Printf("[FinalizerModel.Finalize] Body.Close(): %v", cerr)
-		}
-	}(resp.Body)
 
-	log.Printf("[FinalizerModel.Finalize] HTTP status: %d %s", resp.StatusCode, resp.Status)
-
-	rawResp, err := io.ReadAll(resp.Body)
-	if err != nil {
-		log.Printf("[FinalizerModel.Finalize] Error reading body: %v", err)
-		return "Не удалось обработать ответ модели"
-	}
-	log.Printf("[FinalizerModel.Finalize] RAW response:\n%s", string(rawResp))
-
-	if !isRequestSuccessful(resp.StatusCode) {
-		log.Printf("[FinalizerModel.Finalize] Request failed: %d", resp.StatusCode)
-		return "Не удалось обработать ответ модели"
-	}
-
-	var yr finalizerYaResponse
-	if err := json.Unmarshal(rawResp, &yr); err != nil {
-		log.Printf("[FinalizerModel.Finalize] decode yandex response: %v", err)
-		return "Не удалось обработать ответ модели"
-	}
-
-	if len(yr.Result.Alternatives) == 0 {
-		log.Println("[FinalizerModel.Finalize] no alternatives in response")
-		return "Не удалось обработать ответ модели"
-	}
-
-	modelText := yr.Result.Alternatives[0].Message.Text
-	modelText = stripCodeFence(modelText)
+	modelText := stripCodeFence(resp.Text)
 
 	if strings.TrimSpace(modelText) == "" {
 		log.Println("[FinalizerModel.Finalize] empty text in alternative")
@@ -160,43 +93,30 @@ func (f *FinalizerModel) Finalize(rawJson string) string {
 	}
 
 	// Добавляем информацию о версии модели
-	responseText = fmt.Sprintf("%s\n\n📱 Модель: %s", responseText, yr.Result.ModelVersion)
+	responseText = fmt.Sprintf("%s\n\n📱 Модель: %s", responseText, resp.ModelVersion)
 
 	return responseText
 }
 
-func (f *FinalizerModel) prepareFinalizerRequest(rawJson string) ([]byte, error) {
+func (f *FinalizerModel) prepareFinalizerRequest(rawJson string) llm.Request {
 	// Создаем системное сообщение с правилами
-	systemMsg := MessageYandexGpt{
+	systemMsg := llm.Message{
 		Role: "system",
 		Text: f.ruleText,
 	}
 
 	// Создаем пользовательское сообщение с final ответом
-	userMsg := MessageYandexGpt{
+	userMsg := llm.Message{
 		Role: "user",
 		Text: fmt.Sprintf("final_response: %s", rawJson),
 	}
 
-	r := finalizerRequest{
-		ModelURI: fmt.Sprintf("gpt://%s/%v", f.FolderID, f.modelVersion),
-		Messages: []MessageYandexGpt{systemMsg, userMsg},
+	return llm.Request{
+		Model:    f.Model,
+		Messages: []llm.Message{systemMsg, userMsg},
+		Options: llm.Options{
+			Temperature: 0.1,
+			MaxTokens:   1000,
+		},
 	}
-
-	r.CompletionOptions.Stream = false
-	r.CompletionOptions.Temperature = 0.1
-	r.CompletionOptions.MaxTokens = 1000
-
-	req, err := json.MarshalIndent(r, "", "  ")
-	if err != nil {
-		log.Printf("[FinalizerModel.prepareFinalizerRequest] Error while encoding request: %v", err)
-		return nil, err
-	}
-
-	return req, nil
-}
-
-func (f *FinalizerModel) prepareHttpRequest(req *http.Request) {
-	req.Header.Set("Authorization", "Api-Key "+f.ApiKey)
-	req.Header.Set("Content-Type", "application/json")
 }
@@ -1,6 +0,0 @@
-package yandex
-
-type MessageYandexGpt struct {
-	Role string `json:"role"`
-	Text string `json:"text"`
-}
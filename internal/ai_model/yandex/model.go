@@ -2,57 +2,60 @@ package yandex
 
 import (
 	"adventBot/internal/ai_model"
+	"adventBot/internal/ai_model/llm"
 	"adventBot/internal/ai_model/yandex/summary/prompt"
 	"adventBot/internal/config"
 	dbmessage "adventBot/internal/db/message"
+	"adventBot/internal/db/pending"
+	"adventBot/internal/db/store"
 	"adventBot/internal/db/task"
-	"bytes"
+	"adventBot/internal/recurrence"
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
+	"slices"
 	"strings"
 	"time"
 )
 
-const url = "https://llm.api.cloud.yandex.net/foundationModels/v1/completion"
-const clientTimeout = 60 * time.Second
 const failureRequestReply = "Не удалось выполнить запрос. Повторите позже"
 const modelTemperature = 0.3
 
-type messages []MessageYandexGpt
+const (
+	proModel  = "yandexgpt-5-pro/latest"
+	liteModel = "yandexgpt-5-lite/latest"
+)
+
+type messages []llm.Message
 
 type AiModelYandex struct {
-	IamToken       string
-	FolderID       string
-	system         MessageYandexGpt
-	systemCoT      MessageYandexGpt
-	Repository     dbmessage.Repository
-	TaskRepository task.Repository
-	Finalizer      *FinalizerModel
-	Summarizer     *prompt.Summarizer
+	Client     llm.Client
+	system     llm.Message
+	systemCoT  llm.Message
+	Store      *store.Store
+	Repository dbmessage.Repository
+	Finalizer  *FinalizerModel
+	Summarizer *prompt.Summarizer
 }
 
-func NewAiModelYandex(cfg *config.Config, folderId string, r dbmessage.Repository, tr task.Repository) *AiModelYandex {
+func NewAiModelYandex(cfg *config.Config, client llm.Client, st *store.Store) *AiModelYandex {
 	cotRulePath := cfg.RulePathCot
 
 	return &AiModelYandex{
-		IamToken: cfg.ApiKey,
-		FolderID: folderId,
-		system: MessageYandexGpt{
+		Client: client,
+		system: llm.Message{
 			Role: "system",
 			Text: ai_model.MustReadFile(cfg.RulePath),
 		},
-		systemCoT: MessageYandexGpt{
+		systemCoT: llm.Message{
 			Role: "system",
 			Text: ai_model.MustReadFile(cotRulePath),
 		},
-		Repository:     r,
-		TaskRepository: tr,
-		Finalizer:      NewFinalizerModel(cfg, folderId, "yandexgpt-5-lite/latest"),
-		Summarizer:     prompt.NewSummarizer(500, 500, 1000, cfg.ApiKey, fmt.Sprintf("gpt://%s/%v", cfg.FolderId, "yandexgpt-5-lite/latest")),
+		Store:      st,
+		Repository: st.Messages,
+		Finalizer:  NewFinalizerModel(cfg, client, liteModel),
+		Summarizer: prompt.NewSummarizer(500, 500, 1000, client, liteModel),
 	}
 }
 
@@ -60,67 +63,98 @@ func (a *AiModelYandex) GetUserRole() ai_model.Role {
 	return &user
 }
 
-func (a *AiModelYandex) AskGpt(ctx context.Context, chatId int64, inputForm ai_model.InputForm, isCot bool) string {
+func (a *AiModelYandex) AskGpt(ctx context.Context, chatId int64, inputForm ai_model.InputForm, isCot bool) ai_model.Reply {
 
 	log.Println("[AiModelYandex.AskGpt] input form: ", inputForm)
 
-	if !a.checkAuthorizationInfo() {
-		log.Println("[AiModelYandex.AskGpt] IamToken or FolderID is empty")
-		return failureRequestReply
+	req := a.prepareModelRequest(ctx, chatId, inputForm, isCot)
+	if body, err := json.MarshalIndent(req, "", "  "); err == nil {
+		log.Printf("[AiModelYandex.AskGpt] REQUEST body:\n%s", string(body))
 	}
 
-	reqBody, err := a.prepareModelRequest(inputForm, isCot)
-	if err != nil {
-		log.Println("[AiModelYandex.prepareModelRequest] Failed to prepare model request", err)
-		return failureRequestReply
+	ans, fail := a.complete(ctx, req)
+	if fail != nil {
+		return *fail
 	}
 
-	log.Printf("[AiModelYandex.AskGpt] REQUEST body:\n%s", string(reqBody))
+	// Дату в неверном формате модель исправляет сама: ей возвращается её ответ вместе с ошибкой разбора
+	for attempt := 1; attempt <= maxDateTimeRetries && ans.parsed.Mode == modeFinal; attempt++ {
+		problem := invalidDateTimes(ans.parsed.finalTasks())
+		if problem == "" {
+			break
+		}
+		log.Printf("[AiModelYandex.AskGpt] re-asking model about dateTime, attempt %d: %s", attempt, problem)
+		req.Messages = append(req.Messages,
+			llm.Message{Role: model.GetValue(), Text: ans.text},
+			llm.Message{Role: user.GetValue(), Text: problem},
+		)
+		if ans, fail = a.complete(ctx, req); fail != nil {
+			return *fail
+		}
+	}
+	resp, modelText, parsed := ans.resp, ans.text, ans.parsed
 
-	httpClient := &http.Client{Timeout: clientTimeout}
-	req, _ := http.NewRequest(http.MethodPost, url, bytes.NewReader(reqBody))
-	a.prepareHttpRequest(req)
+	switch parsed.Mode {
+	case modeAsk:
+		if parsed.Question == "" {
+			log.Println("[AiModelYandex.AskGpt] ask without question")
+			return ai_model.Reply{Text: failureRequestReply}
+		}
+		return a.ask(ctx, chatId, inputForm, parsed.Question, parsed.Reasoning, resp)
 
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		log.Println("[AiModelYandex.AskGpt] Error while making request:", err)
-		return failureRequestReply
-	}
-	defer func(Body io.ReadCloser) {
-		if cerr := Body.Close(); cerr != nil {
-			log.Println("[AiModelYandex.AskGpt] Body.Close():", cerr)
+	case modeFinal:
+		items := parsed.finalTasks()
+		if len(items) == 0 {
+			log.Printf("[AiModelYandex.AskGpt] final without tasks; raw=%s", modelText)
+			return ai_model.Reply{Text: failureRequestReply}
+		}
+		if problem := invalidDateTimes(items); problem != "" {
+			log.Printf("[AiModelYandex.AskGpt] dateTime still invalid after %d retries: %s", maxDateTimeRetries, problem)
+			return a.ask(ctx, chatId, inputForm, dateTimeQuestion, "", resp)
+		}
+		for i := range items {
+			normalizeFinalTask(&items[i])
 		}
-	}(resp.Body)
 
-	log.Printf("[AiModelYandex.AskGpt] HTTP status: %d %s", resp.StatusCode, resp.Status)
+		// Задачи сохраняются только после подтверждения пользователем (SavePending). История диалога
+		// остаётся, чтобы по кнопке «Изменить» задачу можно было поправить следующим сообщением
+		draft := pending.Pending{ChatID: chatId, Tasks: newTasks(chatId, timeZone(inputForm), items), CreatedAt: time.Now().UTC().Truncate(time.Second)}
+		if err := a.Store.Pending.Upsert(ctx, draft); err != nil {
+			log.Println("[AiModelYandex.AskGpt] failed to save draft:", err)
+			return ai_model.Reply{Text: failureRequestReply}
+		}
 
-	rawResp, err := io.ReadAll(resp.Body)
-	if err != nil {
-		log.Println("[AiModelYandex.AskGpt] Error reading body:", err)
-		return failureRequestReply
-	}
-	log.Printf("[AiModelYandex.AskGpt] RAW response:\n%s", string(rawResp))
+		return ai_model.Reply{Text: draftText(items, parsed.Reasoning, resp), Pending: &draft}
 
-	if !isRequestSuccessful(resp.StatusCode) {
-		log.Println("[AiModelYandex.AskGpt] Request failed:", resp.StatusCode)
-		return failureRequestReply
-	}
+	case modeUpdate, modeDelete:
+		return a.applyChange(ctx, chatId, parsed, resp)
 
-	var yr yaResponse
-	if err := json.Unmarshal(rawResp, &yr); err != nil {
-		log.Println("[AiModelYandex.AskGpt] decode yandex response:", err)
-		return failureRequestReply
+	default:
+		log.Printf("[AiModelYandex.AskGpt] unknown mode: %s; raw=%s", parsed.Mode, modelText)
+		return ai_model.Reply{Text: failureRequestReply}
 	}
-	if len(yr.Result.Alternatives) == 0 {
-		log.Println("[AiModelYandex.AskGpt] no alternatives in response")
-		return failureRequestReply
+}
+
+// modelAnswer — разобранный ответ основной модели.
+type modelAnswer struct {
+	resp   llm.Response
+	text   string
+	parsed response
+}
+
+// complete выполняет запрос и разбирает JSON-ответ модели. Если ответ получить или разобрать не удалось,
+// вместо него возвращается готовый ответ пользователю.
+func (a *AiModelYandex) complete(ctx context.Context, req llm.Request) (modelAnswer, *ai_model.Reply) {
+	resp, err := a.Client.Complete(ctx, req)
+	if err != nil {
+		log.Println("[AiModelYandex.AskGpt] Error while making request:", err)
+		return modelAnswer{}, &ai_model.Reply{Text: failureRequestReply}
 	}
-	log.Printf("response:\n%s", yr)
-	modelText := yr.Result.Alternatives[0].Message.Text
-	modelText = stripCodeFence(modelText)
+
+	modelText := stripCodeFence(resp.Text)
 	if strings.TrimSpace(modelText) == "" {
 		log.Println("[AiModelYandex.AskGpt] empty text in alternative")
-		return failureRequestReply
+		return modelAnswer{}, &ai_model.Reply{Text: failureRequestReply}
 	}
 
 	var parsed response
@@ -128,91 +162,84 @@ func (a *AiModelYandex) AskGpt(ctx context.Context, chatId int64, inputForm ai_m
 		log.Printf("[AiModelYandex.AskGpt] cannot parse model JSON: %v; text=%s", err, modelText)
 		// Если не удалось распарсить JSON, возможно модель вернула обычный текст
 		// В этом случае возвращаем текст как есть для режима ask
-		return fmt.Sprintf("%s\n\n📱 Модель: %s", modelText, yr.Result.ModelVersion)
+		return modelAnswer{}, &ai_model.Reply{Text: fmt.Sprintf("%s\n\n📱 Модель: %s", modelText, resp.ModelVersion)}
 	}
+	return modelAnswer{resp: resp, text: modelText, parsed: parsed}, nil
+}
 
-	switch parsed.Mode {
-	case modeAsk:
-		last := inputForm.History[0]
-		log.Println("[AiModelYandex.AskGpt] last history:", last)
+// ask задаёт пользователю уточняющий вопрос и запоминает его вместе с последним сообщением пользователя,
+// чтобы следующий ответ модель получила в контексте.
+func (a *AiModelYandex) ask(ctx context.Context, chatId int64, inputForm ai_model.InputForm, question, reasoning string, resp llm.Response) ai_model.Reply {
+	last := inputForm.History[0]
+	log.Println("[AiModelYandex.AskGpt] last history:", last)
 
-		if parsed.Question == "" {
-			log.Println("[AiModelYandex.AskGpt] ask without question")
-			return failureRequestReply
-		}
-		if dberr := a.Repository.Upsert(ctx, chatId, last.Role, last.Message, last.Timestamp); dberr != nil {
-			log.Println("[AiModelYandex.AskGpt] Repository.Upsert user error:", err)
-		}
+	if err := a.Repository.Upsert(ctx, chatId, last.Role, last.Message, last.Timestamp); err != nil {
+		log.Println("[AiModelYandex.AskGpt] Repository.Upsert user error:", err)
+	}
 
-		currTime := int(time.Now().UnixMilli()) / 1000
+	currTime := int(time.Now().UnixMilli()) / 1000
 
-		// Формируем ответ с рассуждениями, если они есть
-		responseText := parsed.Question
+	// Формируем ответ с рассуждениями, если они есть
+	responseText := question
 
-		if dberr := a.Repository.Upsert(ctx, chatId, model.GetValue(), responseText, currTime); dberr != nil {
-			log.Println("[AiModelYandex.AskGpt] Repository.Upsert assistant error:", err)
-		}
+	if err := a.Repository.Upsert(ctx, chatId, model.GetValue(), responseText, currTime); err != nil {
+		log.Println("[AiModelYandex.AskGpt] Repository.Upsert assistant error:", err)
+	}
 
-		if parsed.Reasoning != "" {
-			responseText = fmt.Sprintf("%s\n\n%s", parsed.Reasoning, parsed.Question)
-		}
+	if reasoning != "" {
+		responseText = fmt.Sprintf("%s\n\n%s", reasoning, question)
+	}
 
-		// Добавляем информацию о версии модели и токенах
-		responseText = fmt.Sprintf("%s\n\n📱 Модель: %s\n🔤 Токены: %s/%s (вход/выход)",
-			responseText, yr.Result.ModelVersion,
-			yr.Result.Usage.InputTextTokens, yr.Result.Usage.CompletionTokens)
+	// Добавляем информацию о версии модели и токенах
+	responseText = fmt.Sprintf("%s\n\n📱 Модель: %s\n🔤 Токены: %d/%d (вход/выход)",
+		responseText, resp.ModelVersion,
+		resp.Usage.InputTokens, resp.Usage.CompletionTokens)
 
-		return responseText
+	return ai_model.Reply{Text: responseText}
+}
 
-	case modeFinal:
-		if _, err := time.Parse(time.RFC3339, parsed.DateTime); err != nil {
-			log.Println("[AiModelYandex.AskGpt] invalid date time")
-			//TODO отправлять повторный запрос с нужной датой
-		}
+// confirm формирует подтверждение batch через финализатор, а если он не сработал — простым списком задач.
+// resp — ответ основной модели для строки с токенами; nil, если основная модель не вызывалась.
+func (a *AiModelYandex) confirm(ctx context.Context, batch response, resp *llm.Response) string {
+	// Создаем JSON с ответом для передачи в финализатор
+	finalJson, err := json.Marshal(batch)
+	if err != nil {
+		log.Printf("[AiModelYandex.confirm] failed to marshal %s response: %v", batch.Mode, err)
+		return failureRequestReply
+	}
 
-		_, err := a.Repository.DeleteById(ctx, chatId)
-		if err != nil {
-			log.Println("[AiModelYandex.AskGpt] failed to delete chat history:", err)
+	// Используем финализатор для форматирования ответа
+	finalizedText := a.Finalizer.Finalize(ctx, string(finalJson))
+	if finalizedText == "Не удалось обработать ответ модели" {
+		// Если финализатор не сработал, возвращаем стандартный формат
+		responseText := describeTasks(batch.Tasks)
+		switch batch.Mode {
+		case modeFinal:
+			responseText = "Задачи сохранены:\n" + responseText
+		case modeUpdate:
+			responseText = "Задача изменена:\n" + responseText
+		case modeDelete:
+			responseText = "Задача удалена:\n" + responseText
 		}
-
-		// Создаем JSON с final ответом для передачи в финализатор
-		finalJson, err := json.Marshal(parsed)
-		if err != nil {
-			log.Printf("[AiModelYandex.AskGpt] failed to marshal final response: %v", err)
-			return failureRequestReply
+		if batch.Reasoning != "" {
+			responseText = fmt.Sprintf("%s\n\n%s", batch.Reasoning, responseText)
 		}
-
-		a.saveTask(ctx, chatId, finalJson)
-
-		// Используем финализатор для форматирования ответа
-		finalizedText := a.Finalizer.Finalize(string(finalJson))
-		if finalizedText == "Не удалось обработать ответ модели" {
-			// Если финализатор не сработал, возвращаем стандартный формат
-			responseText := fmt.Sprintf(
-				"Задача: %s\nДата/время: %s\nМесто: %s",
-				parsed.Task, parsed.DateTime, parsed.Location,
-			)
-			if parsed.Reasoning != "" {
-				responseText = fmt.Sprintf("%s\n\n%s", parsed.Reasoning, responseText)
-			}
-			responseText = fmt.Sprintf("%s\n\n📱 Модель: %s", responseText, yr.Result.ModelVersion)
+		if resp == nil {
 			return responseText
 		}
-
-		// Добавляем информацию о версии модели и токенах
-		finalizedText = fmt.Sprintf("%s\n\n Токены: %s/%s (вход/выход)",
-			finalizedText, yr.Result.Usage.InputTextTokens, yr.Result.Usage.CompletionTokens,
-		)
-
+		return fmt.Sprintf("%s\n\n📱 Модель: %s", responseText, resp.ModelVersion)
+	}
+	if resp == nil {
 		return finalizedText
-
-	default:
-		log.Printf("[AiModelYandex.AskGpt] unknown mode: %s; raw=%s", parsed.Mode, modelText)
-		return failureRequestReply
 	}
+
+	// Добавляем информацию о версии модели и токенах
+	return fmt.Sprintf("%s\n\n Токены: %d/%d (вход/выход)",
+		finalizedText, resp.Usage.InputTokens, resp.Usage.CompletionTokens,
+	)
 }
 
-func (a *AiModelYandex) AskWithTemperature(text string, temperature float64) (reply string, tmp float64) {
+func (a *AiModelYandex) AskWithTemperature(ctx context.Context, text string, temperature float64) (reply string, tmp float64) {
 	tmp = temperature
 	if tmp < 0 {
 		tmp = modelTemperature
@@ -220,71 +247,29 @@ func (a *AiModelYandex) AskWithTemperature(text string, temperature float64) (re
 
 	log.Printf("[AiModelYandex.AskWithTemperature] start request %v, %v", text, tmp)
 
-	r := request{
-		ModelURI: fmt.Sprintf("gpt://%s/yandexgpt-5-pro/latest", a.FolderID),
-		Messages: []MessageYandexGpt{{Role: "user", Text: text}},
+	req := llm.Request{
+		Model:    proModel,
+		Messages: []llm.Message{{Role: "user", Text: text}},
+		Options: llm.Options{
+			Temperature: tmp,
+			MaxTokens:   a.Summarizer.MaxOutputTokens,
+		},
 	}
 
-	r.CompletionOptions.Stream = false
-	r.CompletionOptions.Temperature = tmp
-	r.CompletionOptions.MaxTokens = a.Summarizer.MaxOutputTokens
-
-	b, _ := json.Marshal(r)
-
-	httpClient := &http.Client{Timeout: clientTimeout}
-	req, _ := http.NewRequest(http.MethodPost, url, bytes.NewReader(b))
-	a.prepareHttpRequest(req)
-
-	resp, err := httpClient.Do(req)
+	resp, err := a.Client.Complete(ctx, req)
 	if err != nil {
-		log.Println("[AiModelYandex.AskGpt] Error making request:", err)
+		log.Println("[AiModelYandex.AskWithTemperature] Error making request:", err)
 		return failureRequestReply, tmp
 	}
-	defer func(Body io.ReadCloser) {
-		err := Body.Close()
-		if err != nil {
-			log.Println("[AiModelYandex.AskGpt] Body.Close(): ", err)
-		}
-	}(resp.Body)
 
-	if !isRequestSuccessful(resp.StatusCode) {
-		log.Println("[AiModelYandex.AskGpt] Request failed: ", resp.StatusCode)
-		return failureRequestReply, tmp
-	}
-
-	result := struct {
-		Result struct {
-			Alternatives []struct {
-				Message struct {
-					Role string `json:"role"`
-					Text string `json:"text"`
-				} `json:"MessageYandexGpt"`
-			} `json:"alternatives"`
-		} `json:"result"`
-	}{}
-
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		log.Println("[AiModelYandex.AskGpt] Error while decoding response: ", err)
-		return failureRequestReply, tmp
-	}
-
-	if len(result.Result.Alternatives) == 0 {
-		log.Println("[AiModelYandex.AskGpt] No alternatives found")
-		return failureRequestReply, tmp
-	}
+	log.Printf("[AiModelYandex.AskWithTemperature] result: %+v", resp)
 
-	log.Printf("[AiModelYandex.AskGpt] result: %+v", result)
-
-	return result.Result.Alternatives[0].Message.Text, tmp
+	return resp.Text, tmp
 }
 
 // --- private ---
 
-func (a *AiModelYandex) checkAuthorizationInfo() bool {
-	return a.IamToken != "" && a.FolderID != ""
-}
-
-func (a *AiModelYandex) prepareModelRequest(form ai_model.InputForm, isCot bool) ([]byte, error) {
+func (a *AiModelYandex) prepareModelRequest(ctx context.Context, chatId int64, form ai_model.InputForm, isCot bool) llm.Request {
 	dst := make(messages, 0, len(form.History))
 	history := make([]string, 0, len(form.History))
 
@@ -299,9 +284,9 @@ func (a *AiModelYandex) prepareModelRequest(form ai_model.InputForm, isCot bool)
 		history = append(history, m.Message)
 	}
 
-	sumSys, sumHistory := a.Summarizer.Summarize(sys, history)
+	sumSys, sumHistory := a.Summarizer.Summarize(ctx, sys, history)
 	if sumSys != "" {
-		dst = append(dst, MessageYandexGpt{
+		dst = append(dst, llm.Message{
 			Role: "system",
 			Text: sumSys,
 		})
@@ -312,9 +297,11 @@ func (a *AiModelYandex) prepareModelRequest(form ai_model.InputForm, isCot bool)
 			dst = append(dst, a.system)
 		}
 	}
+	// Открытые задачи с ID — чтобы модель могла изменить или удалить задачу вместо создания новой
+	dst = append(dst, a.upcomingTasks(ctx, chatId))
 	if sumHistory != nil {
 		for _, m := range sumHistory {
-			dst = append(dst, MessageYandexGpt{
+			dst = append(dst, llm.Message{
 				Role: "user",
 				Text: m,
 			})
@@ -325,34 +312,17 @@ func (a *AiModelYandex) prepareModelRequest(form ai_model.InputForm, isCot bool)
 		}
 	}
 
-	r := request{
-		ModelURI: fmt.Sprintf("gpt://%s/yandexgpt-5-pro/latest", a.FolderID),
+	return llm.Request{
+		Model:    proModel,
 		Messages: dst.filterEmpty(),
+		Options: llm.Options{
+			Temperature: modelTemperature,
+			MaxTokens:   a.Summarizer.MaxOutputTokens,
+		},
 	}
-
-	r.CompletionOptions.Stream = false
-	r.CompletionOptions.Temperature = modelTemperature
-	r.CompletionOptions.MaxTokens = a.Summarizer.MaxOutputTokens
-
-	req, err := json.MarshalIndent(r, "", "  ")
-	if err != nil {
-		log.Println("[AiModelYandex.prepareModelRequest] Error while encoding request:", err)
-		return nil, err
-	}
-
-	return req, nil
-}
-
-func (a *AiModelYandex) prepareHttpRequest(req *http.Request) {
-	req.Header.Set("Authorization", "Api-Key "+a.IamToken)
-	req.Header.Set("Content-Type", "application/json")
 }
 
-func isRequestSuccessful(status int) bool {
-	return status >= 200 && status < 300
-}
-
-func mapToInternal(src dbmessage.Message) MessageYandexGpt {
+func mapToInternal(src dbmessage.Message) llm.Message {
 	text := src.Message
 	if src.TimeZone != "" {
 		text += fmt.Sprintf(" (timeZone: %s)", src.TimeZone)
@@ -361,7 +331,7 @@ func mapToInternal(src dbmessage.Message) MessageYandexGpt {
 		text += fmt.Sprintf(" [timestamp: %d]", src.Timestamp)
 	}
 
-	return MessageYandexGpt{
+	return llm.Message{
 		Role: src.Role,
 		Text: text,
 	}
@@ -396,17 +366,70 @@ func stripCodeFence(s string) string {
 	return strings.TrimSpace(s)
 }
 
-func (y *AiModelYandex) saveTask(ctx context.Context, chatId int64, raw []byte) {
-	var t task.Task
-	err := json.Unmarshal(raw, &t)
-	if err != nil {
-		log.Println("[AiModelYandex.saveTask] Error while decoding task:", err)
-		err = nil
+// normalizeFinalTask проверяет поля задачи из final ответа: некорректное правило повторения
+// отбрасывается, и задача сохраняется как разовая. Дата к этому моменту уже проверена invalidDateTimes.
+func normalizeFinalTask(t *finalTask) {
+	if t.Recurrence != "" {
+		if rule, err := recurrence.Parse(t.Recurrence); err != nil {
+			log.Printf("[AiModelYandex.AskGpt] invalid recurrence=%q, saving as one-off: %v", t.Recurrence, err)
+			t.Recurrence = ""
+		} else {
+			t.Recurrence = rule.String()
+		}
+	}
+}
+
+// newTasks переводит задачи final ответа в задачи чата; ID им назначит репозиторий при сохранении.
+func newTasks(chatId int64, tz string, items []finalTask) []task.Task {
+	tasks := make([]task.Task, 0, len(items))
+	for _, it := range items {
+		tasks = append(tasks, task.Task{
+			ChatID:       chatId,
+			Task:         it.Task,
+			Location:     it.Location,
+			DateTime:     it.DateTime,
+			RemindBefore: it.RemindBefore,
+			Recurrence:   it.Recurrence,
+			TimeZone:     tz,
+		})
+	}
+	return tasks
+}
+
+// timeZone — часовой пояс чата из последнего сообщения истории, где он указан.
+func timeZone(form ai_model.InputForm) string {
+	for i := len(form.History) - 1; i >= 0; i-- {
+		if tz := form.History[i].TimeZone; tz != "" {
+			return tz
+		}
+	}
+	return ""
+}
+
+// toFinalTask — обратное преобразование для подтверждений через финализатор.
+func toFinalTask(t task.Task) finalTask {
+	return finalTask{
+		Task:         t.Task,
+		DateTime:     t.DateTime,
+		Location:     t.Location,
+		RemindBefore: t.RemindBefore,
+		Recurrence:   t.Recurrence,
 	}
-	t.ChatID = chatId
-	log.Printf("[AiModelYandex.saveTask] Saving \ntask:%v, \nraw:%s", t, string(raw))
-	err = y.TaskRepository.Upsert(ctx, t)
+}
+
+// saveTasks сохраняет задачи в repo одной транзакцией: либо все, либо ни одной.
+func (y *AiModelYandex) saveTasks(ctx context.Context, repo task.Repository, tasks []task.Task) ([]task.Task, error) {
+	tasks = slices.Clone(tasks)
+
+	log.Printf("[AiModelYandex.saveTasks] Saving %d tasks: %v", len(tasks), tasks)
+	ids, err := repo.CreateAll(ctx, tasks)
 	if err != nil {
-		log.Println("[AiModelYandex.saveTask] Error while saving task:", err)
+		log.Println("[AiModelYandex.saveTasks] Error while saving tasks:", err)
+		return nil, err
+	}
+	for i := range tasks {
+		tasks[i].ID = ids[i]
 	}
+	log.Printf("[AiModelYandex.saveTasks] Saved task ids=%v", ids)
+	return tasks, nil
 }
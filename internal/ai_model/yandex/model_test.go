@@ -0,0 +1,261 @@
+package yandex
+
+import (
+	"adventBot/internal/ai_model"
+	"adventBot/internal/ai_model/llm"
+	"adventBot/internal/ai_model/llm/llmtest"
+	"adventBot/internal/config"
+	"adventBot/internal/db/message"
+	"adventBot/internal/db/store"
+	"adventBot/internal/db/task"
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testChatID = 42
+
+// newTestModel — модель, направленная на фейковый LLM-сервер, с хранилищем в памяти.
+func newTestModel(t *testing.T, answers ...string) (*AiModelYandex, *llmtest.Server) {
+	t.Helper()
+
+	dir := t.TempDir()
+	rule := func(name string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("rule "+name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	cfg := config.Config{
+		RulePath:          rule("rule.txt"),
+		RulePathCot:       rule("rule_cot.txt"),
+		RulePathFinalizer: rule("rule_finalizer.txt"),
+	}
+
+	srv := llmtest.NewServer(answers...)
+	t.Cleanup(srv.Close)
+	return NewAiModelYandex(&cfg, srv.YandexClient(), store.NewMemory()), srv
+}
+
+func userInput(text string) ai_model.InputForm {
+	return ai_model.InputForm{History: []message.Message{{
+		Role:      user.GetValue(),
+		Message:   text,
+		TimeZone:  "Europe/Moscow",
+		Timestamp: int(time.Now().Unix()),
+	}}}
+}
+
+func TestAskGptAsk(t *testing.T) {
+	a, srv := newTestModel(t, llmtest.Fenced(llmtest.Ask("Во сколько приём?", "dateTime")))
+	ctx := context.Background()
+
+	reply := a.AskGpt(ctx, testChatID, userInput("завтра к врачу"), true)
+	if !strings.Contains(reply.Text, "Во сколько приём?") || reply.Pending != nil {
+		t.Fatalf("reply = %+v", reply)
+	}
+
+	// Вопрос и сообщение пользователя остаются в истории для следующего запроса
+	history, found, err := a.Repository.GetById(ctx, testChatID, "Europe/Moscow")
+	if err != nil || !found || len(history) != 2 {
+		t.Fatalf("history = %+v, %v, %v", history, found, err)
+	}
+	if srv.Pending() != 0 {
+		t.Errorf("%d answers left", srv.Pending())
+	}
+}
+
+func TestAskGptFinalDraftAndSave(t *testing.T) {
+	a, _ := newTestModel(t,
+		llmtest.Final("Врач", "2030-01-10T10:00:00+03:00", "Клиника"),
+		llmtest.Finalized("Записал: врач 10 января в 10:00"),
+	)
+	ctx := context.Background()
+
+	reply := a.AskGpt(ctx, testChatID, userInput("врач 10 января в 10 в клинике"), true)
+	if reply.Pending == nil || !strings.Contains(reply.Text, "Проверь, всё ли верно") {
+		t.Fatalf("reply = %+v", reply)
+	}
+
+	// До подтверждения ничего не сохранено
+	tasks, err := a.Store.Tasks.GetAll(ctx, testChatID)
+	if err != nil || len(tasks) != 0 {
+		t.Fatalf("tasks before save = %+v, %v", tasks, err)
+	}
+
+	saved, err := a.SavePending(ctx, testChatID, reply.Pending.CreatedAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(saved.Text, "Записал") || len(saved.Tasks) != 1 {
+		t.Fatalf("saved = %+v", saved)
+	}
+
+	tasks, err = a.Store.Tasks.GetAll(ctx, testChatID)
+	if err != nil || len(tasks) != 1 || tasks[0].Task != "Врач" || tasks[0].TimeZone != "Europe/Moscow" {
+		t.Fatalf("tasks after save = %+v, %v", tasks, err)
+	}
+
+	// Повторное нажатие — черновик уже неактуален
+	if _, err := a.SavePending(ctx, testChatID, reply.Pending.CreatedAt); err != ai_model.ErrDraftNotFound {
+		t.Errorf("second save: err = %v", err)
+	}
+}
+
+// createTask сохраняет задачу в хранилище модели и возвращает её ID.
+func createTask(t *testing.T, a *AiModelYandex, chatID int64, text string, dateTime string) int64 {
+	t.Helper()
+	id, err := a.Store.Tasks.Create(context.Background(), task.Task{ChatID: chatID, Task: text, DateTime: dateTime, Location: "Клиника"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestAskGptUpdateTask(t *testing.T) {
+	a, srv := newTestModel(t)
+	ctx := context.Background()
+	id := createTask(t, a, testChatID, "Врач", "2030-01-10T10:00:00+03:00")
+	srv.Script(
+		llmtest.Update(id, map[string]any{"dateTime": "2030-01-12T10:00:00+03:00"}),
+		llmtest.Finalized("Перенёс врача на 12 января"),
+	)
+
+	reply := a.AskGpt(ctx, testChatID, userInput("перенеси врача на пятницу"), true)
+	if !strings.Contains(reply.Text, "Перенёс врача на 12 января") || reply.Pending != nil {
+		t.Fatalf("reply = %+v", reply)
+	}
+
+	got, found, err := a.Store.Tasks.GetByID(ctx, testChatID, id)
+	if err != nil || !found || got.DateTime != "2030-01-12T10:00:00+03:00" || got.Task != "Врач" || got.Location != "Клиника" {
+		t.Fatalf("task after update = %+v, %t, %v", got, found, err)
+	}
+
+	reqs := srv.Requests()
+	if len(reqs) != 2 {
+		t.Fatalf("got %d requests, want the change and the finalizer", len(reqs))
+	}
+	// Модель видит задачи чата с ID, а финализатор — изменённую задачу
+	if !containsMessage(reqs[0].Messages, `"id":`+strconv.FormatInt(id, 10)) {
+		t.Errorf("change request has no upcoming task id=%d: %+v", id, reqs[0].Messages)
+	}
+	if !containsMessage(reqs[1].Messages, `"mode":"update"`) || !containsMessage(reqs[1].Messages, "2030-01-12T10:00:00+03:00") {
+		t.Errorf("finalizer request = %+v", reqs[1].Messages)
+	}
+
+	// Изменение завершает диалог, как и сохранение задачи
+	if history, found, _ := a.Repository.GetById(ctx, testChatID, "Europe/Moscow"); found {
+		t.Errorf("history after update = %+v", history)
+	}
+}
+
+func TestAskGptDeleteTask(t *testing.T) {
+	a, srv := newTestModel(t)
+	ctx := context.Background()
+	id := createTask(t, a, testChatID, "Врач", "2030-01-10T10:00:00+03:00")
+	srv.Script(llmtest.Delete(id), llmtest.Finalized("Удалил запись к врачу"))
+
+	reply := a.AskGpt(ctx, testChatID, userInput("отмени врача"), true)
+	if !strings.Contains(reply.Text, "Удалил запись к врачу") {
+		t.Fatalf("reply = %+v", reply)
+	}
+	if _, found, _ := a.Store.Tasks.GetByID(ctx, testChatID, id); found {
+		t.Error("task still exists after delete")
+	}
+	if reqs := srv.Requests(); len(reqs) != 2 || !containsMessage(reqs[1].Messages, `"mode":"delete"`) {
+		t.Errorf("requests = %+v", reqs)
+	}
+}
+
+func TestAskGptChangeUnknownTask(t *testing.T) {
+	a, srv := newTestModel(t)
+	ctx := context.Background()
+	foreign := createTask(t, a, testChatID+1, "Чужой врач", "2030-01-10T10:00:00+03:00")
+
+	for name, answer := range map[string]string{
+		"unknown id": llmtest.Update(foreign+100, map[string]any{"dateTime": "2030-01-12T10:00:00+03:00"}),
+		"foreign id": llmtest.Delete(foreign),
+	} {
+		srv.Script(answer)
+		before := len(srv.Requests())
+
+		reply := a.AskGpt(ctx, testChatID, userInput("удали врача"), true)
+		if reply.Text != taskNotFoundReply {
+			t.Errorf("%s: reply = %q", name, reply.Text)
+		}
+		// Финализатор не вызывается
+		if n := len(srv.Requests()) - before; n != 1 {
+			t.Errorf("%s: got %d requests, want 1", name, n)
+		}
+	}
+
+	if got, found, _ := a.Store.Tasks.GetByID(ctx, testChatID+1, foreign); !found || got.DateTime != "2030-01-10T10:00:00+03:00" {
+		t.Errorf("foreign task = %+v, %t", got, found)
+	}
+}
+
+func containsMessage(messages []llm.Message, substr string) bool {
+	for _, m := range messages {
+		if strings.Contains(m.Text, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestAskGptReasksInvalidDateTime(t *testing.T) {
+	a, srv := newTestModel(t,
+		llmtest.Final("Врач", "завтра в 10", ""),
+		llmtest.Final("Врач", "2030-01-10T10:00:00+03:00", ""),
+	)
+
+	reply := a.AskGpt(context.Background(), testChatID, userInput("врач завтра в 10"), true)
+	if reply.Pending == nil {
+		t.Fatalf("reply = %+v", reply)
+	}
+
+	reqs := srv.Requests()
+	if len(reqs) != 2 {
+		t.Fatalf("got %d completion requests, want 2", len(reqs))
+	}
+	last := reqs[1].Messages[len(reqs[1].Messages)-1]
+	if last.Role != user.GetValue() || !strings.Contains(last.Text, "RFC-3339") {
+		t.Errorf("re-ask message = %+v", last)
+	}
+}
+
+func TestAskGptGivesUpOnInvalidDateTime(t *testing.T) {
+	invalid := llmtest.Final("Врач", "когда-нибудь", "")
+	a, _ := newTestModel(t, invalid, invalid, invalid)
+
+	reply := a.AskGpt(context.Background(), testChatID, userInput("врач когда-нибудь"), true)
+	if reply.Pending != nil || !strings.Contains(reply.Text, dateTimeQuestion) {
+		t.Fatalf("reply = %+v", reply)
+	}
+}
+
+func TestFinalize(t *testing.T) {
+	a, srv := newTestModel(t, llmtest.Fenced(llmtest.Finalized("Готово")))
+
+	raw := llmtest.Final("Врач", "2030-01-10T10:00:00+03:00", "Клиника")
+	text := a.Finalizer.Finalize(context.Background(), raw)
+	if !strings.HasPrefix(text, "Готово") || !strings.Contains(text, "llmtest") {
+		t.Errorf("Finalize = %q", text)
+	}
+	if reqs := srv.Requests(); len(reqs) != 1 || !strings.Contains(reqs[0].Messages[1].Text, raw) {
+		t.Errorf("requests = %+v", reqs)
+	}
+
+	// Ответ без обязательных полей до модели не доходит
+	if text := a.Finalizer.Finalize(context.Background(), llmtest.Final("", "", "")); text != "Не удалось обработать ответ модели" {
+		t.Errorf("Finalize without task = %q", text)
+	}
+	if len(srv.Requests()) != 1 {
+		t.Error("invalid final answer was sent to the finalizer")
+	}
+}
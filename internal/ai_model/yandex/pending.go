@@ -0,0 +1,153 @@
+package yandex
+
+import (
+	"adventBot/internal/ai_model"
+	"adventBot/internal/ai_model/llm"
+	"adventBot/internal/db/pending"
+	"adventBot/internal/db/store"
+	"adventBot/internal/db/task"
+	"adventBot/internal/recurrence"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// maxDateTimeRetries — сколько раз переспрашивать модель, если она вернула dateTime не в формате RFC-3339.
+const maxDateTimeRetries = 2
+
+// dateTimeQuestion задаётся пользователю, если модель так и не смогла вернуть корректную дату.
+const dateTimeQuestion = "Не получилось разобрать дату и время. Уточни, пожалуйста, когда это будет?"
+
+const draftNotFoundReply = "Этот черновик уже неактуален."
+
+// invalidDateTimes возвращает сообщение для модели со списком задач, у которых dateTime не разбирается,
+// или пустую строку, если все даты корректны.
+func invalidDateTimes(items []finalTask) string {
+	var problems []string
+	for _, it := range items {
+		if _, err := time.Parse(time.RFC3339, it.DateTime); err != nil {
+			log.Printf("[AiModelYandex.invalidDateTimes] task=%q dateTime=%q: %v", it.Task, it.DateTime, err)
+			problems = append(problems, fmt.Sprintf("у задачи «%s» dateTime «%s» не в формате RFC-3339", it.Task, it.DateTime))
+		}
+	}
+	if len(problems) == 0 {
+		return ""
+	}
+	return "Ошибка разбора ответа: " + strings.Join(problems, "; ") +
+		". dateTime должен быть с датой, временем и смещением часового пояса пользователя, например 2025-01-31T10:00:00+03:00. Верни исправленный ответ в том же формате."
+}
+
+// describeTasks — задачи простым списком, когда финализатор недоступен или не нужен.
+func describeTasks(items []finalTask) string {
+	lines := make([]string, 0, len(items))
+	for _, it := range items {
+		line := fmt.Sprintf("Задача: %s\nДата/время: %s\nМесто: %s", it.Task, it.DateTime, it.Location)
+		if it.Recurrence != "" {
+			if rule, err := recurrence.Parse(it.Recurrence); err == nil {
+				line += "\nПовтор: " + rule.Describe()
+			}
+		}
+		if it.RemindBefore != nil {
+			line += fmt.Sprintf("\nНапомнить за %d мин", *it.RemindBefore)
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n\n")
+}
+
+// draftText — черновик, который пользователь подтверждает кнопками перед сохранением.
+func draftText(items []finalTask, reasoning string, resp llm.Response) string {
+	text := "Проверь, всё ли верно:\n\n" + describeTasks(items)
+	if reasoning != "" {
+		text = fmt.Sprintf("%s\n\n%s", reasoning, text)
+	}
+	return fmt.Sprintf("%s\n\n📱 Модель: %s\n🔤 Токены: %d/%d (вход/выход)",
+		text, resp.ModelVersion,
+		resp.Usage.InputTokens, resp.Usage.CompletionTokens)
+}
+
+// SavePending сохраняет задачи черновика и очищает историю диалога одной транзакцией.
+func (a *AiModelYandex) SavePending(ctx context.Context, chatId int64, createdAt time.Time) (ai_model.Reply, error) {
+	var saved []task.Task
+	err := a.withDraft(ctx, chatId, createdAt, func(tx store.Repositories, draft pending.Pending) error {
+		var err error
+		if saved, err = a.saveTasks(ctx, tx.Tasks, draft.Tasks); err != nil {
+			return err
+		}
+		if _, err = tx.Messages.DeleteById(ctx, chatId); err != nil {
+			log.Println("[AiModelYandex.SavePending] failed to delete chat history:", err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return draftFailure("SavePending", chatId, err)
+	}
+
+	items := make([]finalTask, 0, len(saved))
+	for _, t := range saved {
+		items = append(items, toFinalTask(t))
+	}
+	return ai_model.Reply{Text: a.confirm(ctx, response{Mode: modeFinal, Tasks: items}, nil), Tasks: saved}, nil
+}
+
+// ChangePending убирает черновик, но оставляет его в истории диалога, чтобы следующим сообщением
+// пользователь мог поправить задачи, не повторяя их целиком.
+func (a *AiModelYandex) ChangePending(ctx context.Context, chatId int64, createdAt time.Time) (ai_model.Reply, error) {
+	err := a.withDraft(ctx, chatId, createdAt, func(tx store.Repositories, draft pending.Pending) error {
+		items := make([]finalTask, 0, len(draft.Tasks))
+		for _, t := range draft.Tasks {
+			items = append(items, toFinalTask(t))
+		}
+		text := "Черновик:\n" + describeTasks(items) + "\n\nЧто изменить?"
+		return tx.Messages.Upsert(ctx, chatId, model.GetValue(), text, int(time.Now().Unix()))
+	})
+	if err != nil {
+		return draftFailure("ChangePending", chatId, err)
+	}
+	return ai_model.Reply{Text: "Что изменить? Напиши поправку, например «перенеси на 11:00» или «место — офис»."}, nil
+}
+
+// CancelPending удаляет черновик вместе с историей диалога.
+func (a *AiModelYandex) CancelPending(ctx context.Context, chatId int64, createdAt time.Time) (ai_model.Reply, error) {
+	err := a.withDraft(ctx, chatId, createdAt, func(tx store.Repositories, _ pending.Pending) error {
+		_, err := tx.Messages.DeleteById(ctx, chatId)
+		return err
+	})
+	if err != nil {
+		return draftFailure("CancelPending", chatId, err)
+	}
+	return ai_model.Reply{Text: "Отменено, ничего не сохранил."}, nil
+}
+
+// withDraft выполняет fn над черновиком чата, созданным в createdAt, и удаляет черновик в той же транзакции.
+// Черновик с другим createdAt считается ненайденным: кнопки заменённого черновика ничего не делают.
+func (a *AiModelYandex) withDraft(ctx context.Context, chatId int64, createdAt time.Time, fn func(tx store.Repositories, draft pending.Pending) error) error {
+	return a.Store.WithTx(ctx, func(tx store.Repositories) error {
+		draft, found, err := tx.Pending.GetById(ctx, chatId)
+		if err != nil {
+			return err
+		}
+		if !found || !draft.CreatedAt.Equal(createdAt) {
+			return ai_model.ErrDraftNotFound
+		}
+		if err = fn(tx, draft); err != nil {
+			return err
+		}
+		_, err = tx.Pending.DeleteById(ctx, chatId)
+		return err
+	})
+}
+
+// draftFailure переводит ошибку withDraft в ответ пользователю.
+func draftFailure(method string, chatId int64, err error) (ai_model.Reply, error) {
+	if errors.Is(err, ai_model.ErrDraftNotFound) {
+		log.Printf("[AiModelYandex.%s] no matching draft in chatID=%d", method, chatId)
+		return ai_model.Reply{Text: draftNotFoundReply}, err
+	}
+	log.Printf("[AiModelYandex.%s] chatID=%d err=%v", method, chatId, err)
+	return ai_model.Reply{Text: failureRequestReply}, err
+}
@@ -16,13 +16,3 @@ func (r *yandexRole) GetValue() string {
 	}
 	return ""
 }
-
-type request struct {
-	ModelURI          string `json:"modelUri"`
-	CompletionOptions struct {
-		Stream      bool    `json:"stream"`
-		Temperature float64 `json:"temperature"`
-		MaxTokens   int     `json:"maxTokens"`
-	} `json:"completionOptions"`
-	Messages []MessageYandexGpt `json:"messages"`
-}
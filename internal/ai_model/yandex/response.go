@@ -3,8 +3,10 @@ package yandex
 type mode string
 
 const (
-	modeFinal mode = "final"
-	modeAsk   mode = "ask"
+	modeFinal  mode = "final"
+	modeAsk    mode = "ask"
+	modeUpdate mode = "update"
+	modeDelete mode = "delete"
 )
 
 type property string
@@ -15,38 +17,51 @@ const (
 	propLocation property = "location"
 )
 
-// Response — универсальный ответ модели (final или ask)
+// Response — универсальный ответ модели (final, ask, update или delete)
 type response struct {
-	Mode mode `json:"mode"` // "final" | "ask"
+	Mode mode `json:"mode"` // "final" | "ask" | "update" | "delete"
 
-	// final
-	Task      string `json:"task,omitempty"`
-	DateTime  string `json:"dateTime,omitempty"`
-	Location  string `json:"location,omitempty"`
-	Reasoning string `json:"reasoning,omitempty"` // Полные пошаговые рассуждения модели
+	// final; в update — только изменённые поля
+	Task         string `json:"task,omitempty"`
+	DateTime     string `json:"dateTime,omitempty"`
+	Location     string `json:"location,omitempty"`
+	RemindBefore *int   `json:"remindBefore,omitempty"` // За сколько минут напомнить, если пользователь попросил
+	Recurrence   string `json:"recurrence,omitempty"`   // Правило повторения RRULE, если задача регулярная
+	Reasoning    string `json:"reasoning,omitempty"`    // Полные пошаговые рассуждения модели
+
+	// final с несколькими задачами из одного сообщения
+	Tasks []finalTask `json:"tasks,omitempty"`
 
 	// ask
 	Question string   `json:"question,omitempty"`
 	Property property `json:"property,omitempty"` // "task" | "dateTime" | "location"
+
+	// update, delete: ID задачи из списка upcoming_tasks
+	TaskID int64 `json:"taskId,omitempty"`
+}
+
+// finalTask — одна задача из final ответа.
+type finalTask struct {
+	Task         string `json:"task"`
+	DateTime     string `json:"dateTime"`
+	Location     string `json:"location"`
+	RemindBefore *int   `json:"remindBefore,omitempty"`
+	Recurrence   string `json:"recurrence,omitempty"`
 }
 
-type yaResponse struct {
-	Result struct {
-		Alternatives []struct {
-			Message struct {
-				Role string `json:"role"`
-				Text string `json:"text"`
-			} `json:"message"`
-			Status string `json:"status"`
-		} `json:"alternatives"`
-		Usage struct {
-			InputTextTokens         string `json:"inputTextTokens"`
-			CompletionTokens        string `json:"completionTokens"`
-			TotalTokens             string `json:"totalTokens"`
-			CompletionTokensDetails struct {
-				ReasoningTokens string `json:"reasoningTokens"`
-			} `json:"completionTokensDetails"`
-		} `json:"usage"`
-		ModelVersion string `json:"modelVersion"`
-	} `json:"result"`
+// finalTasks возвращает задачи final ответа: массив tasks, а если его нет — единственную задачу из полей верхнего уровня.
+func (r response) finalTasks() []finalTask {
+	if len(r.Tasks) > 0 {
+		return r.Tasks
+	}
+	if r.Task == "" && r.DateTime == "" {
+		return nil
+	}
+	return []finalTask{{
+		Task:         r.Task,
+		DateTime:     r.DateTime,
+		Location:     r.Location,
+		RemindBefore: r.RemindBefore,
+		Recurrence:   r.Recurrence,
+	}}
 }
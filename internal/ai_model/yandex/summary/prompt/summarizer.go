@@ -1,16 +1,19 @@
 package prompt
 
 import (
-	"adventBot/internal/ai_model"
-	"bytes"
+	"adventBot/internal/ai_model/llm"
+	"context"
+	_ "embed"
 	"encoding/json"
-	"io"
 	"log"
-	"net/http"
 	"sync"
 )
 
-const summarizerUrl = "https://llm.api.cloud.yandex.net/foundationModels/v1/completion"
+//go:embed system_summarizer_rule.txt
+var systemSummarizerRule string
+
+//go:embed history_summarizer_rule.txt
+var historySummarizerRule string
 
 const modelTemperature = 0.3
 
@@ -18,78 +21,47 @@ type Summarizer struct {
 	MaxPromptTokens  int // Максимальное количество токенов промпта на вход
 	MaxHistoryTokens int // Максимальное количество токенов истории переписки на вход
 	MaxOutputTokens  int // Максимальное количество токенов на выход
-	IamToken         string
+	Client           llm.Client
 	Model            string
 	Tokenizer        Tokenizer
 	PromptRule       string // Правило для суммаризации system промпта
 	HistoryRule      string // Правило для суммаризации истории
 }
 
-type summarizerRequest struct {
-	ModelURI string `json:"modelUri"`
-	Messages []struct {
-		Role string `json:"role"`
-		Text string `json:"text"`
-	} `json:"messages"`
-	CompletionOptions struct {
-		Stream      bool    `json:"stream"`
-		Temperature float64 `json:"temperature"`
-		MaxTokens   int     `json:"maxTokens"`
-	} `json:"completionOptions"`
-}
-
-type summarizerResponse struct {
-	Result struct {
-		Alternatives []struct {
-			Message struct {
-				Role string `json:"role"`
-				Text string `json:"text"`
-			} `json:"message"`
-			Status string `json:"status"`
-		} `json:"alternatives"`
-		Usage struct {
-			InputTextTokens  string `json:"inputTextTokens"`
-			CompletionTokens string `json:"completionTokens"`
-			TotalTokens      string `json:"totalTokens"`
-		} `json:"usage"`
-		ModelVersion string `json:"modelVersion"`
-	} `json:"result"`
-}
-
 func NewSummarizer(
 	maxPrompt int,
 	maxHistory int,
 	maxOutput int,
-	iamToken string,
-	modelUri string,
+	client llm.Client,
+	model string,
 ) *Summarizer {
 	return &Summarizer{
 		MaxPromptTokens:  maxPrompt,
 		MaxHistoryTokens: maxHistory,
 		MaxOutputTokens:  maxOutput,
-		IamToken:         iamToken,
-		Model:            modelUri,
-		PromptRule:       ai_model.MustReadFile("./internal/ai_model/yandex/summary/prompt/system_summarizer_rule.txt"),
-		HistoryRule:      ai_model.MustReadFile("./internal/ai_model/yandex/summary/prompt/history_summarizer_rule.txt"),
+		Client:           client,
+		Model:            model,
+		PromptRule:       systemSummarizerRule,
+		HistoryRule:      historySummarizerRule,
 		Tokenizer: Tokenizer{
-			IamToken: iamToken,
-			Model:    modelUri,
+			Client: client,
+			Model:  model,
 		},
 	}
 }
 
-func (s *Summarizer) Summarize(sys string, h []string) (system string, history []string) {
+func (s *Summarizer) Summarize(ctx context.Context, sys string, h []string) (system string, history []string) {
 	system = sys
 	h = nil
 
 	var wg sync.WaitGroup
 
-	if systemTokens := s.Tokenizer.GetTokensCount(sys); systemTokens > s.MaxPromptTokens {
+	if systemTokens := s.Tokenizer.GetTokensCount(ctx, sys); systemTokens > s.MaxPromptTokens {
 		log.Printf("[Summarizer.Summarize] sys tokens: %d, max: %d", systemTokens, s.MaxPromptTokens)
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			system = s.summarizeRule(sys)
+			system = s.summarizeRule(ctx, sys)
 			log.Println("[Summarizer.Summarize] summarized system: ", system)
 		}()
 	}
@@ -102,12 +74,12 @@ func (s *Summarizer) Summarize(sys string, h []string) (system string, history [
 
 	input, err := json.Marshal(js)
 	if err == nil {
-		if historyTokens := s.Tokenizer.GetTokensCount(string(input)); historyTokens > s.MaxHistoryTokens {
+		if historyTokens := s.Tokenizer.GetTokensCount(ctx, string(input)); historyTokens > s.MaxHistoryTokens {
 			log.Printf("[Summarizer.Summarize] history tokens: %d, max: %d", historyTokens, s.MaxHistoryTokens)
 			wg.Add(1)
 			go func() {
 				defer wg.Done()
-				history = s.summarizeUser(h, string(input))
+				history = s.summarizeUser(ctx, h, string(input))
 				log.Println("[Summarizer.Summarize] summarized history: ", history)
 			}()
 		}
@@ -118,185 +90,54 @@ func (s *Summarizer) Summarize(sys string, h []string) (system string, history [
 	return
 }
 
-func (s *Summarizer) summarizeRule(prompt string) string {
-	if s.IamToken == "" || s.Model == "" {
-		log.Println("[Summarizer.Summarize] IamToken or Model is empty")
-		return prompt
-	}
-
-	// Создаем запрос для суммаризации
-	reqBody := summarizerRequest{
-		ModelURI: s.Model,
-		Messages: []struct {
-			Role string `json:"role"`
-			Text string `json:"text"`
-		}{
-			{Role: "system", Text: s.PromptRule},
-			{Role: "user", Text: prompt},
-		},
-	}
-
-	reqBody.CompletionOptions.Stream = false
-	reqBody.CompletionOptions.Temperature = modelTemperature
-	reqBody.CompletionOptions.MaxTokens = s.MaxOutputTokens
-
-	jsonBody, err := json.MarshalIndent(reqBody, "", "  ")
-	if err != nil {
-		log.Printf("[Summarizer.Summarize] Error marshaling request: %v", err)
-		return prompt
-	}
-
-	log.Printf("[Summarizer.Summarize] REQUEST body:\n%s", string(jsonBody))
-
-	// Отправляем запрос
-	httpClient := &http.Client{Timeout: clientTimeout}
-	req, err := http.NewRequest(http.MethodPost, summarizerUrl, bytes.NewReader(jsonBody))
-	if err != nil {
-		log.Printf("[Summarizer.Summarize] Error creating request: %v", err)
-		return prompt
-	}
-
-	s.prepareHttpRequest(req)
-
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		log.Printf("[Summarizer.Summarize] Error making request: %v", err)
+func (s *Summarizer) summarizeRule(ctx context.Context, prompt string) string {
+	result, ok := s.complete(ctx, s.PromptRule, prompt)
+	if !ok {
 		return prompt
 	}
-	defer func(Body io.ReadCloser) {
-		if cerr := Body.Close(); cerr != nil {
-			log.Printf("[Summarizer.Summarize] Body.Close(): %v", cerr)
-		}
-	}(resp.Body)
-
-	log.Printf("[Summarizer.Summarize] HTTP status: %d %s", resp.StatusCode, resp.Status)
-
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		log.Printf("[Summarizer.Summarize] Request failed with status: %d", resp.StatusCode)
-		return prompt
-	}
-
-	rawResp, err := io.ReadAll(resp.Body)
-	if err != nil {
-		log.Printf("[Summarizer.Summarize] Error reading response: %v", err)
-		return prompt
-	}
-
-	log.Printf("[Summarizer.Summarize] RAW response:\n%s", string(rawResp))
-
-	var sumResp summarizerResponse
-	if err := json.Unmarshal(rawResp, &sumResp); err != nil {
-		log.Printf("[Summarizer.Summarize] Error decoding response: %v", err)
-		return prompt
-	}
-
-	if len(sumResp.Result.Alternatives) == 0 {
-		log.Println("[Summarizer.Summarize] No alternatives in response")
-		return prompt
-	}
-
-	result := sumResp.Result.Alternatives[0].Message.Text
-	if result == "" {
-		log.Println("[Summarizer.Summarize] Empty result")
-		return prompt
-	}
-
-	log.Printf("[Summarizer.Summarize] Tokens used: %s input, %s output",
-		sumResp.Result.Usage.InputTextTokens, sumResp.Result.Usage.CompletionTokens)
-
 	return result
 }
 
-func (s *Summarizer) summarizeUser(raw []string, text string) (result []string) {
-	if s.IamToken == "" || s.Model == "" {
-		log.Println("[Summarizer.Summarize] IamToken or Model is empty")
+func (s *Summarizer) summarizeUser(ctx context.Context, raw []string, text string) (result []string) {
+	summary, ok := s.complete(ctx, s.HistoryRule, text)
+	if !ok {
 		return nil
 	}
+	return append(result, summary)
+}
+
+func (s *Summarizer) complete(ctx context.Context, rule string, text string) (string, bool) {
+	if s.Client == nil || s.Model == "" {
+		log.Println("[Summarizer.Summarize] client or model is empty")
+		return "", false
+	}
 
 	// Создаем запрос для суммаризации
-	reqBody := summarizerRequest{
-		ModelURI: s.Model,
-		Messages: []struct {
-			Role string `json:"role"`
-			Text string `json:"text"`
-		}{
-			{Role: "system", Text: s.HistoryRule},
+	req := llm.Request{
+		Model: s.Model,
+		Messages: []llm.Message{
+			{Role: "system", Text: rule},
 			{Role: "user", Text: text},
 		},
+		Options: llm.Options{
+			Temperature: modelTemperature,
+			MaxTokens:   s.MaxOutputTokens,
+		},
 	}
 
-	reqBody.CompletionOptions.Stream = false
-	reqBody.CompletionOptions.Temperature = modelTemperature
-	reqBody.CompletionOptions.MaxTokens = s.MaxOutputTokens
-
-	jsonBody, err := json.MarshalIndent(reqBody, "", "  ")
-	if err != nil {
-		log.Printf("[Summarizer.Summarize] Error marshaling request: %v", err)
-		return nil
-	}
-
-	log.Printf("[Summarizer.Summarize] REQUEST body:\n%s", string(jsonBody))
-
-	// Отправляем запрос
-	httpClient := &http.Client{Timeout: clientTimeout}
-	req, err := http.NewRequest(http.MethodPost, summarizerUrl, bytes.NewReader(jsonBody))
-	if err != nil {
-		log.Printf("[Summarizer.Summarize] Error creating request: %v", err)
-		return nil
-	}
-
-	s.prepareHttpRequest(req)
-
-	resp, err := httpClient.Do(req)
+	resp, err := s.Client.Complete(ctx, req)
 	if err != nil {
 		log.Printf("[Summarizer.Summarize] Error making request: %v", err)
-		return nil
+		return "", false
 	}
-	defer func(Body io.ReadCloser) {
-		if cerr := Body.Close(); cerr != nil {
-			log.Printf("[Summarizer.Summarize] Body.Close(): %v", cerr)
-		}
-	}(resp.Body)
-
-	log.Printf("[Summarizer.Summarize] HTTP status: %d %s", resp.StatusCode, resp.Status)
-
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		log.Printf("[Summarizer.Summarize] Request failed with status: %d", resp.StatusCode)
-		return nil
-	}
-
-	rawResp, err := io.ReadAll(resp.Body)
-	if err != nil {
-		log.Printf("[Summarizer.Summarize] Error reading response: %v", err)
-		return nil
-	}
-
-	log.Printf("[Summarizer.Summarize] RAW response:\n%s", string(rawResp))
 
-	var sumResp summarizerResponse
-	if err := json.Unmarshal(rawResp, &sumResp); err != nil {
-		log.Printf("[Summarizer.Summarize] Error decoding response: %v", err)
-		return nil
-	}
-
-	if len(sumResp.Result.Alternatives) == 0 {
-		log.Println("[Summarizer.Summarize] No alternatives in response")
-		return nil
-	}
-
-	result = append(result, sumResp.Result.Alternatives[0].Message.Text)
-	if result[0] == "" {
+	if resp.Text == "" {
 		log.Println("[Summarizer.Summarize] Empty result")
-		return nil
+		return "", false
 	}
 
-	log.Printf("[Summarizer.Summarize] Tokens used: %s input, %s output",
-		sumResp.Result.Usage.InputTextTokens, sumResp.Result.Usage.CompletionTokens)
-
-	return result
-}
+	log.Printf("[Summarizer.Summarize] Tokens used: %d input, %d output",
+		resp.Usage.InputTokens, resp.Usage.CompletionTokens)
 
-func (s *Summarizer) prepareHttpRequest(req *http.Request) {
-	req.Header.Set("Authorization", "Api-Key "+s.IamToken)
-	req.Header.Set("Content-Type", "application/json")
+	return resp.Text, true
 }
@@ -0,0 +1,67 @@
+package prompt
+
+import (
+	"adventBot/internal/ai_model/llm/llmtest"
+	"context"
+	"testing"
+)
+
+func TestGetTokensCount(t *testing.T) {
+	srv := llmtest.NewServer()
+	defer srv.Close()
+
+	tokenizer := Tokenizer{Client: srv.YandexClient(), Model: "yandexgpt-lite"}
+	if n := tokenizer.GetTokensCount(context.Background(), "12345678"); n != 2 {
+		t.Errorf("GetTokensCount = %d, want 2", n)
+	}
+
+	// Без клиента токены не считаются
+	if n := (&Tokenizer{}).GetTokensCount(context.Background(), "12345678"); n != 0 {
+		t.Errorf("GetTokensCount without client = %d, want 0", n)
+	}
+}
+
+func TestSummarizeShortPromptUnchanged(t *testing.T) {
+	srv := llmtest.NewServer()
+	defer srv.Close()
+
+	s := NewSummarizer(500, 500, 100, srv.YandexClient(), "yandexgpt-lite")
+	system, history := s.Summarize(context.Background(), "короткое правило", []string{"привет"})
+	if system != "короткое правило" || history != nil {
+		t.Errorf("Summarize = %q, %q", system, history)
+	}
+	if len(srv.Requests()) != 0 {
+		t.Errorf("short prompt was sent to the model: %+v", srv.Requests())
+	}
+}
+
+func TestSummarizeLongPrompt(t *testing.T) {
+	// Одинаковые ответы: системный промпт и история сжимаются параллельно, порядок запросов не определён
+	srv := llmtest.NewServer("кратко", "кратко")
+	defer srv.Close()
+	srv.TokensCount = 1000
+
+	s := NewSummarizer(500, 500, 100, srv.YandexClient(), "yandexgpt-lite")
+	system, history := s.Summarize(context.Background(), "длинное правило", []string{"длинная история"})
+	if system != "кратко" {
+		t.Errorf("system = %q", system)
+	}
+	if len(history) != 1 || history[0] != "кратко" {
+		t.Errorf("history = %q", history)
+	}
+	if srv.Pending() != 0 {
+		t.Errorf("%d answers left", srv.Pending())
+	}
+}
+
+func TestSummarizeFailureKeepsPrompt(t *testing.T) {
+	srv := llmtest.NewServer()
+	defer srv.Close()
+	srv.TokensCount = 1000
+
+	s := NewSummarizer(500, 500, 100, srv.YandexClient(), "yandexgpt-lite")
+	system, history := s.Summarize(context.Background(), "длинное правило", nil)
+	if system != "длинное правило" || history != nil {
+		t.Errorf("Summarize = %q, %q", system, history)
+	}
+}
@@ -0,0 +1,24 @@
+package tasks
+
+import (
+	"adventBot/internal/ai_model/llm/llmtest"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestSummarize(t *testing.T) {
+	srv := llmtest.NewServer("Сегодня у тебя врач в 10:00.")
+	defer srv.Close()
+
+	s := NewSummarizerTask(srv.YandexClient())
+	digest := "Задача: Врач\nДата: 2030-01-10T10:00:00+03:00\nЛокация: Клиника\n\n"
+	if got := s.Summarize(context.Background(), digest); got != "Сегодня у тебя врач в 10:00." {
+		t.Errorf("Summarize = %q", got)
+	}
+
+	reqs := srv.Requests()
+	if len(reqs) != 1 || !strings.HasSuffix(reqs[0].Model, "/"+summarizerModel) || reqs[0].Messages[1].Text != digest {
+		t.Errorf("requests = %+v", reqs)
+	}
+}
@@ -0,0 +1,122 @@
+package bot
+
+import (
+	"adventBot/internal/ai_model/llm/llmtest"
+	"adventBot/internal/ai_model/yandex"
+	"adventBot/internal/bot/tgtest"
+	"adventBot/internal/config"
+	"adventBot/internal/db/store"
+	"adventBot/internal/service"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fixedTimezone — часовой пояс без похода в GeoNames.
+type fixedTimezone string
+
+func (tz fixedTimezone) Lookup(context.Context, float64, float64) (string, error) {
+	return string(tz), nil
+}
+
+// testBot — бот целиком, как в main.go, но с хранилищем в памяти, фейковыми Bot API и LLM.
+type testBot struct {
+	srv   *tgtest.Server
+	llm   *llmtest.Server
+	store *store.Store
+}
+
+func newTestBot(t *testing.T) *testBot {
+	t.Helper()
+
+	dir := t.TempDir()
+	cfg := config.Config{}
+	for path, name := range map[*string]string{
+		&cfg.RulePath:          "rule.txt",
+		&cfg.RulePathCot:       "rule_cot.txt",
+		&cfg.RulePathFinalizer: "rule_finalizer.txt",
+	} {
+		*path = filepath.Join(dir, name)
+		if err := os.WriteFile(*path, []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	llm := llmtest.NewServer()
+	t.Cleanup(llm.Close)
+
+	st := store.NewMemory()
+	model := yandex.NewAiModelYandex(&cfg, llm.YandexClient(), st)
+	manager := service.NewSchedulerManager(st.Tasks, st.Chats, st.Subscriptions, nil, service.CatchUpPolicy{})
+	t.Cleanup(func() { _ = manager.Shutdown(context.Background()) })
+
+	router := NewRouter()
+	router.Use(Recover(), RequireChat(st.Chats, "start"))
+	tasks := NewTasksHandler(st.Tasks)
+	router.Command("start", "Начать", NewCommandHandler(st.Chats, manager))
+	router.Command("tasks", "Все открытые задачи", tasks)
+	router.Callback(TaskCallbackPrefix, NewCallbackHandler(st.Tasks))
+	router.Callback(TasksCallbackPrefix, tasks)
+	router.Callback(PendingCallbackPrefix, NewPendingHandler(model))
+	router.Location(NewLocationHandler(fixedTimezone("Europe/Moscow"), st.Chats, manager))
+	router.Text(NewTextHandler(model, st.Chats, st.Messages, time.Minute))
+
+	srv := tgtest.NewServer()
+	t.Cleanup(srv.Close)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	if _, err := srv.Run(ctx, router); err != nil {
+		t.Fatal(err)
+	}
+
+	return &testBot{srv: srv, llm: llm, store: st}
+}
+
+func TestConversationStartLocationTask(t *testing.T) {
+	app := newTestBot(t)
+	c := app.srv.Conversation(t, 42)
+
+	c.Command("/start")
+	if start := c.Expect("чтобы определить часовой пояс"); !start.RequestsLocation() {
+		t.Fatalf("/start reply does not request location: %+v", start)
+	}
+
+	c.ShareLocation(55.75, 37.61)
+	c.Expect("Europe/Moscow")
+
+	// Сначала модель уточняет время, потом возвращает задачу
+	app.llm.Script(
+		llmtest.Ask("Во сколько приём?", "dateTime"),
+		llmtest.Final("Врач", "2030-01-10T10:00:00+03:00", "Клиника"),
+		llmtest.Finalized("Записал: врач 10 января в 10:00"),
+	)
+	c.Type("завтра к врачу")
+	c.Expect("Во сколько приём?")
+
+	c.Type("в 10 утра, клиника")
+	c.Expect("Проверь, всё ли верно")
+	c.Press("💾 Сохранить")
+	c.ExpectEdit("Записал: врач 10 января в 10:00")
+
+	tasks, err := app.store.Tasks.GetAll(context.Background(), 42)
+	if err != nil || len(tasks) != 1 || tasks[0].Task != "Врач" || tasks[0].Location != "Клиника" {
+		t.Fatalf("saved tasks = %+v, %v", tasks, err)
+	}
+
+	c.Command("/tasks")
+	c.Expect("Врач")
+}
+
+func TestConversationUnknownChatAskedToStart(t *testing.T) {
+	app := newTestBot(t)
+	c := app.srv.Conversation(t, 7)
+
+	c.Type("завтра к врачу")
+	c.Expect("/start")
+
+	if n := len(app.llm.Requests()); n != 0 {
+		t.Errorf("model got %d requests from an unknown chat", n)
+	}
+}
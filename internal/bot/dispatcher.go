@@ -0,0 +1,145 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"log"
+	"sync"
+)
+
+// ErrDispatcherClosed возвращает Submit после начала Shutdown.
+var ErrDispatcherClosed = errors.New("dispatcher is closed")
+
+// ErrChatQueueFull возвращает Submit, если очередь чата заполнена: апдейт отброшен.
+var ErrChatQueueFull = errors.New("chat queue is full")
+
+// Dispatcher обрабатывает апдейты разных чатов параллельно, а апдейты одного чата — строго
+// в порядке поступления: у каждого чата с непустой очередью своя горутина, которая разбирает её по одному.
+//
+// Одновременно выполняется не больше workers обработчиков. Очередь чата ограничена queueSize:
+// лишние апдейты одного чата отбрасываются, чтобы он не задерживал остальных. Всего в очередях
+// может ждать не больше workers*queueSize апдейтов — дальше Submit блокируется, пока место не освободится.
+type Dispatcher struct {
+	handler   Handler
+	bot       *tgbotapi.BotAPI
+	queueSize int
+
+	// ctx передаётся обработчикам; отменяется, если Shutdown не дождался их завершения
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	workers chan struct{} // занятые обработчики
+	slots   chan struct{} // апдейты в очередях и в обработке
+
+	mu     sync.Mutex
+	queues map[int64][]tgbotapi.Update // есть ключ — у чата работает горутина drain
+	closed bool
+	wg     sync.WaitGroup
+}
+
+func NewDispatcher(h Handler, b *tgbotapi.BotAPI, workers int, queueSize int) *Dispatcher {
+	workers, queueSize = max(workers, 1), max(queueSize, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Dispatcher{
+		handler:   h,
+		bot:       b,
+		queueSize: queueSize,
+		ctx:       ctx,
+		cancel:    cancel,
+		workers:   make(chan struct{}, workers),
+		slots:     make(chan struct{}, workers*queueSize),
+		queues:    make(map[int64][]tgbotapi.Update),
+	}
+}
+
+// Submit ставит апдейт в очередь его чата. Блокируется, если очереди всех чатов вместе заполнены,
+// и возвращает ошибку ctx, если место так и не освободилось.
+func (d *Dispatcher) Submit(ctx context.Context, update tgbotapi.Update) error {
+	select {
+	case d.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	chatID, _ := updateChatID(&update)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		<-d.slots
+		return ErrDispatcherClosed
+	}
+
+	queue, running := d.queues[chatID]
+	if len(queue) >= d.queueSize {
+		<-d.slots
+		log.Printf("[Dispatcher.Submit] chatID=%d queue is full, dropping %s", chatID, describeUpdate(&update))
+		return ErrChatQueueFull
+	}
+	d.queues[chatID] = append(queue, update)
+
+	if !running {
+		d.wg.Add(1)
+		go d.drain(chatID)
+	}
+	return nil
+}
+
+// drain обрабатывает очередь чата, пока она не опустеет.
+func (d *Dispatcher) drain(chatID int64) {
+	defer d.wg.Done()
+
+	for {
+		d.mu.Lock()
+		queue := d.queues[chatID]
+		if len(queue) == 0 {
+			delete(d.queues, chatID)
+			d.mu.Unlock()
+			return
+		}
+		update := queue[0]
+		d.queues[chatID] = queue[1:]
+		d.mu.Unlock()
+
+		d.workers <- struct{}{}
+		d.handler.Handle(d.ctx, d.bot, &update)
+		<-d.workers
+		<-d.slots
+	}
+}
+
+// Shutdown перестаёт принимать апдейты и ждёт, пока обработаются уже принятые. Если ctx истекает
+// раньше, ещё не начатые апдейты отбрасываются, а контекст выполняющихся отменяется (например,
+// прерываются запросы к модели); Shutdown дожидается их выхода и возвращает ошибку ctx.
+func (d *Dispatcher) Shutdown(ctx context.Context) error {
+	d.mu.Lock()
+	d.closed = true
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		d.cancel()
+		return nil
+	case <-ctx.Done():
+		d.mu.Lock()
+		dropped := 0
+		for chatID, queue := range d.queues {
+			dropped += len(queue)
+			d.queues[chatID] = queue[:0]
+		}
+		d.mu.Unlock()
+
+		log.Printf("[Dispatcher.Shutdown] deadline exceeded, dropped %d queued updates, cancelling in-flight ones", dropped)
+		d.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,281 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// gatedHandler запоминает порядок апдейтов и держит каждый обработчик, пока тест его не отпустит.
+type gatedHandler struct {
+	started chan int // MessageID начатых апдейтов
+	release chan struct{}
+	once    sync.Once
+
+	mu         sync.Mutex
+	running    int
+	maxRunning int
+	order      map[int64][]int
+	cancelled  int // обработчики, чей контекст отменили
+}
+
+func newGatedHandler() *gatedHandler {
+	return &gatedHandler{
+		started: make(chan int, 100),
+		release: make(chan struct{}),
+		order:   make(map[int64][]int),
+	}
+}
+
+func (h *gatedHandler) Handle(ctx context.Context, b *tgbotapi.BotAPI, update *tgbotapi.Update) {
+	h.mu.Lock()
+	h.running++
+	h.maxRunning = max(h.maxRunning, h.running)
+	h.order[update.Message.Chat.ID] = append(h.order[update.Message.Chat.ID], update.Message.MessageID)
+	h.mu.Unlock()
+	h.started <- update.Message.MessageID
+
+	select {
+	case <-h.release:
+	case <-ctx.Done():
+		h.mu.Lock()
+		h.cancelled++
+		h.mu.Unlock()
+	}
+
+	h.mu.Lock()
+	h.running--
+	h.mu.Unlock()
+}
+
+// releaseOne отпускает один выполняющийся обработчик.
+func (h *gatedHandler) releaseOne(t *testing.T) {
+	t.Helper()
+	select {
+	case h.release <- struct{}{}:
+	case <-time.After(2 * time.Second):
+		t.Fatal("no handler is waiting to be released")
+	}
+}
+
+// releaseAll отпускает все обработчики, в том числе будущие.
+func (h *gatedHandler) releaseAll() {
+	h.once.Do(func() { close(h.release) })
+}
+
+// expectStarted ждёт начала обработки апдейта с MessageID id.
+func (h *gatedHandler) expectStarted(t *testing.T, id int) {
+	t.Helper()
+	select {
+	case got := <-h.started:
+		if got != id {
+			t.Fatalf("started update %d, want %d", got, id)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("update %d was not started", id)
+	}
+}
+
+// expectNoStart проверяет, что новых обработчиков не запустилось.
+func (h *gatedHandler) expectNoStart(t *testing.T) {
+	t.Helper()
+	select {
+	case got := <-h.started:
+		t.Fatalf("unexpected start of update %d", got)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func (h *gatedHandler) snapshot() (order map[int64][]int, maxRunning, cancelled int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	order = make(map[int64][]int, len(h.order))
+	for chatID, ids := range h.order {
+		order[chatID] = slices.Clone(ids)
+	}
+	return order, h.maxRunning, h.cancelled
+}
+
+func chatUpdate(chatID int64, id int) tgbotapi.Update {
+	return tgbotapi.Update{UpdateID: id, Message: &tgbotapi.Message{MessageID: id, Chat: &tgbotapi.Chat{ID: chatID}}}
+}
+
+func submit(t *testing.T, d *Dispatcher, chatID int64, id int) {
+	t.Helper()
+	if err := d.Submit(context.Background(), chatUpdate(chatID, id)); err != nil {
+		t.Fatalf("Submit(chat %d, update %d): %v", chatID, id, err)
+	}
+}
+
+func shutdown(t *testing.T, d *Dispatcher) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := d.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+}
+
+func TestDispatcherOrderAndParallelism(t *testing.T) {
+	h := newGatedHandler()
+	d := NewDispatcher(h, nil, 2, 10)
+
+	// Апдейты трёх чатов вперемешку; у чата N номера N0, N1, N2, N3
+	for i := range 4 {
+		for chatID := int64(1); chatID <= 3; chatID++ {
+			submit(t, d, chatID, int(chatID)*10+i)
+		}
+	}
+
+	// Работают только два обработчика, третий чат ждёт свободного
+	<-h.started
+	<-h.started
+	h.expectNoStart(t)
+
+	h.releaseAll()
+	shutdown(t, d)
+
+	order, maxRunning, cancelled := h.snapshot()
+	for chatID := int64(1); chatID <= 3; chatID++ {
+		base := int(chatID) * 10
+		if want := []int{base, base + 1, base + 2, base + 3}; !slices.Equal(order[chatID], want) {
+			t.Errorf("chat %d order = %v, want %v", chatID, order[chatID], want)
+		}
+	}
+	if maxRunning != 2 {
+		t.Errorf("max parallel handlers = %d, want 2", maxRunning)
+	}
+	if cancelled != 0 {
+		t.Errorf("%d handlers were cancelled on graceful shutdown", cancelled)
+	}
+}
+
+func TestDispatcherDropsWhenChatQueueFull(t *testing.T) {
+	h := newGatedHandler()
+	d := NewDispatcher(h, nil, 2, 2)
+
+	submit(t, d, 1, 1)
+	h.expectStarted(t, 1)
+	submit(t, d, 1, 2)
+	submit(t, d, 1, 3)
+
+	if err := d.Submit(context.Background(), chatUpdate(1, 4)); !errors.Is(err, ErrChatQueueFull) {
+		t.Fatalf("Submit to a full chat queue = %v, want ErrChatQueueFull", err)
+	}
+
+	// Переполненный чат не мешает остальным
+	submit(t, d, 2, 5)
+	h.expectStarted(t, 5)
+
+	h.releaseAll()
+	shutdown(t, d)
+	if order, _, _ := h.snapshot(); !slices.Equal(order[1], []int{1, 2, 3}) {
+		t.Errorf("chat 1 order = %v, want [1 2 3]", order[1])
+	}
+}
+
+func TestDispatcherBackPressure(t *testing.T) {
+	h := newGatedHandler()
+	// Всего в очередях и в обработке — не больше workers*queueSize = 2 апдейтов
+	d := NewDispatcher(h, nil, 1, 2)
+	defer shutdown(t, d)
+	defer h.releaseAll()
+
+	submit(t, d, 1, 1)
+	h.expectStarted(t, 1)
+	submit(t, d, 2, 2)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := d.Submit(ctx, chatUpdate(3, 3)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Submit without free slots = %v, want context.DeadlineExceeded", err)
+	}
+
+	// Submit ждёт, пока освободится место, а не отбрасывает апдейт
+	submitted := make(chan error, 1)
+	go func() { submitted <- d.Submit(context.Background(), chatUpdate(3, 4)) }()
+	select {
+	case err := <-submitted:
+		t.Fatalf("Submit returned %v before a slot was freed", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	h.releaseOne(t)
+	select {
+	case err := <-submitted:
+		if err != nil {
+			t.Fatalf("Submit after a slot was freed: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Submit is still blocked after a slot was freed")
+	}
+	h.expectStarted(t, 2)
+}
+
+func TestDispatcherShutdownDrains(t *testing.T) {
+	h := newGatedHandler()
+	d := NewDispatcher(h, nil, 1, 10)
+	for id := 1; id <= 3; id++ {
+		submit(t, d, 1, id)
+	}
+	h.expectStarted(t, 1)
+
+	done := make(chan error, 1)
+	go func() { done <- d.Shutdown(context.Background()) }()
+
+	// Shutdown ждёт уже принятые апдейты
+	for id := 1; id <= 3; id++ {
+		select {
+		case err := <-done:
+			t.Fatalf("Shutdown returned %v before update %d was handled", err, id)
+		case <-time.After(50 * time.Millisecond):
+		}
+		h.releaseOne(t)
+		if id < 3 {
+			h.expectStarted(t, id+1)
+		}
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Shutdown: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Shutdown did not return after the queue was drained")
+	}
+	if _, _, cancelled := h.snapshot(); cancelled != 0 {
+		t.Errorf("%d handlers were cancelled on graceful shutdown", cancelled)
+	}
+	if err := d.Submit(context.Background(), chatUpdate(1, 4)); !errors.Is(err, ErrDispatcherClosed) {
+		t.Errorf("Submit after Shutdown = %v, want ErrDispatcherClosed", err)
+	}
+}
+
+func TestDispatcherShutdownDeadline(t *testing.T) {
+	h := newGatedHandler()
+	d := NewDispatcher(h, nil, 1, 10)
+	for id := 1; id <= 3; id++ {
+		submit(t, d, 1, id)
+	}
+	h.expectStarted(t, 1)
+
+	// Обработчик не отпускаем: по дедлайну его контекст отменяется, а очередь отбрасывается
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := d.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown = %v, want context.DeadlineExceeded", err)
+	}
+
+	order, _, cancelled := h.snapshot()
+	if !slices.Equal(order[1], []int{1}) {
+		t.Errorf("handled %v, want only the in-flight update [1]", order[1])
+	}
+	if cancelled != 1 {
+		t.Errorf("cancelled handlers = %d, want 1", cancelled)
+	}
+}
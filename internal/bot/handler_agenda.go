@@ -0,0 +1,226 @@
+package bot
+
+import (
+	"adventBot/internal/db/chat"
+	"adventBot/internal/db/task"
+	"adventBot/internal/utils"
+	"context"
+	"fmt"
+	"github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	AgendaCallbackPrefix = "agenda"
+
+	defaultAgendaDays = 7
+	maxAgendaDays     = 31
+
+	// maxMessageLen — запас до лимита Telegram в 4096 символов
+	maxMessageLen = 4000
+)
+
+var weekdayShort = [...]string{"вс", "пн", "вт", "ср", "чт", "пт", "сб"}
+
+// agenda показывает задачи за несколько суток подряд, сгруппированные по локальным дням чата.
+type agenda struct {
+	taskRepo task.Repository
+	chatRepo chat.Repository
+}
+
+// WeekHandler обрабатывает /week — текущую неделю с понедельника.
+type WeekHandler struct {
+	agenda
+}
+
+func NewWeekHandler(r task.Repository, c chat.Repository) *WeekHandler {
+	return &WeekHandler{agenda{r, c}}
+}
+
+func (h *WeekHandler) Handle(ctx context.Context, b *tgbotapi.BotAPI, update *tgbotapi.Update) {
+	if update == nil || update.Message == nil {
+		return
+	}
+	chatID := update.Message.Chat.ID
+
+	today, _ := utils.DayRange(time.Now().In(h.location(ctx, chatID)))
+	monday := today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+	h.send(ctx, b, chatID, monday, 7)
+}
+
+// AgendaHandler обрабатывает "/agenda [N]" — N дней начиная с сегодня, и кнопки листания
+// для /week и /agenda.
+type AgendaHandler struct {
+	agenda
+}
+
+func NewAgendaHandler(r task.Repository, c chat.Repository) *AgendaHandler {
+	return &AgendaHandler{agenda{r, c}}
+}
+
+func (h *AgendaHandler) Handle(ctx context.Context, b *tgbotapi.BotAPI, update *tgbotapi.Update) {
+	if update == nil {
+		return
+	}
+	if update.CallbackQuery != nil {
+		h.handleCallback(ctx, b, update.CallbackQuery)
+		return
+	}
+	if update.Message == nil {
+		return
+	}
+	chatID := update.Message.Chat.ID
+
+	days := defaultAgendaDays
+	if arg := strings.TrimSpace(update.Message.CommandArguments()); arg != "" {
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 1 || n > maxAgendaDays {
+			msg := fmt.Sprintf("Не понял «%s». Укажи число дней от 1 до %d, например /agenda 3.", arg, maxAgendaDays)
+			if _, err := b.Send(tgbotapi.NewMessage(chatID, msg)); err != nil {
+				log.Printf("[AgendaHandler.Handle] Error sending message: %v", err)
+			}
+			return
+		}
+		days = n
+	}
+
+	today, _ := utils.DayRange(time.Now().In(h.location(ctx, chatID)))
+	h.send(ctx, b, chatID, today, days)
+}
+
+func (h *AgendaHandler) handleCallback(ctx context.Context, b *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery) {
+	if query.Message == nil {
+		return
+	}
+	chatID := query.Message.Chat.ID
+
+	from, days, ok := parseAgendaCallbackData(query.Data, h.location(ctx, chatID))
+	if !ok {
+		log.Printf("[AgendaHandler.handleCallback] unknown callback data=%q chatID=%d", query.Data, chatID)
+		answerCallback(b, query.ID, "Неизвестное действие")
+		return
+	}
+
+	text, kb, err := h.render(ctx, chatID, from, days)
+	if err != nil {
+		log.Printf("[AgendaHandler.handleCallback] render chatID=%d err=%v", chatID, err)
+		answerCallback(b, query.ID, "Не удалось загрузить задачи")
+		return
+	}
+	answerCallback(b, query.ID, "")
+	editMessage(b, tgbotapi.NewEditMessageTextAndMarkup(chatID, query.Message.MessageID, text, kb))
+}
+
+func (a agenda) send(ctx context.Context, b *tgbotapi.BotAPI, chatID int64, from time.Time, days int) {
+	text, kb, err := a.render(ctx, chatID, from, days)
+	if err != nil {
+		log.Printf("[agenda.send] render chatID=%d err=%v", chatID, err)
+		text = "Не удалось загрузить задачи 😔"
+	}
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	if err == nil {
+		msg.ReplyMarkup = kb
+	}
+	if _, err := b.Send(msg); err != nil {
+		log.Printf("[agenda.send] Error sending message: %v", err)
+	}
+}
+
+func (a agenda) location(ctx context.Context, chatID int64) *time.Location {
+	tz, _, err := a.chatRepo.GetById(ctx, chatID)
+	if err != nil {
+		log.Printf("[agenda.location] GetById chatID=%d err=%v", chatID, err)
+	}
+	return utils.LoadLocation(tz)
+}
+
+// render собирает текст за days суток начиная с from (полночь в зоне чата) и кнопки листания на days суток.
+func (a agenda) render(ctx context.Context, chatID int64, from time.Time, days int) (string, tgbotapi.InlineKeyboardMarkup, error) {
+	to := from.AddDate(0, 0, days)
+	tasks, err := a.taskRepo.ListRange(ctx, chatID, from, to)
+	if err != nil {
+		return "", tgbotapi.InlineKeyboardMarkup{}, err
+	}
+
+	byDay := make(map[string][]task.Task)
+	for _, t := range tasks {
+		due, err := t.Due()
+		if err != nil {
+			continue
+		}
+		day := due.In(from.Location()).Format(time.DateOnly)
+		byDay[day] = append(byDay[day], t)
+	}
+
+	var sb strings.Builder
+	last := to.AddDate(0, 0, -1)
+	fmt.Fprintf(&sb, "📅 %s — %s\n", formatDay(from), formatDay(last))
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		fmt.Fprintf(&sb, "\n%s\n", formatDay(day))
+		dayTasks := byDay[day.Format(time.DateOnly)]
+		if len(dayTasks) == 0 {
+			sb.WriteString("  —\n")
+			continue
+		}
+		for _, t := range dayTasks {
+			sb.WriteString("  " + formatAgendaLine(t, from.Location()) + "\n")
+		}
+	}
+
+	text := sb.String()
+	if r := []rune(text); len(r) > maxMessageLen {
+		text = string(r[:maxMessageLen]) + "\n…"
+	}
+
+	kb := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("◀ Назад", agendaCallbackData(from.AddDate(0, 0, -days), days)),
+			tgbotapi.NewInlineKeyboardButtonData("Вперёд ▶", agendaCallbackData(to, days)),
+		),
+	)
+	return text, kb, nil
+}
+
+func formatDay(day time.Time) string {
+	return fmt.Sprintf("%s, %s", weekdayShort[day.Weekday()], day.Format("02.01"))
+}
+
+func formatAgendaLine(t task.Task, loc *time.Location) string {
+	line := t.Task
+	if due, err := t.Due(); err == nil {
+		line = due.In(loc).Format("15:04") + " " + line
+	}
+	if t.Location != "" {
+		line += " (" + t.Location + ")"
+	}
+	if t.IsRecurring() {
+		line += " 🔁"
+	}
+	return line
+}
+
+// agendaCallbackData — кнопка листания: agenda:<первый день>:<число дней>.
+func agendaCallbackData(from time.Time, days int) string {
+	return fmt.Sprintf("%s:%s:%d", AgendaCallbackPrefix, from.Format(time.DateOnly), days)
+}
+
+func parseAgendaCallbackData(data string, loc *time.Location) (from time.Time, days int, ok bool) {
+	parts := strings.Split(data, ":")
+	if len(parts) != 3 || parts[0] != AgendaCallbackPrefix {
+		return time.Time{}, 0, false
+	}
+
+	from, err := time.ParseInLocation(time.DateOnly, parts[1], loc)
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+	days, err = strconv.Atoi(parts[2])
+	if err != nil || days < 1 || days > maxAgendaDays {
+		return time.Time{}, 0, false
+	}
+	return from, days, true
+}
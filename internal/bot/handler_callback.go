@@ -0,0 +1,154 @@
+package bot
+
+import (
+	"adventBot/internal/db/task"
+	"context"
+	"github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"log"
+	"time"
+)
+
+type CallbackHandler struct {
+	taskRepo task.Repository
+}
+
+func NewCallbackHandler(r task.Repository) *CallbackHandler { return &CallbackHandler{r} }
+
+func (h *CallbackHandler) Handle(ctx context.Context, b *tgbotapi.BotAPI, update *tgbotapi.Update) {
+	if update == nil || update.CallbackQuery == nil || update.CallbackQuery.Message == nil {
+		return
+	}
+
+	query := update.CallbackQuery
+	chatID := query.Message.Chat.ID
+	messageID := query.Message.MessageID
+
+	action, id, at, ok := parseTaskCallbackData(query.Data)
+	if !ok {
+		log.Printf("[CallbackHandler.Handle] unknown callback data=%q chatID=%d", query.Data, chatID)
+		answerCallback(b, query.ID, "Неизвестное действие")
+		return
+	}
+
+	t, found, err := h.taskRepo.GetByID(ctx, chatID, id)
+	if err != nil || !found {
+		log.Printf("[CallbackHandler.Handle] task not found chatID=%d id=%d err=%v", chatID, id, err)
+		answerCallback(b, query.ID, "Задача не найдена")
+		if action == actionReject {
+			// В подтверждении могут быть другие задачи — текст не трогаем
+			editMessage(b, tgbotapi.NewEditMessageTextAndMarkup(chatID, messageID, query.Message.Text, withoutButton(query.Message.ReplyMarkup, query.Data)))
+			return
+		}
+		editMessage(b, tgbotapi.NewEditMessageText(chatID, messageID, "Задача не найдена"))
+		return
+	}
+
+	if !at.IsZero() {
+		h.handleOccurrence(ctx, b, query, t, action, at)
+		return
+	}
+
+	if t.Status != task.StatusOpen && (action == actionDone || action == actionSnooze || action == actionTomorrow) {
+		// Кнопка со старого сообщения: закрытую задачу не переносим и не открываем заново
+		answerCallback(b, query.ID, "Задача уже закрыта")
+		return
+	}
+
+	switch action {
+	case actionDone:
+		if _, err := h.taskRepo.Complete(ctx, chatID, id); err != nil {
+			log.Printf("[CallbackHandler.Handle] Complete chatID=%d id=%d err=%v", chatID, id, err)
+			answerCallback(b, query.ID, "Не удалось выполнить действие")
+			return
+		}
+		answerCallback(b, query.ID, "Готово!")
+		editMessage(b, tgbotapi.NewEditMessageText(chatID, messageID, "✅ Выполнено\n"+formatTask(t)))
+
+	case actionSnooze, actionTomorrow:
+		t, err = shiftTask(t, action, time.Now())
+		if err != nil {
+			log.Printf("[CallbackHandler.Handle] invalid dateTime=%q id=%d err=%v", t.DateTime, id, err)
+			answerCallback(b, query.ID, "Не удалось перенести задачу")
+			return
+		}
+
+		if _, err := h.taskRepo.Update(ctx, t); err != nil {
+			log.Printf("[CallbackHandler.Handle] Update chatID=%d id=%d err=%v", chatID, id, err)
+			answerCallback(b, query.ID, "Не удалось перенести задачу")
+			return
+		}
+		answerCallback(b, query.ID, "Задача перенесена")
+		editMessage(b, tgbotapi.NewEditMessageTextAndMarkup(chatID, messageID, formatTask(t), taskKeyboard(t.ID)))
+
+	case actionDelete:
+		if _, err := h.taskRepo.Delete(ctx, chatID, id); err != nil {
+			log.Printf("[CallbackHandler.Handle] Delete chatID=%d id=%d err=%v", chatID, id, err)
+			answerCallback(b, query.ID, "Не удалось удалить задачу")
+			return
+		}
+		answerCallback(b, query.ID, "Задача удалена")
+		editMessage(b, tgbotapi.NewEditMessageText(chatID, messageID, "🗑 Задача удалена\n"+formatTask(t)))
+
+	case actionReject:
+		if _, err := h.taskRepo.Delete(ctx, chatID, id); err != nil {
+			log.Printf("[CallbackHandler.Handle] Delete chatID=%d id=%d err=%v", chatID, id, err)
+			answerCallback(b, query.ID, "Не удалось отменить задачу")
+			return
+		}
+		answerCallback(b, query.ID, "Задача отменена")
+		// Остальные задачи из подтверждения остаются, убираем только кнопку отменённой
+		text := query.Message.Text + "\n\n❌ Отменена: " + t.Task
+		editMessage(b, tgbotapi.NewEditMessageTextAndMarkup(chatID, messageID, text, withoutButton(query.Message.ReplyMarkup, query.Data)))
+
+	default:
+		log.Printf("[CallbackHandler.Handle] unknown action=%q chatID=%d", action, chatID)
+		answerCallback(b, query.ID, "Неизвестное действие")
+	}
+}
+
+// handleOccurrence отмечает одно повторение регулярной задачи, не трогая остальную серию.
+func (h *CallbackHandler) handleOccurrence(ctx context.Context, b *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery, t task.Task, action taskAction, at time.Time) {
+	chatID := query.Message.Chat.ID
+	messageID := query.Message.MessageID
+
+	if start, err := time.Parse(time.RFC3339, t.DateTime); err == nil {
+		at = at.In(start.Location())
+	}
+	t.DateTime = at.Format(time.RFC3339)
+
+	var (
+		status task.Status
+		answer string
+		text   string
+	)
+	switch action {
+	case actionDone:
+		status, answer, text = task.StatusDone, "Готово!", "✅ Выполнено\n"
+	case actionSkip:
+		status, answer, text = task.StatusCancelled, "Повторение пропущено", "⏭ Пропущено\n"
+	default:
+		log.Printf("[CallbackHandler.handleOccurrence] unsupported action=%q chatID=%d", action, chatID)
+		answerCallback(b, query.ID, "Неизвестное действие")
+		return
+	}
+
+	if _, err := h.taskRepo.SetOccurrenceStatus(ctx, chatID, t.ID, at, status); err != nil {
+		log.Printf("[CallbackHandler.handleOccurrence] SetOccurrenceStatus chatID=%d id=%d at=%s err=%v", chatID, t.ID, t.DateTime, err)
+		answerCallback(b, query.ID, "Не удалось выполнить действие")
+		return
+	}
+	answerCallback(b, query.ID, answer)
+	editMessage(b, tgbotapi.NewEditMessageText(chatID, messageID, text+formatTask(t)))
+}
+
+func answerCallback(b *tgbotapi.BotAPI, queryID string, text string) {
+	if _, err := b.Request(tgbotapi.NewCallback(queryID, text)); err != nil {
+		log.Println("[CallbackHandler.answerCallback] Request:", err)
+	}
+}
+
+func editMessage(b *tgbotapi.BotAPI, edit tgbotapi.EditMessageTextConfig) {
+	if _, err := b.Send(edit); err != nil {
+		log.Println("[CallbackHandler.editMessage] Send:", err)
+	}
+}
@@ -0,0 +1,168 @@
+package bot
+
+import (
+	"adventBot/internal/bot/tgtest"
+	"adventBot/internal/db/store"
+	"adventBot/internal/db/task"
+	"adventBot/internal/utils"
+	"context"
+	"testing"
+	"time"
+)
+
+// todayBot — бот с /today и кнопками задач; в чате 42 одна задача на сегодня со сроком due.
+type todayBot struct {
+	c   *tgtest.Conversation
+	st  *store.Store
+	id  int64
+	due time.Time
+	msg tgtest.Sent // сообщение задачи из ответа на /today
+}
+
+func newTodayBot(t *testing.T, text string, due time.Time) todayBot {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	st := store.NewMemory()
+	if err := st.Chats.Upsert(ctx, 42, "Europe/Moscow"); err != nil {
+		t.Fatal(err)
+	}
+	id, err := st.Tasks.Create(ctx, task.Task{
+		ChatID:   42,
+		Task:     text,
+		DateTime: due.Format(time.RFC3339),
+		Location: "Дом",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := NewRouter()
+	router.Command("today", "Задачи на сегодня", NewTodayHandler(st.Tasks, st.Chats))
+	router.Callback(TaskCallbackPrefix, NewCallbackHandler(st.Tasks))
+
+	srv := tgtest.NewServer()
+	t.Cleanup(srv.Close)
+	if _, err := srv.Run(ctx, router); err != nil {
+		t.Fatal(err)
+	}
+
+	c := srv.Conversation(t, 42)
+	c.Command("/today")
+	msg := c.ExpectButtons("✅ Готово", "⏰ +1 час", "📅 Завтра", "🗑 Удалить")
+	return todayBot{c: c, st: st, id: id, due: due, msg: msg}
+}
+
+// todayAt — сегодняшний день по Москве плюс d от полуночи.
+func todayAt(d time.Duration) time.Time {
+	from, _ := utils.DayRange(time.Now().In(utils.LoadLocation("Europe/Moscow")))
+	return from.Add(d)
+}
+
+func getTask(t *testing.T, st *store.Store, id int64) (task.Task, bool) {
+	t.Helper()
+	got, found, err := st.Tasks.GetByID(context.Background(), 42, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return got, found
+}
+
+func TestTaskButtonDone(t *testing.T) {
+	app := newTodayBot(t, "Полить цветы", todayAt(12*time.Hour))
+	c := app.c
+
+	c.Press("✅ Готово")
+	c.ExpectEdit("✅ Выполнено")
+	c.ExpectCall("answerCallbackQuery")
+
+	if got, _ := getTask(t, app.st, app.id); got.Status != task.StatusDone {
+		t.Errorf("status = %q, want %q", got.Status, task.StatusDone)
+	}
+}
+
+func TestTaskButtonsSnoozeAndTomorrow(t *testing.T) {
+	start := time.Now().In(utils.LoadLocation("Europe/Moscow")).Truncate(time.Minute).Add(30 * time.Minute)
+	if start.Day() != todayAt(0).Day() {
+		t.Skip("до полуночи меньше получаса: задача не попала бы в /today")
+	}
+	app := newTodayBot(t, "Позвонить маме", start)
+	c := app.c
+
+	c.Press("⏰ +1 час")
+	c.ExpectEdit("Позвонить маме")
+	got, _ := getTask(t, app.st, app.id)
+	if due, _ := got.Due(); !due.Equal(start.Add(time.Hour)) {
+		t.Fatalf("after snooze dateTime = %s, want %s", got.DateTime, start.Add(time.Hour).Format(time.RFC3339))
+	}
+
+	// Кнопки остаются на отредактированном сообщении
+	c.Press("📅 Завтра")
+	c.ExpectEdit("Позвонить маме")
+	got, _ = getTask(t, app.st, app.id)
+	if due, _ := got.Due(); !due.Equal(start.Add(time.Hour).AddDate(0, 0, 1)) {
+		t.Errorf("after tomorrow dateTime = %s", got.DateTime)
+	}
+}
+
+func TestTaskButtonSnoozeOverdue(t *testing.T) {
+	if time.Since(todayAt(0)) < 2*time.Minute {
+		t.Skip("только что наступила полночь: задача на 00:00 ещё не просрочена")
+	}
+	app := newTodayBot(t, "Оплатить счёт", todayAt(0))
+	c := app.c
+
+	before := time.Now().Truncate(time.Minute)
+	c.Press("⏰ +1 час")
+	c.ExpectEdit("Оплатить счёт")
+
+	// Час отсчитывается от текущего момента: задача больше не просрочена
+	got, _ := getTask(t, app.st, app.id)
+	due, err := got.Due()
+	if err != nil || due.Before(before.Add(time.Hour)) || due.After(time.Now().Add(time.Hour)) {
+		t.Errorf("after snooze dateTime = %s, want about an hour from now", got.DateTime)
+	}
+}
+
+func TestTaskButtonsOnClosedTask(t *testing.T) {
+	app := newTodayBot(t, "Купить хлеб", todayAt(12*time.Hour))
+	c := app.c
+
+	c.Press("✅ Готово")
+	c.ExpectEdit("✅ Выполнено")
+
+	// Кнопки старого сообщения /today не должны переносить выполненную задачу
+	answered := make(map[string]bool)
+	for _, button := range []string{"⏰ +1 час", "📅 Завтра"} {
+		data, _ := app.msg.CallbackData(button)
+		c.PressData(app.msg, data)
+		call := c.ExpectCallFunc("answerCallbackQuery", func(call tgtest.Call) bool {
+			return !answered[call.Params.Get("callback_query_id")] && call.Params.Get("text") == "Задача уже закрыта"
+		})
+		answered[call.Params.Get("callback_query_id")] = true
+	}
+
+	got, _ := getTask(t, app.st, app.id)
+	if due, _ := got.Due(); got.Status != task.StatusDone || !due.Equal(app.due) {
+		t.Errorf("closed task changed: %+v", got)
+	}
+}
+
+func TestTaskButtonDelete(t *testing.T) {
+	app := newTodayBot(t, "Вынести мусор", todayAt(12*time.Hour))
+	c := app.c
+
+	c.Press("🗑 Удалить")
+	c.ExpectEdit("🗑 Задача удалена")
+
+	if _, found := getTask(t, app.st, app.id); found {
+		t.Error("task still exists after delete")
+	}
+
+	// Повторное нажатие на старое сообщение
+	data, _ := app.msg.CallbackData("✅ Готово")
+	c.PressData(app.msg, data)
+	c.ExpectEdit("Задача не найдена")
+}
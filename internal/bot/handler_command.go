@@ -66,7 +66,9 @@ func (h *CommandHandler) Handle(ctx context.Context, b *tgbotapi.BotAPI, update
 	}
 
 	h.manager.AddScheduler(chatID, b)
-	scheduleMsg := tgbotapi.NewMessage(chatID, "Для чата создан Scheduler, он будет уведомлять вас раз в 24 часа")
+	scheduleMsg := tgbotapi.NewMessage(chatID, fmt.Sprintf(
+		"Для чата создан Scheduler, он будет присылать сводку задач каждый день в %s по вашему времени.\n"+
+			"Изменить время можно командой /digest ЧЧ:ММ", chat.DefaultDigestTime))
 	if _, err := b.Send(scheduleMsg); err != nil {
 		log.Println("[CommandHandler.Handle] SendMessage:", err)
 	}
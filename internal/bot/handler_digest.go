@@ -0,0 +1,74 @@
+package bot
+
+import (
+	"adventBot/internal/db/chat"
+	"adventBot/internal/service"
+	"adventBot/internal/utils"
+	"context"
+	"fmt"
+	"github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"log"
+	"strings"
+)
+
+type DigestHandler struct {
+	Repository chat.Repository
+	manager    *service.SchedulerManager
+}
+
+func NewDigestHandler(r chat.Repository, m *service.SchedulerManager) *DigestHandler {
+	return &DigestHandler{r, m}
+}
+
+// Handle обрабатывает "/digest" (показать время сводки) и "/digest ЧЧ:ММ" (изменить его).
+func (h *DigestHandler) Handle(ctx context.Context, b *tgbotapi.BotAPI, update *tgbotapi.Update) {
+	if update == nil || update.Message == nil {
+		return
+	}
+	chatID := update.Message.Chat.ID
+
+	settings, err := h.Repository.GetSettings(ctx, chatID)
+	if err != nil {
+		log.Printf("[DigestHandler.Handle] GetSettings chatID=%d err=%v", chatID, err)
+	}
+
+	arg := strings.TrimSpace(update.Message.CommandArguments())
+	if arg == "" {
+		msg := fmt.Sprintf("Присылаю сводку задач каждый день в %s по твоему времени.\n"+
+			"Чтобы изменить, отправь /digest ЧЧ:ММ, например /digest 07:30.", settings.DigestTime)
+		if err := sendWithMenu(ctx, b, h.Repository, chatID, msg); err != nil {
+			log.Println("[DigestHandler.Handle] SendWithMenu:", err)
+		}
+		return
+	}
+
+	hour, minute, err := utils.ParseClock(arg)
+	if err != nil {
+		msg := fmt.Sprintf("Не понял «%s». Укажи время в формате ЧЧ:ММ, например /digest 08:00.", arg)
+		if err := sendWithMenu(ctx, b, h.Repository, chatID, msg); err != nil {
+			log.Println("[DigestHandler.Handle] SendWithMenu:", err)
+		}
+		return
+	}
+
+	settings.DigestTime = fmt.Sprintf("%02d:%02d", hour, minute)
+	ok, err := h.Repository.UpdateSettings(ctx, chatID, settings)
+	if err != nil {
+		log.Printf("[DigestHandler.Handle] UpdateSettings chatID=%d err=%v", chatID, err)
+		_, _ = b.Send(tgbotapi.NewMessage(chatID, "Не удалось сохранить настройку 😔"))
+		return
+	}
+	if !ok {
+		if err := sendWithStart(ctx, b, chatID, "Сначала нажми /start, чтобы настроить часовой пояс."); err != nil {
+			log.Println("[DigestHandler.Handle] sendWithStart:", err)
+		}
+		return
+	}
+
+	h.manager.Reschedule(chatID)
+
+	msg := fmt.Sprintf("Готово! Сводка будет приходить каждый день в %s.", settings.DigestTime)
+	if err := sendWithMenu(ctx, b, h.Repository, chatID, msg); err != nil {
+		log.Println("[DigestHandler.Handle] SendWithMenu:", err)
+	}
+}
@@ -0,0 +1,63 @@
+package bot
+
+import (
+	"adventBot/internal/db/task"
+	"context"
+	"fmt"
+	"github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"log"
+	"strings"
+)
+
+// findLimit — сколько найденных задач отправлять отдельными сообщениями с кнопками.
+const findLimit = 10
+
+// FindHandler обрабатывает "/find <запрос>": ищет открытые задачи по тексту и месту.
+type FindHandler struct {
+	taskRepo task.Repository
+}
+
+func NewFindHandler(r task.Repository) *FindHandler { return &FindHandler{r} }
+
+func (h *FindHandler) Handle(ctx context.Context, b *tgbotapi.BotAPI, update *tgbotapi.Update) {
+	if update == nil || update.Message == nil {
+		return
+	}
+	chatID := update.Message.Chat.ID
+
+	query := strings.TrimSpace(update.Message.CommandArguments())
+	if len(task.SearchTerms(query)) == 0 {
+		h.reply(b, chatID, "Напиши, что искать, например /find врач")
+		return
+	}
+
+	tasks, err := h.taskRepo.Search(ctx, chatID, query)
+	if err != nil {
+		log.Printf("[FindHandler.Handle] Search chatID=%d query=%q err=%v", chatID, query, err)
+		h.reply(b, chatID, "Не удалось выполнить поиск 😔")
+		return
+	}
+
+	switch {
+	case len(tasks) == 0:
+		h.reply(b, chatID, fmt.Sprintf("По запросу «%s» ничего не нашлось", query))
+		return
+	case len(tasks) > findLimit:
+		h.reply(b, chatID, fmt.Sprintf("Найдено задач: %d, показаны ближайшие %d. Уточни запрос, чтобы увидеть остальные.", len(tasks), findLimit))
+		tasks = tasks[:findLimit]
+	default:
+		h.reply(b, chatID, fmt.Sprintf("Найдено задач: %d", len(tasks)))
+	}
+
+	for _, t := range tasks {
+		if _, err := b.Send(newTaskMessage(chatID, t)); err != nil {
+			log.Printf("[FindHandler.Handle] Error sending message: %v", err)
+		}
+	}
+}
+
+func (h *FindHandler) reply(b *tgbotapi.BotAPI, chatID int64, text string) {
+	if _, err := b.Send(tgbotapi.NewMessage(chatID, text)); err != nil {
+		log.Printf("[FindHandler.reply] Error sending message: %v", err)
+	}
+}
@@ -2,6 +2,7 @@ package bot
 
 import (
 	"adventBot/internal/db/chat"
+	"adventBot/internal/service"
 	"adventBot/internal/timezone"
 	"context"
 	"fmt"
@@ -13,10 +14,11 @@ import (
 type LocationHandler struct {
 	TzAPI      timezone.ApiTimezone
 	Repository chat.Repository
+	manager    *service.SchedulerManager
 }
 
-func NewLocationHandler(tz timezone.ApiTimezone, r chat.Repository) *LocationHandler {
-	return &LocationHandler{TzAPI: tz, Repository: r}
+func NewLocationHandler(tz timezone.ApiTimezone, r chat.Repository, m *service.SchedulerManager) *LocationHandler {
+	return &LocationHandler{TzAPI: tz, Repository: r, manager: m}
 }
 
 func (h *LocationHandler) Handle(ctx context.Context, b *tgbotapi.BotAPI, upd *tgbotapi.Update) {
@@ -45,6 +47,7 @@ func (h *LocationHandler) Handle(ctx context.Context, b *tgbotapi.BotAPI, upd *t
 		log.Printf("[LocationHandler.Handle] upsert tz failed chatID=%d tz=%s err=%v", chatID, tz, err)
 	} else {
 		log.Printf("[LocationHandler.Handle] upsert tz OK chatID=%d tz=%s", chatID, tz)
+		h.manager.Reschedule(chatID)
 	}
 
 	locTZ, err := time.LoadLocation(tz)
@@ -0,0 +1,107 @@
+package bot
+
+import (
+	"adventBot/internal/ai_model"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const PendingCallbackPrefix = "pending"
+
+type pendingAction string
+
+const (
+	pendingSave   pendingAction = "save"
+	pendingChange pendingAction = "change"
+	pendingCancel pendingAction = "cancel"
+)
+
+// PendingHandler обрабатывает кнопки черновика задач: сохранить, изменить или отменить.
+type PendingHandler struct {
+	model ai_model.AiModel
+}
+
+func NewPendingHandler(m ai_model.AiModel) *PendingHandler { return &PendingHandler{m} }
+
+// pendingKeyboard — кнопки под черновиком; createdAt отличает его от черновиков, которые его заменят.
+func pendingKeyboard(createdAt time.Time) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("💾 Сохранить", pendingCallbackData(pendingSave, createdAt)),
+		tgbotapi.NewInlineKeyboardButtonData("✏️ Изменить", pendingCallbackData(pendingChange, createdAt)),
+		tgbotapi.NewInlineKeyboardButtonData("✖️ Отмена", pendingCallbackData(pendingCancel, createdAt)),
+	))
+}
+
+// pendingCallbackData — pending:<action>:<unix>.
+func pendingCallbackData(action pendingAction, createdAt time.Time) string {
+	return fmt.Sprintf("%s:%s:%d", PendingCallbackPrefix, action, createdAt.Unix())
+}
+
+func parsePendingCallbackData(data string) (action pendingAction, createdAt time.Time, ok bool) {
+	parts := strings.Split(data, ":")
+	if len(parts) != 3 || parts[0] != PendingCallbackPrefix {
+		return "", time.Time{}, false
+	}
+	unix, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	return pendingAction(parts[1]), time.Unix(unix, 0).UTC(), true
+}
+
+func (h *PendingHandler) Handle(ctx context.Context, b *tgbotapi.BotAPI, update *tgbotapi.Update) {
+	if update == nil || update.CallbackQuery == nil || update.CallbackQuery.Message == nil {
+		return
+	}
+
+	query := update.CallbackQuery
+	chatID := query.Message.Chat.ID
+	messageID := query.Message.MessageID
+
+	action, createdAt, ok := parsePendingCallbackData(query.Data)
+	if !ok {
+		log.Printf("[PendingHandler.Handle] unknown callback data=%q chatID=%d", query.Data, chatID)
+		answerCallback(b, query.ID, "Неизвестное действие")
+		return
+	}
+
+	var (
+		reply ai_model.Reply
+		err   error
+	)
+	switch action {
+	case pendingSave:
+		reply, err = h.model.SavePending(ctx, chatID, createdAt)
+	case pendingChange:
+		reply, err = h.model.ChangePending(ctx, chatID, createdAt)
+	case pendingCancel:
+		reply, err = h.model.CancelPending(ctx, chatID, createdAt)
+	default:
+		log.Printf("[PendingHandler.Handle] unknown action=%q chatID=%d", action, chatID)
+		answerCallback(b, query.ID, "Неизвестное действие")
+		return
+	}
+
+	switch {
+	case errors.Is(err, ai_model.ErrDraftNotFound):
+		// Черновик сохранён, отменён или заменён новым — убираем кнопки, текст оставляем
+		answerCallback(b, query.ID, reply.Text)
+		editMessage(b, tgbotapi.NewEditMessageText(chatID, messageID, query.Message.Text))
+	case err != nil:
+		// Кнопки остаются, чтобы можно было повторить
+		answerCallback(b, query.ID, reply.Text)
+	case len(reply.Tasks) > 0:
+		// Подтверждение сохранённых задач с возможностью отменить любую из них
+		answerCallback(b, query.ID, "Сохранено")
+		editMessage(b, tgbotapi.NewEditMessageTextAndMarkup(chatID, messageID, reply.Text, rejectKeyboard(reply.Tasks)))
+	default:
+		answerCallback(b, query.ID, "")
+		editMessage(b, tgbotapi.NewEditMessageText(chatID, messageID, reply.Text))
+	}
+}
@@ -0,0 +1,76 @@
+package bot
+
+import (
+	"adventBot/internal/db/chat"
+	"adventBot/internal/service"
+	"context"
+	"fmt"
+	"github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"log"
+	"strconv"
+	"strings"
+)
+
+const maxRemindBefore = 24 * 60
+
+type RemindHandler struct {
+	Repository chat.Repository
+	reminders  *service.ReminderEngine
+}
+
+func NewRemindHandler(r chat.Repository, e *service.ReminderEngine) *RemindHandler {
+	return &RemindHandler{r, e}
+}
+
+// Handle обрабатывает "/remind" (показать настройку) и "/remind N" (напоминать за N минут).
+func (h *RemindHandler) Handle(ctx context.Context, b *tgbotapi.BotAPI, update *tgbotapi.Update) {
+	if update == nil || update.Message == nil {
+		return
+	}
+	chatID := update.Message.Chat.ID
+
+	settings, err := h.Repository.GetSettings(ctx, chatID)
+	if err != nil {
+		log.Printf("[RemindHandler.Handle] GetSettings chatID=%d err=%v", chatID, err)
+	}
+
+	arg := strings.TrimSpace(update.Message.CommandArguments())
+	if arg == "" {
+		msg := fmt.Sprintf("Напоминаю за %d мин. до задачи.\nЧтобы изменить, отправь /remind N, где N — минуты (0–%d).",
+			settings.RemindBefore, maxRemindBefore)
+		if err := sendWithMenu(ctx, b, h.Repository, chatID, msg); err != nil {
+			log.Println("[RemindHandler.Handle] SendWithMenu:", err)
+		}
+		return
+	}
+
+	minutes, err := strconv.Atoi(arg)
+	if err != nil || minutes < 0 || minutes > maxRemindBefore {
+		msg := fmt.Sprintf("Не понял «%s». Укажи число минут от 0 до %d, например /remind 30.", arg, maxRemindBefore)
+		if err := sendWithMenu(ctx, b, h.Repository, chatID, msg); err != nil {
+			log.Println("[RemindHandler.Handle] SendWithMenu:", err)
+		}
+		return
+	}
+
+	settings.RemindBefore = minutes
+	ok, err := h.Repository.UpdateSettings(ctx, chatID, settings)
+	if err != nil {
+		log.Printf("[RemindHandler.Handle] UpdateSettings chatID=%d err=%v", chatID, err)
+		_, _ = b.Send(tgbotapi.NewMessage(chatID, "Не удалось сохранить настройку 😔"))
+		return
+	}
+	if !ok {
+		if err := sendWithStart(ctx, b, chatID, "Сначала нажми /start, чтобы настроить часовой пояс."); err != nil {
+			log.Println("[RemindHandler.Handle] sendWithStart:", err)
+		}
+		return
+	}
+
+	h.reminders.Rearm()
+
+	msg := fmt.Sprintf("Готово! Буду напоминать за %d мин. до задачи.", minutes)
+	if err := sendWithMenu(ctx, b, h.Repository, chatID, msg); err != nil {
+		log.Println("[RemindHandler.Handle] SendWithMenu:", err)
+	}
+}
@@ -0,0 +1,97 @@
+package bot
+
+import (
+	"adventBot/internal/db/task"
+	"context"
+	"fmt"
+	"github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TaskRefHandler обрабатывает "/done N", "/snooze N", "/tomorrow N" и "/delete N", где N — номер задачи
+// из /tasks. Действует только на открытые задачи.
+type TaskRefHandler struct {
+	taskRepo task.Repository
+}
+
+func NewTaskRefHandler(r task.Repository) *TaskRefHandler { return &TaskRefHandler{r} }
+
+func (h *TaskRefHandler) Handle(ctx context.Context, b *tgbotapi.BotAPI, update *tgbotapi.Update) {
+	if update == nil || update.Message == nil {
+		return
+	}
+	chatID := update.Message.Chat.ID
+	command := update.Message.Command()
+
+	arg := strings.TrimSpace(update.Message.CommandArguments())
+	id, err := strconv.ParseInt(strings.TrimPrefix(arg, "#"), 10, 64)
+	if err != nil {
+		h.reply(b, chatID, fmt.Sprintf("Укажи номер задачи из /tasks, например /%s 12.", command))
+		return
+	}
+
+	t, found, err := openTask(ctx, h.taskRepo, chatID, id)
+	if err != nil {
+		log.Printf("[TaskRefHandler.Handle] openTask chatID=%d id=%d err=%v", chatID, id, err)
+		h.reply(b, chatID, "Не удалось загрузить задачи 😔")
+		return
+	}
+	if !found {
+		h.reply(b, chatID, fmt.Sprintf("Среди открытых задач нет #%d. Посмотреть номера: /tasks", id))
+		return
+	}
+
+	switch command {
+	case "done":
+		// в списке регулярная задача представлена ближайшим повторением — отмечается только оно
+		if at, err := t.Due(); err == nil && t.IsRecurring() {
+			_, err = h.taskRepo.SetOccurrenceStatus(ctx, chatID, t.ID, at, task.StatusDone)
+		} else {
+			_, err = h.taskRepo.Complete(ctx, chatID, t.ID)
+		}
+		if err != nil {
+			log.Printf("[TaskRefHandler.Handle] done chatID=%d id=%d err=%v", chatID, t.ID, err)
+			h.reply(b, chatID, "Не удалось выполнить действие 😔")
+			return
+		}
+		h.reply(b, chatID, "✅ Выполнено\n"+formatTask(t))
+
+	case "snooze", "tomorrow":
+		if t.IsRecurring() {
+			h.reply(b, chatID, fmt.Sprintf("Регулярную задачу нельзя перенести. Отметить повторение: /done %d", t.ID))
+			return
+		}
+		t, err = shiftTask(t, taskAction(command), time.Now())
+		if err != nil {
+			log.Printf("[TaskRefHandler.Handle] invalid dateTime=%q id=%d err=%v", t.DateTime, t.ID, err)
+			h.reply(b, chatID, "Не удалось перенести задачу 😔")
+			return
+		}
+		if _, err := h.taskRepo.Update(ctx, t); err != nil {
+			log.Printf("[TaskRefHandler.Handle] Update chatID=%d id=%d err=%v", chatID, t.ID, err)
+			h.reply(b, chatID, "Не удалось перенести задачу 😔")
+			return
+		}
+		h.reply(b, chatID, "⏰ Задача перенесена\n"+formatTask(t))
+
+	case "delete":
+		if _, err := h.taskRepo.Delete(ctx, chatID, t.ID); err != nil {
+			log.Printf("[TaskRefHandler.Handle] Delete chatID=%d id=%d err=%v", chatID, t.ID, err)
+			h.reply(b, chatID, "Не удалось удалить задачу 😔")
+			return
+		}
+		h.reply(b, chatID, "🗑 Задача удалена\n"+formatTask(t))
+
+	default:
+		log.Printf("[TaskRefHandler.Handle] unsupported command=%q chatID=%d", command, chatID)
+	}
+}
+
+func (h *TaskRefHandler) reply(b *tgbotapi.BotAPI, chatID int64, text string) {
+	if _, err := b.Send(tgbotapi.NewMessage(chatID, text)); err != nil {
+		log.Printf("[TaskRefHandler.reply] Error sending message: %v", err)
+	}
+}
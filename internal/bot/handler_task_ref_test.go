@@ -0,0 +1,125 @@
+package bot
+
+import (
+	"adventBot/internal/bot/tgtest"
+	"adventBot/internal/db/store"
+	"adventBot/internal/db/task"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// newTaskRefBot — бот с /tasks и командами по номеру задачи для чата 42.
+func newTaskRefBot(t *testing.T) (*tgtest.Conversation, *store.Store) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	st := store.NewMemory()
+	if err := st.Chats.Upsert(ctx, 42, "Europe/Moscow"); err != nil {
+		t.Fatal(err)
+	}
+
+	taskRef := NewTaskRefHandler(st.Tasks)
+	router := NewRouter()
+	router.Command("tasks", "Все открытые задачи", NewTasksHandler(st.Tasks))
+	for _, command := range []string{"done", "snooze", "tomorrow", "delete"} {
+		router.Command(command, "", taskRef)
+	}
+
+	srv := tgtest.NewServer()
+	t.Cleanup(srv.Close)
+	if _, err := srv.Run(ctx, router); err != nil {
+		t.Fatal(err)
+	}
+	return srv.Conversation(t, 42), st
+}
+
+func createTask(t *testing.T, st *store.Store, tt task.Task) int64 {
+	t.Helper()
+	tt.ChatID = 42
+	id, err := st.Tasks.Create(context.Background(), tt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestTaskRefStableAcrossListChanges(t *testing.T) {
+	c, st := newTaskRefBot(t)
+	milk := createTask(t, st, task.Task{Task: "Купить молоко", DateTime: "2030-01-01T10:00:00+03:00"})
+	doctor := createTask(t, st, task.Task{Task: "Врач", DateTime: "2030-01-02T10:00:00+03:00"})
+	gym := createTask(t, st, task.Task{Task: "Зал", DateTime: "2030-01-03T10:00:00+03:00"})
+
+	c.Command("/tasks")
+	list := c.Expect("Ваши задачи")
+	if !list.Contains(fmt.Sprintf("#%d <b>Врач</b>", doctor)) {
+		t.Fatalf("/tasks = %q, want Врач under #%d", list.Text, doctor)
+	}
+
+	// После /tasks список меняется: появляется более ранняя задача, первая выполнена
+	bills := createTask(t, st, task.Task{Task: "Оплатить счёт", DateTime: "2029-12-31T10:00:00+03:00"})
+	c.Command(fmt.Sprintf("/done %d", milk))
+	c.Expect("✅ Выполнено")
+
+	c.Command(fmt.Sprintf("/delete %d", doctor))
+	c.Expect("🗑 Задача удалена\nЗадача: Врач")
+
+	tasks, err := st.Tasks.GetAll(context.Background(), 42)
+	if err != nil || len(tasks) != 2 || tasks[0].ID != bills || tasks[1].ID != gym {
+		t.Fatalf("open tasks = %+v, %v; want the bill and the gym", tasks, err)
+	}
+
+	// Номер закрытой задачи больше ничего не трогает
+	c.Command(fmt.Sprintf("/delete %d", milk))
+	c.Expect(fmt.Sprintf("Среди открытых задач нет #%d", milk))
+	if got, found, _ := st.Tasks.GetByID(context.Background(), 42, milk); !found || got.Status != task.StatusDone {
+		t.Errorf("done task after /delete = %+v, %t", got, found)
+	}
+
+	c.Command("/done молоко")
+	c.Expect("Укажи номер задачи из /tasks")
+}
+
+func TestTaskRefSnoozeAndTomorrow(t *testing.T) {
+	c, st := newTaskRefBot(t)
+	call := createTask(t, st, task.Task{Task: "Позвонить маме", DateTime: "2030-01-01T10:00:00+03:00"})
+	overdue := createTask(t, st, task.Task{Task: "Оплатить счёт", DateTime: time.Now().Add(-24 * time.Hour).Format(time.RFC3339)})
+	start := time.Now().AddDate(0, 0, -2).Format(time.RFC3339)
+	gym := createTask(t, st, task.Task{Task: "Зарядка", DateTime: start, Recurrence: "FREQ=DAILY"})
+
+	due := func(id int64) string {
+		t.Helper()
+		got, _, err := st.Tasks.GetByID(context.Background(), 42, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got.DateTime
+	}
+
+	c.Command(fmt.Sprintf("/snooze %d", call))
+	c.Expect("⏰ Задача перенесена")
+	if got := due(call); got != "2030-01-01T11:00:00+03:00" {
+		t.Errorf("after /snooze dateTime = %s", got)
+	}
+	c.Command(fmt.Sprintf("/tomorrow %d", call))
+	c.Expect("⏰ Задача перенесена")
+	if got := due(call); got != "2030-01-02T11:00:00+03:00" {
+		t.Errorf("after /tomorrow dateTime = %s", got)
+	}
+
+	before := time.Now().Truncate(time.Minute)
+	c.Command(fmt.Sprintf("/snooze %d", overdue))
+	c.Expect("⏰ Задача перенесена")
+	if got, err := time.Parse(time.RFC3339, due(overdue)); err != nil || got.Before(before.Add(time.Hour)) {
+		t.Errorf("overdue task after /snooze dateTime = %s, want about an hour from now", got)
+	}
+
+	c.Command(fmt.Sprintf("/snooze %d", gym))
+	c.Expect("Регулярную задачу нельзя перенести")
+	if got := due(gym); got != start {
+		t.Errorf("recurring task moved to %s", got)
+	}
+}
@@ -5,9 +5,20 @@ import (
 	"context"
 	"fmt"
 	"github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"html"
 	"log"
+	"strconv"
+	"strings"
 )
 
+const (
+	TasksCallbackPrefix = "tasks"
+	tasksPageSize       = 10
+)
+
+// TasksHandler показывает открытые задачи одним сообщением по страницам. У каждой задачи в списке
+// её постоянный номер (ID): порядок списка меняется с каждой новой или закрытой задачей, а номер —
+// нет, поэтому /done N, /snooze N, /tomorrow N и /delete N не заденут не ту задачу.
 type TasksHandler struct {
 	taskRepo task.Repository
 }
@@ -15,33 +26,149 @@ type TasksHandler struct {
 func NewTasksHandler(r task.Repository) *TasksHandler { return &TasksHandler{r} }
 
 func (h *TasksHandler) Handle(ctx context.Context, b *tgbotapi.BotAPI, update *tgbotapi.Update) {
-	if update == nil || update.Message == nil {
+	if update == nil {
+		return
+	}
+	if update.CallbackQuery != nil {
+		h.handleCallback(ctx, b, update.CallbackQuery)
+		return
+	}
+	if update.Message == nil {
 		return
 	}
 
 	chatID := update.Message.Chat.ID
 
-	tasks, err := h.taskRepo.GetAll(chatID)
+	tasks, err := h.taskRepo.GetAll(ctx, chatID)
 	if err != nil {
 		log.Println("[TasksHandler.Handle] error getting tasks: ", err)
+		if _, err := b.Send(tgbotapi.NewMessage(chatID, "Не удалось загрузить задачи 😔")); err != nil {
+			log.Printf("[TasksHandler.Handle] Error sending message: %v", err)
+		}
+		return
+	}
+
+	text, kb := renderTasksPage(tasks, 1)
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = tgbotapi.ModeHTML
+	if kb != nil {
+		msg.ReplyMarkup = *kb
+	}
+	if _, err := b.Send(msg); err != nil {
+		log.Printf("[TasksHandler.Handle] Error sending message: %v", err)
+	}
+}
+
+func (h *TasksHandler) handleCallback(ctx context.Context, b *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery) {
+	if query.Message == nil {
+		return
 	}
+	chatID := query.Message.Chat.ID
 
+	page, ok := parseTasksCallbackData(query.Data)
+	if !ok {
+		log.Printf("[TasksHandler.handleCallback] unknown callback data=%q chatID=%d", query.Data, chatID)
+		answerCallback(b, query.ID, "Неизвестное действие")
+		return
+	}
+
+	tasks, err := h.taskRepo.GetAll(ctx, chatID)
+	if err != nil {
+		log.Printf("[TasksHandler.handleCallback] GetAll chatID=%d err=%v", chatID, err)
+		answerCallback(b, query.ID, "Не удалось загрузить задачи")
+		return
+	}
+
+	text, kb := renderTasksPage(tasks, page)
+	edit := tgbotapi.NewEditMessageText(chatID, query.Message.MessageID, text)
+	edit.ParseMode = tgbotapi.ModeHTML
+	edit.ReplyMarkup = kb
+	answerCallback(b, query.ID, "")
+	editMessage(b, edit)
+}
+
+// renderTasksPage собирает страницу page (с 1) списка задач в HTML. Номер страницы за пределами
+// списка сдвигается к ближайшей существующей. Клавиатура nil, если страница одна.
+func renderTasksPage(tasks []task.Task, page int) (string, *tgbotapi.InlineKeyboardMarkup) {
 	if len(tasks) == 0 {
-		msg := tgbotapi.NewMessage(chatID, "У вас не осталось задач")
-		if _, err := b.Send(msg); err != nil {
-			log.Printf("[TodayHandler.Handle] Error sending message: %v", err)
-		}
-	} else {
-		msg := tgbotapi.NewMessage(chatID, "Ваши задачи: ")
-		if _, err := b.Send(msg); err != nil {
-			log.Printf("[TodayHandler.Handle] Error sending message: %v", err)
-		}
+		return "У вас не осталось задач", nil
 	}
 
+	pages := (len(tasks) + tasksPageSize - 1) / tasksPageSize
+	page = min(max(page, 1), pages)
+	first := (page - 1) * tasksPageSize
+	last := min(first+tasksPageSize, len(tasks))
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "<b>Ваши задачи</b> (%d)", len(tasks))
+	if pages > 1 {
+		fmt.Fprintf(&sb, ", стр. %d/%d", page, pages)
+	}
+	sb.WriteString("\n")
+	for i := first; i < last; i++ {
+		sb.WriteString("\n" + formatTaskEntry(tasks[i]))
+	}
+	sb.WriteString("\n\nN — номер задачи после #. Выполнено: /done N, на час позже: /snooze N, на завтра: /tomorrow N, удалить: /delete N")
+
+	if pages == 1 {
+		return sb.String(), nil
+	}
+
+	var row []tgbotapi.InlineKeyboardButton
+	if page > 1 {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData("◀ Назад", tasksCallbackData(page-1)))
+	}
+	if page < pages {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData("Вперёд ▶", tasksCallbackData(page+1)))
+	}
+	kb := tgbotapi.NewInlineKeyboardMarkup(row)
+	return sb.String(), &kb
+}
+
+// formatTaskEntry — пункт списка: номер задачи, текст, дата и место. Пользовательский текст экранируется.
+func formatTaskEntry(t task.Task) string {
+	when := t.DateTime
+	if due, err := t.Due(); err == nil {
+		when = due.Format("02.01.2006 15:04")
+	}
+
+	line := fmt.Sprintf("#%d <b>%s</b>\n    📅 %s", t.ID, html.EscapeString(t.Task), html.EscapeString(when))
+	if t.Location != "" {
+		line += " · 📍 " + html.EscapeString(t.Location)
+	}
+	if t.IsRecurring() {
+		line += " 🔁"
+	}
+	return line
+}
+
+// openTask возвращает открытую задачу с номером id так, как она показана в /tasks: регулярная —
+// ближайшим открытым повторением. false — такой открытой задачи в чате нет.
+func openTask(ctx context.Context, r task.Repository, chatID int64, id int64) (task.Task, bool, error) {
+	tasks, err := r.GetAll(ctx, chatID)
+	if err != nil {
+		return task.Task{}, false, err
+	}
 	for _, t := range tasks {
-		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("Задача: %s\nДата: %s\nЛокация: %s", t.Task, t.DateTime, t.Location))
-		if _, err := b.Send(msg); err != nil {
-			log.Printf("[TodayHandler.Handle] Error sending message: %v", err)
+		if t.ID == id {
+			return t, true, nil
 		}
 	}
+	return task.Task{}, false, nil
+}
+
+func tasksCallbackData(page int) string {
+	return fmt.Sprintf("%s:page:%d", TasksCallbackPrefix, page)
+}
+
+func parseTasksCallbackData(data string) (page int, ok bool) {
+	parts := strings.Split(data, ":")
+	if len(parts) != 3 || parts[0] != TasksCallbackPrefix || parts[1] != "page" {
+		return 0, false
+	}
+	page, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return 0, false
+	}
+	return page, true
 }
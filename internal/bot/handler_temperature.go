@@ -32,7 +32,7 @@ func (h *TemperatureHandler) Handle(ctx context.Context, b *tgbotapi.BotAPI, upd
 	}
 
 	var reply string
-	reply, temp = h.Model.AskWithTemperature(txt, temp)
+	reply, temp = h.Model.AskWithTemperature(ctx, txt, temp)
 
 	msg := tgbotapi.NewMessage(update.Message.Chat.ID, fmt.Sprintf("%v\n%s", temp, reply))
 	_, err := b.Send(msg)
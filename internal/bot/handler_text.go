@@ -5,18 +5,25 @@ import (
 	"adventBot/internal/db/chat"
 	"adventBot/internal/db/message"
 	"context"
+	"errors"
 	"github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"log"
+	"time"
 )
 
+// timeoutReply отправляется, когда модель не ответила за Timeout: запрос к ней отменён и ничего не сохранено.
+const timeoutReply = "⌛ Не дождался ответа модели, запрос отменён. Отправь сообщение ещё раз чуть позже."
+
 type TextHandler struct {
 	Model          ai_model.AiModel
 	ChatRepository chat.Repository
 	MsgRepository  message.Repository
+	// Timeout ограничивает ответ модели на одно сообщение; 0 — без ограничения.
+	Timeout time.Duration
 }
 
-func NewTextHandler(model ai_model.AiModel, r chat.Repository, m message.Repository) *TextHandler {
-	return &TextHandler{Model: model, ChatRepository: r, MsgRepository: m}
+func NewTextHandler(model ai_model.AiModel, r chat.Repository, m message.Repository, timeout time.Duration) *TextHandler {
+	return &TextHandler{Model: model, ChatRepository: r, MsgRepository: m, Timeout: timeout}
 }
 
 func (h *TextHandler) Handle(ctx context.Context, b *tgbotapi.BotAPI, update *tgbotapi.Update) {
@@ -32,8 +39,44 @@ func (h *TextHandler) Handle(ctx context.Context, b *tgbotapi.BotAPI, update *tg
 	}
 
 	payload := h.getInput(ctx, update, tz)
-	reply := h.Model.AskGpt(ctx, chatID, payload, true)
-	_ = sendWithMenu(ctx, b, h.ChatRepository, chatID, reply)
+	reply, err := h.ask(ctx, chatID, payload)
+	switch {
+	case ctx.Err() != nil:
+		// Бот останавливается — ответ уже не нужен
+		log.Printf("[TextHandler.Handle] chatID=%d request cancelled: %v", chatID, ctx.Err())
+		return
+	case err != nil:
+		log.Printf("[TextHandler.Handle] chatID=%d no reply in %s, request cancelled", chatID, h.Timeout)
+		_ = sendWithMenu(ctx, b, h.ChatRepository, chatID, timeoutReply)
+		return
+	case reply.Pending == nil:
+		_ = sendWithMenu(ctx, b, h.ChatRepository, chatID, reply.Text)
+		return
+	}
+
+	// Черновик задач: сохраняются они только по кнопке
+	msg := tgbotapi.NewMessage(chatID, reply.Text)
+	msg.ReplyMarkup = pendingKeyboard(reply.Pending.CreatedAt)
+	if _, err := b.Send(msg); err != nil {
+		log.Printf("[TextHandler.Handle] Error sending message: %v", err)
+	}
+}
+
+// ask спрашивает модель, ограничивая ответ Timeout. Если модель не успела, возвращает
+// context.DeadlineExceeded — кроме случая, когда черновик задач всё же сохранён.
+func (h *TextHandler) ask(ctx context.Context, chatID int64, payload ai_model.InputForm) (ai_model.Reply, error) {
+	if h.Timeout <= 0 {
+		return h.Model.AskGpt(ctx, chatID, payload, true), nil
+	}
+
+	askCtx, cancel := context.WithTimeout(ctx, h.Timeout)
+	defer cancel()
+
+	reply := h.Model.AskGpt(askCtx, chatID, payload, true)
+	if reply.Pending == nil && errors.Is(askCtx.Err(), context.DeadlineExceeded) {
+		return reply, askCtx.Err()
+	}
+	return reply, nil
 }
 
 func (h *TextHandler) getTimeZone(ctx context.Context, b *tgbotapi.BotAPI, update *tgbotapi.Update) (found bool, tz string) {
@@ -46,8 +89,7 @@ func (h *TextHandler) getTimeZone(ctx context.Context, b *tgbotapi.BotAPI, updat
 	}
 
 	if !found {
-		reply := "Не помню твою временную зону. Давай начнём сначала. Нажми /start, чтобы настроить часовой пояс или /restart если мы уже знакомы."
-		err := sendWithStart(ctx, b, chatID, reply)
+		err := sendWithStart(ctx, b, chatID, unknownChatReply)
 		if err != nil {
 			log.Printf("[TextHandler.Handle.getTimeZone] Error sendWithStart chatID=%d err=%v", chatID, err)
 			return false, ""
@@ -0,0 +1,60 @@
+package bot
+
+import (
+	"adventBot/internal/ai_model"
+	"adventBot/internal/bot/tgtest"
+	"adventBot/internal/db/store"
+	"context"
+	"testing"
+	"time"
+)
+
+type userRole struct{}
+
+func (userRole) GetValue() string { return "user" }
+
+// stuckModel отвечает только после отмены запроса, как модель, которая не уложилась в Timeout.
+type stuckModel struct {
+	ai_model.AiModel
+	cancelled chan struct{}
+}
+
+func (m *stuckModel) AskGpt(ctx context.Context, chatId int64, inputForm ai_model.InputForm, isCot bool) ai_model.Reply {
+	<-ctx.Done()
+	close(m.cancelled)
+	return ai_model.Reply{Text: "Не удалось получить ответ"}
+}
+
+func (m *stuckModel) GetUserRole() ai_model.Role {
+	return userRole{}
+}
+
+func TestTextReplyTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	st := store.NewMemory()
+	if err := st.Chats.Upsert(ctx, 42, "Europe/Moscow"); err != nil {
+		t.Fatal(err)
+	}
+	model := &stuckModel{cancelled: make(chan struct{})}
+
+	router := NewRouter()
+	router.Text(NewTextHandler(model, st.Chats, st.Messages, 50*time.Millisecond))
+
+	srv := tgtest.NewServer()
+	t.Cleanup(srv.Close)
+	if _, err := srv.Run(ctx, router); err != nil {
+		t.Fatal(err)
+	}
+
+	c := srv.Conversation(t, 42)
+	c.Type("завтра к врачу")
+	c.Expect("запрос отменён")
+
+	select {
+	case <-model.cancelled:
+	default:
+		t.Error("the model request was not cancelled")
+	}
+}
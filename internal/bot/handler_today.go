@@ -1,28 +1,36 @@
 package bot
 
 import (
+	"adventBot/internal/db/chat"
 	"adventBot/internal/db/task"
 	"adventBot/internal/utils"
 	"context"
-	"fmt"
 	"github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"log"
+	"time"
 )
 
 type TodayHandler struct {
 	taskRepo task.Repository
+	chatRepo chat.Repository
 }
 
-func NewTodayHandler(r task.Repository) *TodayHandler { return &TodayHandler{r} }
+func NewTodayHandler(r task.Repository, c chat.Repository) *TodayHandler { return &TodayHandler{r, c} }
 
-func (h *TodayHandler) Handle(_ context.Context, b *tgbotapi.BotAPI, update *tgbotapi.Update) {
+func (h *TodayHandler) Handle(ctx context.Context, b *tgbotapi.BotAPI, update *tgbotapi.Update) {
 	if update == nil || update.Message == nil {
 		return
 	}
 
 	chatID := update.Message.Chat.ID
 
-	tasks, err := h.taskRepo.GetToday(chatID, utils.GetToday())
+	tz, _, err := h.chatRepo.GetById(ctx, chatID)
+	if err != nil {
+		log.Println("[TodayHandler.Handle] error getting time zone: ", err)
+	}
+
+	from, to := utils.DayRange(time.Now().In(utils.LoadLocation(tz)))
+	tasks, err := h.taskRepo.ListRange(ctx, chatID, from, to)
 	if err != nil {
 		log.Println("[TodayHandler.Handle] error getting tasks: ", err)
 	}
@@ -40,8 +48,7 @@ func (h *TodayHandler) Handle(_ context.Context, b *tgbotapi.BotAPI, update *tgb
 	}
 
 	for _, t := range tasks {
-		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("Задача: %s\nДата: %s\nЛокация: %s", t.Task, t.DateTime, t.Location))
-		if _, err := b.Send(msg); err != nil {
+		if _, err := b.Send(newTaskMessage(chatID, t)); err != nil {
 			log.Printf("[TodayHandler.Handle] Error sending message: %v", err)
 		}
 	}
@@ -15,14 +15,14 @@ func NewTriggerHandler(m *service.SchedulerManager) *TriggerHandler {
 	return &TriggerHandler{m}
 }
 
-func (h *TriggerHandler) Handle(_ context.Context, b *tgbotapi.BotAPI, update *tgbotapi.Update) {
+func (h *TriggerHandler) Handle(ctx context.Context, b *tgbotapi.BotAPI, update *tgbotapi.Update) {
 	if update == nil || update.Message == nil {
 		return
 	}
 
 	chatID := update.Message.Chat.ID
 	if s := h.manager.GetScheduler(chatID); s != nil {
-		s.ProcessNow()
+		s.ProcessNow(ctx)
 	} else {
 		scheduleMsg := tgbotapi.NewMessage(chatID, "Scheduler не найден")
 		if _, err := b.Send(scheduleMsg); err != nil {
@@ -36,6 +36,7 @@ func buildMainKeyboard(ctx context.Context, r chat.Repository, chatID int64) tgb
 		keyboard := tgbotapi.NewReplyKeyboard(
 			tgbotapi.NewKeyboardButtonRow(
 				tgbotapi.NewKeyboardButton("/today"),
+				tgbotapi.NewKeyboardButton("/week"),
 				tgbotapi.NewKeyboardButton("/tasks"),
 			),
 			tgbotapi.NewKeyboardButtonRow(
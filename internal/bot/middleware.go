@@ -0,0 +1,205 @@
+package bot
+
+import (
+	"adventBot/internal/db/chat"
+	"context"
+	"github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"log"
+	"runtime/debug"
+	"slices"
+	"sync"
+	"time"
+)
+
+const unknownChatReply = "Не помню твою временную зону. Давай начнём сначала. Нажми /start, чтобы настроить часовой пояс или /restart если мы уже знакомы."
+
+// Logging пишет в лог, что за апдейт пришёл и сколько заняла его обработка.
+func Logging() Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, b *tgbotapi.BotAPI, update *tgbotapi.Update) {
+			start := time.Now()
+			next.Handle(ctx, b, update)
+			chatID, _ := updateChatID(update)
+			log.Printf("[Router] chatID=%d %s took=%s", chatID, describeUpdate(update), time.Since(start).Round(time.Millisecond))
+		})
+	}
+}
+
+// Recover не даёт панике в обработчике уронить бота: пишет стек в лог и сообщает пользователю об ошибке.
+func Recover() Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, b *tgbotapi.BotAPI, update *tgbotapi.Update) {
+			defer func() {
+				if p := recover(); p != nil {
+					log.Printf("[Router] panic on %s: %v\n%s", describeUpdate(update), p, debug.Stack())
+					if query := update.CallbackQuery; query != nil {
+						answerCallback(b, query.ID, "Что-то пошло не так 😔")
+						return
+					}
+					if chatID, ok := updateChatID(update); ok {
+						if _, err := b.Send(tgbotapi.NewMessage(chatID, "Что-то пошло не так 😔 Попробуй ещё раз.")); err != nil {
+							log.Println("[Router] Send:", err)
+						}
+					}
+				}
+			}()
+			next.Handle(ctx, b, update)
+		})
+	}
+}
+
+// Auth пропускает только апдейты из чатов allowed; пустой список пропускает всех.
+// Апдейты из остальных чатов молча отбрасываются.
+func Auth(allowed []int64) Middleware {
+	return func(next Handler) Handler {
+		if len(allowed) == 0 {
+			return next
+		}
+		return HandlerFunc(func(ctx context.Context, b *tgbotapi.BotAPI, update *tgbotapi.Update) {
+			chatID, ok := updateChatID(update)
+			if !ok || !slices.Contains(allowed, chatID) {
+				log.Printf("[Router] chatID=%d is not allowed, dropping %s", chatID, describeUpdate(update))
+				return
+			}
+			next.Handle(ctx, b, update)
+		})
+	}
+}
+
+// rateWindow — счётчик апдейтов одного чата в текущем окне RateLimit.
+type rateWindow struct {
+	start  time.Time
+	count  int
+	warned bool
+}
+
+// RateLimit пропускает от одного чата не больше limit апдейтов за interval. О превышении
+// пользователь узнаёт один раз за окно, остальные лишние апдейты отбрасываются. limit <= 0 отключает ограничение.
+func RateLimit(limit int, interval time.Duration) Middleware {
+	var (
+		mu      sync.Mutex
+		windows = make(map[int64]*rateWindow)
+	)
+
+	// allow возвращает, можно ли обработать апдейт, и нужно ли предупредить пользователя.
+	allow := func(chatID int64, now time.Time) (ok bool, warn bool) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		w, found := windows[chatID]
+		if !found || now.Sub(w.start) >= interval {
+			// Заодно выбрасываем окна молчащих чатов, чтобы карта не росла бесконечно
+			for id, old := range windows {
+				if now.Sub(old.start) >= interval {
+					delete(windows, id)
+				}
+			}
+			w = &rateWindow{start: now}
+			windows[chatID] = w
+		}
+
+		w.count++
+		if w.count <= limit {
+			return true, false
+		}
+		warn = !w.warned
+		w.warned = true
+		return false, warn
+	}
+
+	return func(next Handler) Handler {
+		if limit <= 0 {
+			return next
+		}
+		return HandlerFunc(func(ctx context.Context, b *tgbotapi.BotAPI, update *tgbotapi.Update) {
+			chatID, ok := updateChatID(update)
+			if !ok {
+				next.Handle(ctx, b, update)
+				return
+			}
+
+			allowed, warn := allow(chatID, time.Now())
+			if allowed {
+				next.Handle(ctx, b, update)
+				return
+			}
+
+			log.Printf("[Router] chatID=%d rate limited, dropping %s", chatID, describeUpdate(update))
+			const reply = "Слишком много сообщений. Подожди немного и попробуй снова."
+			if query := update.CallbackQuery; query != nil {
+				answerCallback(b, query.ID, reply)
+				return
+			}
+			if warn {
+				if _, err := b.Send(tgbotapi.NewMessage(chatID, reply)); err != nil {
+					log.Println("[Router] Send:", err)
+				}
+			}
+		})
+	}
+}
+
+// RequireChat пропускает апдейты только от чатов, для которых известен часовой пояс; остальным
+// предлагает начать с /start. Команды public и присланная геолокация пропускаются всегда — через
+// них чат и настраивается.
+func RequireChat(r chat.Repository, public ...string) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, b *tgbotapi.BotAPI, update *tgbotapi.Update) {
+			if msg := update.Message; msg != nil && (msg.Location != nil || slices.Contains(public, msg.Command())) {
+				next.Handle(ctx, b, update)
+				return
+			}
+
+			chatID, ok := updateChatID(update)
+			if !ok {
+				next.Handle(ctx, b, update)
+				return
+			}
+
+			_, found, err := r.GetById(ctx, chatID)
+			if err != nil {
+				log.Printf("[Router] RequireChat GetById chatID=%d err=%v", chatID, err)
+			}
+			if found {
+				next.Handle(ctx, b, update)
+				return
+			}
+
+			if query := update.CallbackQuery; query != nil {
+				answerCallback(b, query.ID, "Сначала нажми /start")
+				return
+			}
+			if err := sendWithStart(ctx, b, chatID, unknownChatReply); err != nil {
+				log.Printf("[Router] RequireChat sendWithStart chatID=%d err=%v", chatID, err)
+			}
+		})
+	}
+}
+
+// updateChatID возвращает чат, из которого пришло сообщение или нажатие кнопки.
+func updateChatID(update *tgbotapi.Update) (int64, bool) {
+	switch {
+	case update.Message != nil:
+		return update.Message.Chat.ID, true
+	case update.CallbackQuery != nil && update.CallbackQuery.Message != nil:
+		return update.CallbackQuery.Message.Chat.ID, true
+	default:
+		return 0, false
+	}
+}
+
+// describeUpdate — короткое описание апдейта для логов, без текста сообщения.
+func describeUpdate(update *tgbotapi.Update) string {
+	switch {
+	case update.CallbackQuery != nil:
+		return "callback " + update.CallbackQuery.Data
+	case update.Message == nil:
+		return "update"
+	case update.Message.IsCommand():
+		return "command /" + update.Message.Command()
+	case update.Message.Location != nil:
+		return "location"
+	default:
+		return "text"
+	}
+}
@@ -0,0 +1,137 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"log"
+	"strings"
+)
+
+// HandlerFunc позволяет использовать обычную функцию как Handler.
+type HandlerFunc func(ctx context.Context, b *tgbotapi.BotAPI, update *tgbotapi.Update)
+
+func (f HandlerFunc) Handle(ctx context.Context, b *tgbotapi.BotAPI, update *tgbotapi.Update) {
+	f(ctx, b, update)
+}
+
+// Middleware оборачивает обработку апдейта: может что-то сделать до и после next или не вызвать его вовсе.
+type Middleware func(next Handler) Handler
+
+type callbackRoute struct {
+	prefix  string
+	handler Handler
+}
+
+// Router направляет апдейты по командам, префиксам данных inline-кнопок, геолокации и тексту.
+// Все маршруты регистрируются до начала обработки апдейтов; Handle можно вызывать конкурентно.
+type Router struct {
+	commands   map[string]Handler
+	menu       []tgbotapi.BotCommand
+	callbacks  []callbackRoute
+	location   Handler
+	text       Handler
+	middleware []Middleware
+}
+
+func NewRouter() *Router {
+	return &Router{commands: make(map[string]Handler)}
+}
+
+// Use добавляет middleware; первая добавленная выполняется первой.
+func (r *Router) Use(mw ...Middleware) {
+	r.middleware = append(r.middleware, mw...)
+}
+
+// Command регистрирует обработчик команды name (без "/"). Команды с описанием попадают в меню
+// Telegram через SetMyCommands, без описания — только обрабатываются.
+func (r *Router) Command(name string, description string, h Handler) {
+	if _, ok := r.commands[name]; ok {
+		panic(fmt.Sprintf("bot: command %q registered twice", name))
+	}
+	r.commands[name] = h
+	if description != "" {
+		r.menu = append(r.menu, tgbotapi.BotCommand{Command: name, Description: description})
+	}
+}
+
+// Callback регистрирует обработчик inline-кнопок с данными вида "<prefix>:...".
+func (r *Router) Callback(prefix string, h Handler) {
+	r.callbacks = append(r.callbacks, callbackRoute{prefix: prefix + ":", handler: h})
+}
+
+// Location регистрирует обработчик присланной геолокации.
+func (r *Router) Location(h Handler) {
+	r.location = h
+}
+
+// Text регистрирует обработчик обычных текстовых сообщений.
+func (r *Router) Text(h Handler) {
+	r.text = h
+}
+
+// SetMyCommands отправляет в Telegram меню из команд с описаниями в порядке регистрации.
+func (r *Router) SetMyCommands(b *tgbotapi.BotAPI) error {
+	if _, err := b.Request(tgbotapi.NewSetMyCommands(r.menu...)); err != nil {
+		return fmt.Errorf("setMyCommands: %w", err)
+	}
+	log.Printf("[Router.SetMyCommands] registered %d commands", len(r.menu))
+	return nil
+}
+
+// Handle пропускает апдейт через middleware и передаёт подходящему обработчику.
+func (r *Router) Handle(ctx context.Context, b *tgbotapi.BotAPI, update *tgbotapi.Update) {
+	if update == nil {
+		return
+	}
+
+	var h Handler = HandlerFunc(r.dispatch)
+	for i := len(r.middleware) - 1; i >= 0; i-- {
+		h = r.middleware[i](h)
+	}
+	h.Handle(ctx, b, update)
+}
+
+func (r *Router) dispatch(ctx context.Context, b *tgbotapi.BotAPI, update *tgbotapi.Update) {
+	if query := update.CallbackQuery; query != nil {
+		for _, route := range r.callbacks {
+			if strings.HasPrefix(query.Data, route.prefix) {
+				route.handler.Handle(ctx, b, update)
+				return
+			}
+		}
+		log.Printf("[Router.dispatch] no route for callback data=%q", query.Data)
+		answerCallback(b, query.ID, "Неизвестное действие")
+		return
+	}
+
+	msg := update.Message
+	if msg == nil {
+		return
+	}
+
+	switch {
+	case msg.IsCommand():
+		if h, ok := r.commands[msg.Command()]; ok {
+			h.Handle(ctx, b, update)
+			return
+		}
+		r.unknownCommand(b, msg.Chat.ID)
+	case msg.Location != nil && r.location != nil:
+		r.location.Handle(ctx, b, update)
+	case msg.Text != "" && r.text != nil:
+		r.text.Handle(ctx, b, update)
+	}
+}
+
+// unknownCommand отвечает списком команд вместо того, чтобы отправлять команду модели.
+func (r *Router) unknownCommand(b *tgbotapi.BotAPI, chatID int64) {
+	lines := make([]string, 0, len(r.menu)+1)
+	lines = append(lines, "Не знаю такой команды. Вот что я умею:")
+	for _, c := range r.menu {
+		lines = append(lines, fmt.Sprintf("/%s — %s", c.Command, c.Description))
+	}
+	if _, err := b.Send(tgbotapi.NewMessage(chatID, strings.Join(lines, "\n"))); err != nil {
+		log.Println("[Router.unknownCommand] Send:", err)
+	}
+}
@@ -0,0 +1,161 @@
+package bot
+
+import (
+	"adventBot/internal/db/task"
+	"adventBot/internal/recurrence"
+	"fmt"
+	"github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const TaskCallbackPrefix = "task"
+
+type taskAction string
+
+const (
+	actionDone     taskAction = "done"
+	actionSnooze   taskAction = "snooze"
+	actionTomorrow taskAction = "tomorrow"
+	actionDelete   taskAction = "delete"
+	actionSkip     taskAction = "skip"
+	actionReject   taskAction = "reject"
+)
+
+func formatTask(t task.Task) string {
+	text := fmt.Sprintf("Задача: %s\nДата: %s\nЛокация: %s", t.Task, t.DateTime, t.Location)
+	if t.IsRecurring() {
+		if rule, err := recurrence.Parse(t.Recurrence); err == nil {
+			text += "\nПовтор: " + rule.Describe()
+		}
+	}
+	return text
+}
+
+// shiftTask переносит разовую задачу: actionSnooze — на час, но не раньше чем на час от now,
+// actionTomorrow — на сутки.
+func shiftTask(t task.Task, action taskAction, now time.Time) (task.Task, error) {
+	dt, err := t.Due()
+	if err != nil {
+		return t, err
+	}
+	if action == actionSnooze {
+		// Просроченная задача переносится на час от текущего момента, а не от прошедшего срока
+		if now = now.In(dt.Location()).Truncate(time.Minute); now.After(dt) {
+			dt = now
+		}
+		dt = dt.Add(time.Hour)
+	} else {
+		dt = dt.AddDate(0, 0, 1)
+	}
+	t.DateTime = dt.Format(time.RFC3339)
+	return t, nil
+}
+
+func taskCallbackData(action taskAction, id int64) string {
+	return fmt.Sprintf("%s:%s:%d", TaskCallbackPrefix, action, id)
+}
+
+// occurrenceCallbackData адресует одно повторение регулярной задачи: task:<action>:<id>:<unix>.
+func occurrenceCallbackData(action taskAction, id int64, at time.Time) string {
+	return fmt.Sprintf("%s:%d", taskCallbackData(action, id), at.Unix())
+}
+
+// parseTaskCallbackData разбирает данные кнопки; at нулевое, если кнопка относится ко всей задаче.
+func parseTaskCallbackData(data string) (action taskAction, id int64, at time.Time, ok bool) {
+	parts := strings.Split(data, ":")
+	if len(parts) < 3 || len(parts) > 4 || parts[0] != TaskCallbackPrefix {
+		return "", 0, time.Time{}, false
+	}
+
+	id, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return "", 0, time.Time{}, false
+	}
+
+	if len(parts) == 4 {
+		unix, err := strconv.ParseInt(parts[3], 10, 64)
+		if err != nil {
+			return "", 0, time.Time{}, false
+		}
+		at = time.Unix(unix, 0)
+	}
+
+	return taskAction(parts[1]), id, at, true
+}
+
+func taskKeyboard(id int64) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅ Готово", taskCallbackData(actionDone, id)),
+			tgbotapi.NewInlineKeyboardButtonData("⏰ +1 час", taskCallbackData(actionSnooze, id)),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("📅 Завтра", taskCallbackData(actionTomorrow, id)),
+			tgbotapi.NewInlineKeyboardButtonData("🗑 Удалить", taskCallbackData(actionDelete, id)),
+		),
+	)
+}
+
+// occurrenceKeyboard — действия над повторением регулярной задачи: перенос одного повторения
+// не поддерживается, поэтому вместо него можно пропустить повторение.
+func occurrenceKeyboard(id int64, at time.Time) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅ Готово", occurrenceCallbackData(actionDone, id, at)),
+			tgbotapi.NewInlineKeyboardButtonData("⏭ Пропустить", occurrenceCallbackData(actionSkip, id, at)),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🗑 Удалить серию", taskCallbackData(actionDelete, id)),
+		),
+	)
+}
+
+// rejectKeyboard — по кнопке на каждую только что сохранённую задачу, чтобы отменить её из подтверждения.
+func rejectKeyboard(tasks []task.Task) tgbotapi.InlineKeyboardMarkup {
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(tasks))
+	for _, t := range tasks {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("❌ Отменить: "+t.Task, taskCallbackData(actionReject, t.ID)),
+		))
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// withoutButton возвращает клавиатуру без кнопки с данными data; пустые ряды отбрасываются.
+func withoutButton(kb *tgbotapi.InlineKeyboardMarkup, data string) tgbotapi.InlineKeyboardMarkup {
+	res := tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}
+	if kb == nil {
+		return res
+	}
+	for _, row := range kb.InlineKeyboard {
+		var kept []tgbotapi.InlineKeyboardButton
+		for _, btn := range row {
+			if btn.CallbackData != nil && *btn.CallbackData == data {
+				continue
+			}
+			kept = append(kept, btn)
+		}
+		if len(kept) > 0 {
+			res.InlineKeyboard = append(res.InlineKeyboard, kept)
+		}
+	}
+	return res
+}
+
+// keyboardFor выбирает клавиатуру: для повторения регулярной задачи — occurrenceKeyboard.
+func keyboardFor(t task.Task) tgbotapi.InlineKeyboardMarkup {
+	if t.IsRecurring() {
+		if at, err := time.Parse(time.RFC3339, t.DateTime); err == nil {
+			return occurrenceKeyboard(t.ID, at)
+		}
+	}
+	return taskKeyboard(t.ID)
+}
+
+func newTaskMessage(chatID int64, t task.Task) tgbotapi.MessageConfig {
+	msg := tgbotapi.NewMessage(chatID, formatTask(t))
+	msg.ReplyMarkup = keyboardFor(t)
+	return msg
+}
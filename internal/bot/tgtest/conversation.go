@@ -0,0 +1,223 @@
+package tgtest
+
+import (
+	"github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// DefaultTimeout — сколько Expect ждёт ответа бота.
+const DefaultTimeout = 5 * time.Second
+
+// Conversation — DSL для сценария переписки одного пользователя с ботом:
+//
+//	c := srv.Conversation(t, 42)
+//	c.Command("/start")
+//	c.Expect("Привет").RequestsLocation()
+//	c.ShareLocation(55.75, 37.61)
+//	c.Type("завтра в 10 врач")
+//	c.Expect("Где")
+type Conversation struct {
+	t       testing.TB
+	srv     *Server
+	ChatID  int64
+	User    tgbotapi.User
+	Timeout time.Duration
+
+	// seen — сколько записей Sent уже разобрано Expect.
+	seen int
+	last *Sent
+}
+
+func (s *Server) Conversation(t testing.TB, chatID int64) *Conversation {
+	return &Conversation{
+		t:       t,
+		srv:     s,
+		ChatID:  chatID,
+		User:    tgbotapi.User{ID: chatID, FirstName: "Test", UserName: "test_user", LanguageCode: "ru"},
+		Timeout: DefaultTimeout,
+	}
+}
+
+// Type отправляет боту текстовое сообщение.
+func (c *Conversation) Type(text string) *Conversation {
+	c.push(c.message(text))
+	return c
+}
+
+// Command отправляет команду вида "/start" или "/agenda 3".
+func (c *Conversation) Command(command string) *Conversation {
+	m := c.message(command)
+	name := strings.SplitN(command, " ", 2)[0]
+	m.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len(name)}}
+	c.push(m)
+	return c
+}
+
+// ShareLocation отправляет геопозицию, как кнопка «Поделиться локацией».
+func (c *Conversation) ShareLocation(lat float64, lon float64) *Conversation {
+	m := c.message("")
+	m.Location = &tgbotapi.Location{Latitude: lat, Longitude: lon}
+	c.push(m)
+	return c
+}
+
+// Press нажимает inline-кнопку с текстом button в последнем полученном сообщении.
+func (c *Conversation) Press(button string) *Conversation {
+	c.t.Helper()
+	if c.last == nil {
+		c.t.Fatalf("tgtest: press %q: no message from bot yet", button)
+		return c
+	}
+	data, ok := c.last.CallbackData(button)
+	if !ok {
+		c.t.Fatalf("tgtest: press %q: no such inline button in %q, have %v", button, c.last.Text, c.last.InlineButtons())
+		return c
+	}
+	return c.PressData(*c.last, data)
+}
+
+// PressData отправляет callback query с произвольными данными от сообщения msg.
+func (c *Conversation) PressData(msg Sent, data string) *Conversation {
+	c.srv.Push(tgbotapi.Update{
+		CallbackQuery: &tgbotapi.CallbackQuery{
+			ID:           strconv.Itoa(c.srv.nextMessageID()),
+			From:         &c.User,
+			Message:      ptr(botMessage(msg)),
+			ChatInstance: "tgtest",
+			Data:         data,
+		},
+	})
+	return c
+}
+
+// Expect ждёт следующее сообщение бота в этом чате, содержащее substr, и возвращает его.
+// Промежуточные сообщения пропускаются.
+func (c *Conversation) Expect(substr string) Sent {
+	c.t.Helper()
+	return c.ExpectFunc("message containing "+strings.TrimSpace(substr), func(s Sent) bool {
+		return s.Contains(substr)
+	})
+}
+
+// ExpectEdit ждёт редактирования сообщения, содержащего substr.
+func (c *Conversation) ExpectEdit(substr string) Sent {
+	c.t.Helper()
+	return c.ExpectFunc("edit containing "+substr, func(s Sent) bool {
+		return s.Edited && s.Contains(substr)
+	})
+}
+
+// ExpectButtons ждёт сообщение, в клавиатуре которого есть все указанные кнопки.
+func (c *Conversation) ExpectButtons(buttons ...string) Sent {
+	c.t.Helper()
+	return c.ExpectFunc("keyboard with "+strings.Join(buttons, ", "), func(s Sent) bool {
+		for _, b := range buttons {
+			if !s.HasButton(b) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func (c *Conversation) ExpectFunc(what string, match func(Sent) bool) Sent {
+	c.t.Helper()
+
+	deadline := time.After(c.Timeout)
+	for {
+		changed := c.srv.wait()
+
+		sent := c.srv.Sent()
+		for i := c.seen; i < len(sent); i++ {
+			if sent[i].ChatID != c.ChatID {
+				continue
+			}
+			if match(sent[i]) {
+				c.seen = i + 1
+				c.last = &sent[i]
+				return sent[i]
+			}
+		}
+
+		select {
+		case <-changed:
+		case <-deadline:
+			c.t.Fatalf("tgtest: chat %d: timed out waiting for %s; got %v", c.ChatID, what, c.texts(sent))
+			return Sent{}
+		}
+	}
+}
+
+// ExpectCall ждёт вызов метода Bot API (например "answerCallbackQuery")
+// в этом чате или без привязки к чату.
+func (c *Conversation) ExpectCall(method string) Call {
+	c.t.Helper()
+	return c.ExpectCallFunc(method, func(Call) bool { return true })
+}
+
+// ExpectCallFunc ждёт вызов метода в этом чате или без привязки к чату, для которого match вернул true.
+func (c *Conversation) ExpectCallFunc(method string, match func(Call) bool) Call {
+	c.t.Helper()
+
+	deadline := time.After(c.Timeout)
+	for {
+		changed := c.srv.wait()
+
+		for _, call := range c.srv.CallsOf(method) {
+			chatID := call.Params.Get("chat_id")
+			if (chatID == "" || chatID == strconv.FormatInt(c.ChatID, 10)) && match(call) {
+				return call
+			}
+		}
+
+		select {
+		case <-changed:
+		case <-deadline:
+			c.t.Fatalf("tgtest: chat %d: timed out waiting for %s call", c.ChatID, method)
+			return Call{}
+		}
+	}
+}
+
+// ExpectSilence проверяет, что бот ничего не отправил в чат за время d.
+func (c *Conversation) ExpectSilence(d time.Duration) {
+	c.t.Helper()
+	time.Sleep(d)
+	sent := c.srv.Sent()
+	for i := c.seen; i < len(sent); i++ {
+		if sent[i].ChatID == c.ChatID {
+			c.t.Fatalf("tgtest: chat %d: unexpected message %q", c.ChatID, sent[i].Text)
+		}
+	}
+}
+
+func (c *Conversation) message(text string) *tgbotapi.Message {
+	return &tgbotapi.Message{
+		MessageID: c.srv.nextMessageID(),
+		From:      &c.User,
+		Date:      int(time.Now().Unix()),
+		Chat:      &tgbotapi.Chat{ID: c.ChatID, Type: "private", UserName: c.User.UserName},
+		Text:      text,
+	}
+}
+
+func (c *Conversation) push(m *tgbotapi.Message) {
+	c.srv.Push(tgbotapi.Update{Message: m})
+}
+
+func (c *Conversation) texts(sent []Sent) []string {
+	var out []string
+	for _, s := range sent[c.seen:] {
+		if s.ChatID == c.ChatID {
+			out = append(out, s.Text)
+		}
+	}
+	return out
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,45 @@
+package tgtest
+
+import (
+	"context"
+	"github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Handler совпадает с bot.Handler. Объявлен здесь, а не взят из пакета bot: тесты внутри пакета bot
+// (handler_callback_test.go, conversation_test.go) импортируют tgtest, и импорт bot отсюда дал бы цикл.
+type Handler interface {
+	Handle(ctx context.Context, b *tgbotapi.BotAPI, update *tgbotapi.Update)
+}
+
+// Run крутит цикл long polling против фейкового сервера и передаёт каждый апдейт в h,
+// пока не отменён ctx. Возвращает клиента Bot API, через которого отвечает бот.
+func (s *Server) Run(ctx context.Context, h Handler) (*tgbotapi.BotAPI, error) {
+	b, err := s.Bot()
+	if err != nil {
+		return nil, err
+	}
+
+	u := tgbotapi.NewUpdate(0)
+	u.Timeout = 1
+	updates := b.GetUpdatesChan(u)
+
+	go func() {
+		<-ctx.Done()
+		b.StopReceivingUpdates()
+	}()
+
+	go func() {
+		for update := range updates {
+			h.Handle(ctx, b, &update)
+		}
+	}()
+
+	return b, nil
+}
+
+// HandlerFunc позволяет передать в Run обычную функцию.
+type HandlerFunc func(ctx context.Context, b *tgbotapi.BotAPI, update *tgbotapi.Update)
+
+func (f HandlerFunc) Handle(ctx context.Context, b *tgbotapi.BotAPI, update *tgbotapi.Update) {
+	f(ctx, b, update)
+}
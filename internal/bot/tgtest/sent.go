@@ -0,0 +1,84 @@
+package tgtest
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+type markup struct {
+	InlineKeyboard [][]struct {
+		Text         string  `json:"text"`
+		CallbackData *string `json:"callback_data"`
+	} `json:"inline_keyboard"`
+	Keyboard [][]struct {
+		Text            string `json:"text"`
+		RequestLocation bool   `json:"request_location"`
+	} `json:"keyboard"`
+}
+
+func (s Sent) markup() markup {
+	var m markup
+	if len(s.ReplyMarkup) > 0 {
+		_ = json.Unmarshal(s.ReplyMarkup, &m)
+	}
+	return m
+}
+
+// Buttons — тексты кнопок обычной (reply) клавиатуры.
+func (s Sent) Buttons() []string {
+	var out []string
+	for _, row := range s.markup().Keyboard {
+		for _, b := range row {
+			out = append(out, b.Text)
+		}
+	}
+	return out
+}
+
+// InlineButtons — тексты кнопок inline-клавиатуры.
+func (s Sent) InlineButtons() []string {
+	var out []string
+	for _, row := range s.markup().InlineKeyboard {
+		for _, b := range row {
+			out = append(out, b.Text)
+		}
+	}
+	return out
+}
+
+// CallbackData возвращает callback_data inline-кнопки с указанным текстом.
+func (s Sent) CallbackData(text string) (string, bool) {
+	for _, row := range s.markup().InlineKeyboard {
+		for _, b := range row {
+			if b.Text == text && b.CallbackData != nil {
+				return *b.CallbackData, true
+			}
+		}
+	}
+	return "", false
+}
+
+// RequestsLocation — есть ли в клавиатуре кнопка «поделиться геопозицией».
+func (s Sent) RequestsLocation() bool {
+	for _, row := range s.markup().Keyboard {
+		for _, b := range row {
+			if b.RequestLocation {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s Sent) HasButton(text string) bool {
+	for _, b := range append(s.Buttons(), s.InlineButtons()...) {
+		if b == text {
+			return true
+		}
+	}
+	return false
+}
+
+func (s Sent) Contains(substr string) bool {
+	return strings.Contains(s.Text, substr)
+}
@@ -0,0 +1,300 @@
+package tgtest
+
+import (
+	"encoding/json"
+	"github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	Token       = "123456:test-token"
+	BotID       = 123456
+	BotUserName = "advent_test_bot"
+
+	// maxPollTimeout ограничивает long polling, чтобы StopReceivingUpdates не ждал минуту.
+	maxPollTimeout = time.Second
+)
+
+// Call — вызов метода Bot API, полученный фейковым сервером.
+type Call struct {
+	Method string
+	Params url.Values
+}
+
+// Sent — сообщение, отправленное или отредактированное ботом.
+type Sent struct {
+	ChatID      int64
+	MessageID   int
+	Text        string
+	ParseMode   string
+	ReplyMarkup json.RawMessage
+	Edited      bool
+}
+
+// Server — фейковый Telegram Bot API поверх httptest. Бот направляется на него через
+// tgbotapi.NewBotAPIWithAPIEndpoint (см. Server.Bot), апдейты подкладываются через Push.
+type Server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	changed   chan struct{}
+	updates   []tgbotapi.Update
+	calls     []Call
+	sent      []Sent
+	commands  string
+	nextMsgID int
+	nextUpdID int
+}
+
+func NewServer() *Server {
+	s := &Server{
+		changed:   make(chan struct{}),
+		nextMsgID: 1,
+		nextUpdID: 1,
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// Bot создаёт клиента Bot API, направленного на фейковый сервер.
+func (s *Server) Bot() (*tgbotapi.BotAPI, error) {
+	return tgbotapi.NewBotAPIWithAPIEndpoint(Token, s.URL+"/bot%s/%s")
+}
+
+// Push ставит апдейт в очередь getUpdates, проставляя UpdateID.
+func (s *Server) Push(update tgbotapi.Update) tgbotapi.Update {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	update.UpdateID = s.nextUpdID
+	s.nextUpdID++
+	s.updates = append(s.updates, update)
+	s.notifyLocked()
+	return update
+}
+
+func (s *Server) Calls() []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Call(nil), s.calls...)
+}
+
+// CallsOf возвращает вызовы указанного метода Bot API.
+func (s *Server) CallsOf(method string) []Call {
+	var out []Call
+	for _, c := range s.Calls() {
+		if c.Method == method {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func (s *Server) Sent() []Sent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Sent(nil), s.sent...)
+}
+
+// Commands — последний список команд, переданный через setMyCommands (JSON).
+func (s *Server) Commands() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.commands
+}
+
+func (s *Server) nextMessageID() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := s.nextMsgID
+	s.nextMsgID++
+	return id
+}
+
+// wait возвращает канал, который закроется при следующем изменении состояния сервера.
+func (s *Server) wait() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.changed
+}
+
+func (s *Server) notifyLocked() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if len(parts) != 2 || parts[0] != "bot"+Token {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	method := parts[1]
+
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	s.calls = append(s.calls, Call{Method: method, Params: r.PostForm})
+	if method != "getUpdates" {
+		s.notifyLocked()
+	}
+	s.mu.Unlock()
+
+	switch method {
+	case "getMe":
+		writeResult(w, tgbotapi.User{ID: BotID, IsBot: true, FirstName: "Advent", UserName: BotUserName})
+	case "getUpdates":
+		s.handleGetUpdates(w, r.PostForm)
+	case "sendMessage":
+		s.handleSendMessage(w, r.PostForm)
+	case "editMessageText", "editMessageReplyMarkup":
+		s.handleEdit(w, method, r.PostForm)
+	case "setMyCommands":
+		s.mu.Lock()
+		s.commands = r.PostForm.Get("commands")
+		s.mu.Unlock()
+		writeResult(w, true)
+	case "answerCallbackQuery", "deleteMessage", "deleteWebhook", "setWebhook", "sendChatAction":
+		writeResult(w, true)
+	default:
+		writeError(w, http.StatusNotFound, "Not Found: method "+method)
+	}
+}
+
+func (s *Server) handleGetUpdates(w http.ResponseWriter, params url.Values) {
+	offset, _ := strconv.Atoi(params.Get("offset"))
+	timeout, _ := strconv.Atoi(params.Get("timeout"))
+
+	pollTimeout := time.Duration(timeout) * time.Second
+	if pollTimeout > maxPollTimeout {
+		pollTimeout = maxPollTimeout
+	}
+	deadline := time.After(pollTimeout)
+
+	for {
+		changed := s.wait()
+
+		s.mu.Lock()
+		var out []tgbotapi.Update
+		for _, u := range s.updates {
+			if u.UpdateID >= offset {
+				out = append(out, u)
+			}
+		}
+		s.mu.Unlock()
+
+		if len(out) > 0 {
+			writeResult(w, out)
+			return
+		}
+
+		select {
+		case <-changed:
+		case <-deadline:
+			writeResult(w, []tgbotapi.Update{})
+			return
+		}
+	}
+}
+
+func (s *Server) handleSendMessage(w http.ResponseWriter, params url.Values) {
+	chatID, err := strconv.ParseInt(params.Get("chat_id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Bad Request: chat_id is empty")
+		return
+	}
+	if params.Get("text") == "" {
+		writeError(w, http.StatusBadRequest, "Bad Request: message text is empty")
+		return
+	}
+
+	sent := Sent{
+		ChatID:      chatID,
+		MessageID:   s.nextMessageID(),
+		Text:        params.Get("text"),
+		ParseMode:   params.Get("parse_mode"),
+		ReplyMarkup: rawMarkup(params.Get("reply_markup")),
+	}
+
+	s.mu.Lock()
+	s.sent = append(s.sent, sent)
+	s.notifyLocked()
+	s.mu.Unlock()
+
+	writeResult(w, botMessage(sent))
+}
+
+func (s *Server) handleEdit(w http.ResponseWriter, method string, params url.Values) {
+	chatID, _ := strconv.ParseInt(params.Get("chat_id"), 10, 64)
+	messageID, _ := strconv.Atoi(params.Get("message_id"))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var prev *Sent
+	for i := len(s.sent) - 1; i >= 0; i-- {
+		if s.sent[i].ChatID == chatID && s.sent[i].MessageID == messageID {
+			prev = &s.sent[i]
+			break
+		}
+	}
+	if prev == nil {
+		writeError(w, http.StatusBadRequest, "Bad Request: message to edit not found")
+		return
+	}
+
+	edited := *prev
+	edited.Edited = true
+	edited.ReplyMarkup = rawMarkup(params.Get("reply_markup"))
+	if method == "editMessageText" {
+		edited.Text = params.Get("text")
+		edited.ParseMode = params.Get("parse_mode")
+	}
+
+	s.sent = append(s.sent, edited)
+	s.notifyLocked()
+
+	writeResult(w, botMessage(edited))
+}
+
+func botMessage(sent Sent) tgbotapi.Message {
+	return tgbotapi.Message{
+		MessageID: sent.MessageID,
+		From:      &tgbotapi.User{ID: BotID, IsBot: true, FirstName: "Advent", UserName: BotUserName},
+		Date:      int(time.Now().Unix()),
+		Chat:      &tgbotapi.Chat{ID: sent.ChatID, Type: "private"},
+		Text:      sent.Text,
+	}
+}
+
+func rawMarkup(s string) json.RawMessage {
+	if s == "" {
+		return nil
+	}
+	return json.RawMessage(s)
+}
+
+func writeResult(w http.ResponseWriter, v any) {
+	result, err := json.Marshal(v)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(tgbotapi.APIResponse{Ok: true, Result: result})
+}
+
+func writeError(w http.ResponseWriter, code int, description string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(tgbotapi.APIResponse{Ok: false, ErrorCode: code, Description: description})
+}
@@ -0,0 +1,112 @@
+package bot
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"log"
+	"net/http"
+	"time"
+)
+
+// secretTokenHeader — заголовок, в котором Telegram присылает secret_token из setWebhook.
+const secretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// maxUpdateSize — апдейты Telegram намного меньше; ограничение защищает от мусорных запросов.
+const maxUpdateSize = 1 << 20
+
+// WebhookConfig — параметры приёма апдейтов через webhook.
+type WebhookConfig struct {
+	// URL — публичный адрес, на который Telegram присылает апдейты, например через reverse proxy.
+	URL string
+	// Listen — адрес HTTP-сервера, например ":8080".
+	Listen string
+	// Path — секретный путь, на котором сервер принимает апдейты; остальные пути отвечают 404.
+	Path string
+	// SecretToken Telegram присылает в заголовке X-Telegram-Bot-Api-Secret-Token; пусто — не проверяется.
+	SecretToken string
+	// CertFile и KeyFile включают TLS на самом сервере; без них TLS завершается на proxy.
+	CertFile string
+	KeyFile  string
+}
+
+// Webhook принимает апдейты от Telegram по HTTP и ставит их в очередь Dispatcher — так же,
+// как цикл long polling.
+type Webhook struct {
+	cfg        WebhookConfig
+	dispatcher *Dispatcher
+	server     *http.Server
+}
+
+func NewWebhook(cfg WebhookConfig, d *Dispatcher) *Webhook {
+	w := &Webhook{cfg: cfg, dispatcher: d}
+
+	mux := http.NewServeMux()
+	mux.Handle("POST "+cfg.Path, w)
+	w.server = &http.Server{
+		Addr:              cfg.Listen,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return w
+}
+
+// Register сообщает Telegram адрес webhook и секрет. Апдейты, накопившиеся до этого, Telegram
+// доставит уже на новый адрес.
+func (w *Webhook) Register(b *tgbotapi.BotAPI) error {
+	params := tgbotapi.Params{"url": w.cfg.URL}
+	params.AddNonEmpty("secret_token", w.cfg.SecretToken)
+	if _, err := b.MakeRequest("setWebhook", params); err != nil {
+		return fmt.Errorf("setWebhook: %w", err)
+	}
+	log.Printf("[Webhook.Register] webhook set, listening on %s%s", w.cfg.Listen, w.cfg.Path)
+	return nil
+}
+
+// ListenAndServe блокируется, пока сервер не остановлен через Shutdown; тогда возвращает http.ErrServerClosed.
+func (w *Webhook) ListenAndServe() error {
+	if w.cfg.CertFile != "" {
+		return w.server.ListenAndServeTLS(w.cfg.CertFile, w.cfg.KeyFile)
+	}
+	return w.server.ListenAndServe()
+}
+
+// Shutdown перестаёт принимать соединения и ждёт текущие запросы; по истечении ctx обрывает их.
+func (w *Webhook) Shutdown(ctx context.Context) error {
+	if err := w.server.Shutdown(ctx); err != nil {
+		_ = w.server.Close()
+		return err
+	}
+	return nil
+}
+
+func (w *Webhook) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if w.cfg.SecretToken != "" {
+		token := r.Header.Get(secretTokenHeader)
+		if subtle.ConstantTimeCompare([]byte(token), []byte(w.cfg.SecretToken)) != 1 {
+			log.Printf("[Webhook.ServeHTTP] bad secret token from %s", r.RemoteAddr)
+			http.Error(rw, "forbidden", http.StatusForbidden)
+			return
+		}
+	}
+
+	var update tgbotapi.Update
+	if err := json.NewDecoder(http.MaxBytesReader(rw, r.Body, maxUpdateSize)).Decode(&update); err != nil {
+		log.Printf("[Webhook.ServeHTTP] decode update: %v", err)
+		http.Error(rw, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	// Пока очереди заполнены, запрос ждёт: Telegram не пришлёт следующие апдейты, не дождавшись ответа.
+	// Если место так и не освободилось или бот останавливается, ответ 503 — Telegram повторит доставку.
+	switch err := w.dispatcher.Submit(r.Context(), update); {
+	case err == nil, errors.Is(err, ErrChatQueueFull):
+		rw.WriteHeader(http.StatusOK)
+	default:
+		log.Printf("[Webhook.ServeHTTP] update_id=%d not accepted: %v", update.UpdateID, err)
+		http.Error(rw, "unavailable", http.StatusServiceUnavailable)
+	}
+}
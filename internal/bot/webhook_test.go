@@ -0,0 +1,110 @@
+package bot
+
+import (
+	"context"
+	"github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testWebhookPath   = "/telegram/secret-path"
+	testWebhookSecret = "s3cret"
+)
+
+// newTestWebhook поднимает HTTP-сервер webhook; принятые апдейты попадают в возвращаемый канал.
+func newTestWebhook(t *testing.T) (*Webhook, *httptest.Server, <-chan int) {
+	t.Helper()
+	received := make(chan int, 10)
+	d := NewDispatcher(HandlerFunc(func(ctx context.Context, b *tgbotapi.BotAPI, update *tgbotapi.Update) {
+		received <- update.UpdateID
+	}), nil, 1, 10)
+	t.Cleanup(func() { _ = d.Shutdown(context.Background()) })
+
+	w := NewWebhook(WebhookConfig{Path: testWebhookPath, SecretToken: testWebhookSecret}, d)
+	srv := httptest.NewServer(w.server.Handler)
+	t.Cleanup(srv.Close)
+	return w, srv, received
+}
+
+func postUpdate(t *testing.T, url string, secret string, body string) int {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if secret != "" {
+		req.Header.Set(secretTokenHeader, secret)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	return resp.StatusCode
+}
+
+const testUpdate = `{"update_id":7,"message":{"message_id":1,"date":0,"chat":{"id":42,"type":"private"},"text":"привет"}}`
+
+func TestWebhookAcceptsUpdate(t *testing.T) {
+	_, srv, received := newTestWebhook(t)
+
+	if code := postUpdate(t, srv.URL+testWebhookPath, testWebhookSecret, testUpdate); code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", code)
+	}
+	select {
+	case id := <-received:
+		if id != 7 {
+			t.Errorf("handled update_id=%d, want 7", id)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("update was not dispatched")
+	}
+}
+
+func TestWebhookRejectsBadRequests(t *testing.T) {
+	_, srv, received := newTestWebhook(t)
+
+	tests := []struct {
+		name   string
+		path   string
+		secret string
+		body   string
+		want   int
+	}{
+		{"no secret", testWebhookPath, "", testUpdate, http.StatusForbidden},
+		{"wrong secret", testWebhookPath, "guess", testUpdate, http.StatusForbidden},
+		{"wrong path", "/telegram/other", testWebhookSecret, testUpdate, http.StatusNotFound},
+		{"garbage body", testWebhookPath, testWebhookSecret, "not json", http.StatusBadRequest},
+		{"oversized body", testWebhookPath, testWebhookSecret, `{"update_id":7,"padding":"` + strings.Repeat("x", maxUpdateSize) + `"}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := postUpdate(t, srv.URL+tt.path, tt.secret, tt.body); code != tt.want {
+				t.Errorf("status = %d, want %d", code, tt.want)
+			}
+		})
+	}
+
+	select {
+	case id := <-received:
+		t.Errorf("rejected request was dispatched: update_id=%d", id)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestWebhookUnavailableAfterShutdown(t *testing.T) {
+	w, srv, _ := newTestWebhook(t)
+	if err := w.dispatcher.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// 503 — Telegram повторит доставку, когда бот снова запустится
+	if code := postUpdate(t, srv.URL+testWebhookPath, testWebhookSecret, testUpdate); code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", code)
+	}
+}
@@ -1,9 +1,33 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"github.com/joho/godotenv"
+	"io/fs"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DbDriverSQLite   = "sqlite3"
+	DbDriverPostgres = "postgres"
+	// DbPathMemory вместо пути к файлу включает хранение данных только в памяти процесса.
+	DbPathMemory = ":memory:"
+
+	DigestCatchUpOnce = "once"
+	DigestCatchUpSkip = "skip"
+
+	defaultDigestCatchUpWindow = 3 * time.Hour
+	defaultRateLimit           = 30
+	defaultWorkers             = 8
+	defaultChatQueueSize       = 10
+	defaultWebhookListen       = ":8080"
+	defaultShutdownTimeout     = 30 * time.Second
+	defaultReplyTimeout        = 90 * time.Second
 )
 
 type Config struct {
@@ -15,6 +39,46 @@ type Config struct {
 	RulePath          string
 	RulePathCot       string
 	RulePathFinalizer string
+	LlmProvider       string
+	LlmBaseUrl        string
+	LlmApiKey         string
+	LlmModel          string
+
+	// DbDriver — СУБД: sqlite3 (файл DB_PATH, :memory: — без базы) или postgres (строка подключения DB_DSN).
+	DbDriver string
+	DbDsn    string
+
+	// DigestCatchUp — что делать со сводкой, пропущенной пока бот был выключен: once или skip.
+	DigestCatchUp string
+	// DigestCatchUpWindow — пропущенная сводка старше этого окна считается устаревшей и не отправляется.
+	DigestCatchUpWindow time.Duration
+
+	// AllowedChatIDs — чаты, которым разрешено пользоваться ботом; пусто — всем.
+	AllowedChatIDs []int64
+	// RateLimit — сколько апдейтов в минуту обрабатывать от одного чата; 0 — без ограничения.
+	RateLimit int
+	// Workers — сколько апдейтов разных чатов обрабатывать одновременно.
+	Workers int
+	// ChatQueueSize — сколько апдейтов одного чата может ждать обработки; лишние отбрасываются.
+	ChatQueueSize int
+	// ShutdownTimeout — сколько при остановке ждать завершения начатой работы: обработки апдейтов,
+	// сводок и напоминаний. Потом она отменяется.
+	ShutdownTimeout time.Duration
+	// ReplyTimeout — сколько ждать ответа модели на сообщение, потом запрос отменяется; 0 — без ограничения.
+	ReplyTimeout time.Duration
+
+	// WebhookURL включает приём апдейтов через webhook вместо long polling: публичный адрес,
+	// на который Telegram присылает апдейты.
+	WebhookURL string
+	// WebhookListen — адрес HTTP-сервера webhook.
+	WebhookListen string
+	// WebhookPath — секретный путь, на котором сервер принимает апдейты; по умолчанию путь из WebhookURL.
+	WebhookPath string
+	// WebhookSecretToken Telegram присылает в заголовке X-Telegram-Bot-Api-Secret-Token.
+	WebhookSecretToken string
+	// WebhookTLSCert и WebhookTLSKey включают TLS на самом сервере webhook.
+	WebhookTLSCert string
+	WebhookTLSKey  string
 }
 
 func Load() (c Config, err error) {
@@ -32,17 +96,169 @@ func Load() (c Config, err error) {
 		RulePath:          os.Getenv("RULE_PATH"),
 		RulePathCot:       os.Getenv("RULE_PATH_COT"),
 		RulePathFinalizer: os.Getenv("RULE_PATH_FINALIZER"),
+		LlmProvider:       os.Getenv("LLM_PROVIDER"),
+		LlmBaseUrl:        os.Getenv("LLM_BASE_URL"),
+		LlmApiKey:         os.Getenv("LLM_API_KEY"),
+		LlmModel:          os.Getenv("LLM_MODEL"),
+		DbDriver:          os.Getenv("DB_DRIVER"),
+		DbDsn:             os.Getenv("DB_DSN"),
+		DigestCatchUp:     os.Getenv("DIGEST_CATCHUP"),
+
+		WebhookURL:         os.Getenv("WEBHOOK_URL"),
+		WebhookListen:      os.Getenv("WEBHOOK_LISTEN"),
+		WebhookPath:        os.Getenv("WEBHOOK_PATH"),
+		WebhookSecretToken: os.Getenv("WEBHOOK_SECRET_TOKEN"),
+		WebhookTLSCert:     os.Getenv("WEBHOOK_TLS_CERT"),
+		WebhookTLSKey:      os.Getenv("WEBHOOK_TLS_KEY"),
 	}
 
 	if c.BotToken == "" {
 		return c, fmt.Errorf("TELEGRAM_BOT_TOKEN is required")
 	}
-	if c.ApiKey == "" {
-		return c, fmt.Errorf("YC_API_KEY is required")
+	if c.LlmProvider == "" || c.LlmProvider == "yandex" {
+		if c.ApiKey == "" {
+			return c, fmt.Errorf("YC_API_KEY is required")
+		}
+		if c.FolderId == "" {
+			return c, fmt.Errorf("YC_FOLDER_ID is required")
+		}
+	}
+
+	if err := c.validateDB(); err != nil {
+		return c, err
+	}
+
+	switch c.DigestCatchUp {
+	case "":
+		c.DigestCatchUp = DigestCatchUpOnce
+	case DigestCatchUpOnce, DigestCatchUpSkip:
+	default:
+		return c, fmt.Errorf("DIGEST_CATCHUP must be %q or %q, got %q", DigestCatchUpOnce, DigestCatchUpSkip, c.DigestCatchUp)
+	}
+
+	c.DigestCatchUpWindow = defaultDigestCatchUpWindow
+	if v := os.Getenv("DIGEST_CATCHUP_WINDOW"); v != "" {
+		c.DigestCatchUpWindow, err = time.ParseDuration(v)
+		if err != nil {
+			return c, fmt.Errorf("invalid DIGEST_CATCHUP_WINDOW: %w", err)
+		}
+	}
+
+	if v := os.Getenv("ALLOWED_CHAT_IDS"); v != "" {
+		for _, id := range strings.Split(v, ",") {
+			chatID, err := strconv.ParseInt(strings.TrimSpace(id), 10, 64)
+			if err != nil {
+				return c, fmt.Errorf("invalid ALLOWED_CHAT_IDS: %w", err)
+			}
+			c.AllowedChatIDs = append(c.AllowedChatIDs, chatID)
+		}
+	}
+
+	if c.RateLimit, err = intEnv("RATE_LIMIT", defaultRateLimit, 0); err != nil {
+		return c, err
+	}
+	if c.Workers, err = intEnv("BOT_WORKERS", defaultWorkers, 1); err != nil {
+		return c, err
+	}
+	if c.ChatQueueSize, err = intEnv("BOT_CHAT_QUEUE_SIZE", defaultChatQueueSize, 1); err != nil {
+		return c, err
+	}
+
+	c.ShutdownTimeout = defaultShutdownTimeout
+	if v := os.Getenv("SHUTDOWN_TIMEOUT"); v != "" {
+		c.ShutdownTimeout, err = time.ParseDuration(v)
+		if err != nil {
+			return c, fmt.Errorf("invalid SHUTDOWN_TIMEOUT: %w", err)
+		}
+	}
+
+	c.ReplyTimeout = defaultReplyTimeout
+	if v := os.Getenv("REPLY_TIMEOUT"); v != "" {
+		c.ReplyTimeout, err = time.ParseDuration(v)
+		if err != nil {
+			return c, fmt.Errorf("invalid REPLY_TIMEOUT: %w", err)
+		}
+	}
+
+	if c.WebhookURL != "" {
+		if err := c.validateWebhook(); err != nil {
+			return c, err
+		}
 	}
-	if c.FolderId == "" {
-		return c, fmt.Errorf("YC_FOLDER_ID is required")
+
+	return c, nil
+}
+
+// LoadDB читает только настройки базы (DB_DRIVER, DB_PATH, DB_DSN): их хватает для -schema-version,
+// который запускают и там, где нет токена бота, ключей LLM и даже файла .env.
+func LoadDB() (c Config, err error) {
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return Config{}, err
 	}
 
+	c = Config{
+		DbPath:   os.Getenv("DB_PATH"),
+		DbDriver: os.Getenv("DB_DRIVER"),
+		DbDsn:    os.Getenv("DB_DSN"),
+	}
+	if err := c.validateDB(); err != nil {
+		return c, err
+	}
 	return c, nil
 }
+
+// validateDB проверяет выбор СУБД и заполняет драйвер по умолчанию.
+func (c *Config) validateDB() error {
+	switch c.DbDriver {
+	case "", "sqlite":
+		c.DbDriver = DbDriverSQLite
+	case DbDriverSQLite:
+	case DbDriverPostgres:
+		if c.DbDsn == "" {
+			return fmt.Errorf("DB_DSN is required for DB_DRIVER=%s", DbDriverPostgres)
+		}
+	default:
+		return fmt.Errorf("DB_DRIVER must be %q or %q, got %q", DbDriverSQLite, DbDriverPostgres, c.DbDriver)
+	}
+	return nil
+}
+
+// validateWebhook проверяет настройки webhook и заполняет значения по умолчанию.
+func (c *Config) validateWebhook() error {
+	u, err := url.Parse(c.WebhookURL)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("WEBHOOK_URL must be an https URL, got %q", c.WebhookURL)
+	}
+	if c.WebhookListen == "" {
+		c.WebhookListen = defaultWebhookListen
+	}
+	if c.WebhookPath == "" {
+		c.WebhookPath = u.Path
+	}
+	if !strings.HasPrefix(c.WebhookPath, "/") || c.WebhookPath == "/" {
+		return fmt.Errorf("WEBHOOK_PATH must be a secret path like /telegram/<random>, got %q", c.WebhookPath)
+	}
+	// Telegram принимает в secret_token только A-Z, a-z, 0-9, _ и -, до 256 символов
+	if len(c.WebhookSecretToken) > 256 || strings.IndexFunc(c.WebhookSecretToken, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-')
+	}) >= 0 {
+		return fmt.Errorf("WEBHOOK_SECRET_TOKEN may contain only A-Z, a-z, 0-9, _ and -, up to 256 characters")
+	}
+	if (c.WebhookTLSCert == "") != (c.WebhookTLSKey == "") {
+		return fmt.Errorf("WEBHOOK_TLS_CERT and WEBHOOK_TLS_KEY must be set together")
+	}
+	return nil
+}
+
+// intEnv читает целое из переменной name; если она не задана — def. Значения меньше minValue — ошибка.
+func intEnv(name string, def int, minValue int) (int, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < minValue {
+		return 0, fmt.Errorf("%s must be an integer >= %d, got %q", name, minValue, v)
+	}
+	return n, nil
+}
@@ -0,0 +1,94 @@
+package memory
+
+import (
+	"adventBot/internal/db/chat"
+	"adventBot/internal/db/memdb"
+	"context"
+	"log"
+	"maps"
+)
+
+type record struct {
+	timeZone string
+	settings chat.Settings
+}
+
+type table struct {
+	chats map[int64]record
+}
+
+func (t *table) Snapshot() func() {
+	saved := maps.Clone(t.chats)
+	return func() { t.chats = saved }
+}
+
+type RepositoryMemory struct {
+	db *memdb.DB
+	t  *table
+}
+
+func NewRepositoryMemory(db *memdb.DB) *RepositoryMemory {
+	t := &table{chats: make(map[int64]record)}
+	db.Register(t)
+	return &RepositoryMemory{db: db, t: t}
+}
+
+// WithTx возвращает репозиторий над теми же данными, работающий внутри транзакции tx.
+func (r *RepositoryMemory) WithTx(tx *memdb.DB) *RepositoryMemory {
+	return &RepositoryMemory{db: tx, t: r.t}
+}
+
+func (r *RepositoryMemory) Upsert(ctx context.Context, chatID int64, tz string) error {
+	r.db.Write(func() {
+		rec, ok := r.t.chats[chatID]
+		if !ok {
+			rec.settings = chat.DefaultSettings()
+		}
+		rec.timeZone = tz
+		r.t.chats[chatID] = rec
+	})
+	log.Printf("[chat/RepositoryMemory.Upsert] success chatID=%d tz=%s", chatID, tz)
+	return nil
+}
+
+func (r *RepositoryMemory) GetById(ctx context.Context, chatID int64) (tz string, found bool, err error) {
+	r.db.Read(func() {
+		var rec record
+		rec, found = r.t.chats[chatID]
+		tz = rec.timeZone
+	})
+	return tz, found, nil
+}
+
+func (r *RepositoryMemory) DeleteById(ctx context.Context, chatID int64) (bool, error) {
+	var found bool
+	r.db.Write(func() {
+		_, found = r.t.chats[chatID]
+		delete(r.t.chats, chatID)
+	})
+	log.Printf("[chat/RepositoryMemory.DeleteById] chatID=%d deleted=%t", chatID, found)
+	return found, nil
+}
+
+func (r *RepositoryMemory) GetSettings(ctx context.Context, chatID int64) (chat.Settings, error) {
+	s := chat.DefaultSettings()
+	r.db.Read(func() {
+		if rec, ok := r.t.chats[chatID]; ok {
+			s = rec.settings
+		}
+	})
+	return s, nil
+}
+
+func (r *RepositoryMemory) UpdateSettings(ctx context.Context, chatID int64, s chat.Settings) (bool, error) {
+	var found bool
+	r.db.Write(func() {
+		var rec record
+		if rec, found = r.t.chats[chatID]; found {
+			rec.settings = s
+			r.t.chats[chatID] = rec
+		}
+	})
+	log.Printf("[chat/RepositoryMemory.UpdateSettings] chatID=%d settings=%+v updated=%t", chatID, s, found)
+	return found, nil
+}
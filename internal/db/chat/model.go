@@ -0,0 +1,16 @@
+package chat
+
+const (
+	DefaultRemindBefore = 15
+	DefaultDigestTime   = "08:00"
+)
+
+// Settings — настройки чата. Для неизвестного чата возвращаются значения по умолчанию.
+type Settings struct {
+	RemindBefore int    // за сколько минут до задачи присылать напоминание
+	DigestTime   string // локальное время ежедневной сводки, "15:04"
+}
+
+func DefaultSettings() Settings {
+	return Settings{RemindBefore: DefaultRemindBefore, DigestTime: DefaultDigestTime}
+}
@@ -0,0 +1,35 @@
+package postgres
+
+import "fmt"
+
+const (
+	tableChat = "chat"
+
+	colChatID       = "chat_id"
+	colTimeZone     = "time_zone"
+	colRemindBefore = "remind_before"
+	colDigestTime   = "digest_time"
+)
+
+var upsert = fmt.Sprintf(`
+INSERT INTO %s (%s, %s)
+VALUES ($1, $2)
+ON CONFLICT(%s) DO UPDATE SET
+  %s = excluded.%s;
+`, tableChat,
+	colChatID, colTimeZone,
+	colChatID,
+	colTimeZone, colTimeZone,
+)
+
+var selectByChatId = fmt.Sprintf(`SELECT %s FROM %s WHERE %s = $1;`,
+	colTimeZone, tableChat, colChatID)
+
+var deleteByChatId = fmt.Sprintf(`DELETE FROM %s WHERE %s = $1;`,
+	tableChat, colChatID)
+
+var selectSettingsByChatId = fmt.Sprintf(`SELECT %s, %s FROM %s WHERE %s = $1;`,
+	colRemindBefore, colDigestTime, tableChat, colChatID)
+
+var updateSettings = fmt.Sprintf(`UPDATE %s SET %s = $1, %s = $2 WHERE %s = $3;`,
+	tableChat, colRemindBefore, colDigestTime, colChatID)
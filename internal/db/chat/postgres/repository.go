@@ -0,0 +1,94 @@
+package postgres
+
+import (
+	"adventBot/internal/db/chat"
+	"adventBot/internal/db/dbtx"
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+)
+
+type RepositoryPostgres struct {
+	db dbtx.DBTX
+}
+
+func NewRepositoryPostgres(db dbtx.DBTX) *RepositoryPostgres {
+	return &RepositoryPostgres{db: db}
+}
+
+func (r *RepositoryPostgres) Upsert(ctx context.Context, chatID int64, tz string) error {
+	_, err := r.db.ExecContext(ctx, upsert, chatID, tz)
+	if err != nil {
+		log.Printf("[chat/RepositoryPostgres.Upsert] chatID=%d tz=%s error=%v", chatID, tz, err)
+		return err
+	}
+	log.Printf("[chat/RepositoryPostgres.Upsert] success chatID=%d tz=%s", chatID, tz)
+	return nil
+}
+
+func (r *RepositoryPostgres) GetById(ctx context.Context, chatID int64) (tz string, found bool, err error) {
+	row := r.db.QueryRowContext(ctx, selectByChatId, chatID)
+	switch err = row.Scan(&tz); {
+	case err == nil:
+		log.Printf("[chat/RepositoryPostgres.GetById] found chatID=%d tz=%s", chatID, tz)
+		return tz, true, nil
+	case errors.Is(err, sql.ErrNoRows):
+		log.Printf("[chat/RepositoryPostgres.GetById] not found chatID=%d", chatID)
+		return "", false, nil
+	default:
+		log.Printf("[chat/RepositoryPostgres.GetById] error chatID=%d err=%v", chatID, err)
+		return "", false, err
+	}
+}
+
+func (r *RepositoryPostgres) DeleteById(ctx context.Context, chatID int64) (bool, error) {
+	res, err := r.db.ExecContext(ctx, deleteByChatId, chatID)
+	if err != nil {
+		log.Printf("[chat/RepositoryPostgres.DeleteById] chatID=%d error=%v", chatID, err)
+		return false, err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		log.Printf("[chat/RepositoryPostgres.DeleteById] chatID=%d error getting RowsAffected=%v", chatID, err)
+		return false, err
+	}
+
+	if rows > 0 {
+		log.Printf("[chat/RepositoryPostgres.DeleteById] success deleted chatID=%d", chatID)
+		return true, nil
+	}
+
+	log.Printf("[chat/RepositoryPostgres.DeleteById] no record found chatID=%d", chatID)
+	return false, nil
+}
+
+func (r *RepositoryPostgres) GetSettings(ctx context.Context, chatID int64) (chat.Settings, error) {
+	s := chat.DefaultSettings()
+	row := r.db.QueryRowContext(ctx, selectSettingsByChatId, chatID)
+	switch err := row.Scan(&s.RemindBefore, &s.DigestTime); {
+	case err == nil, errors.Is(err, sql.ErrNoRows):
+		return s, nil
+	default:
+		log.Printf("[chat/RepositoryPostgres.GetSettings] error chatID=%d err=%v", chatID, err)
+		return chat.DefaultSettings(), err
+	}
+}
+
+func (r *RepositoryPostgres) UpdateSettings(ctx context.Context, chatID int64, s chat.Settings) (bool, error) {
+	res, err := r.db.ExecContext(ctx, updateSettings, s.RemindBefore, s.DigestTime, chatID)
+	if err != nil {
+		log.Printf("[chat/RepositoryPostgres.UpdateSettings] chatID=%d settings=%+v error=%v", chatID, s, err)
+		return false, err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		log.Printf("[chat/RepositoryPostgres.UpdateSettings] chatID=%d error getting RowsAffected=%v", chatID, err)
+		return false, err
+	}
+
+	log.Printf("[chat/RepositoryPostgres.UpdateSettings] chatID=%d settings=%+v updated=%t", chatID, s, rows > 0)
+	return rows > 0, nil
+}
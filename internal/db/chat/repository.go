@@ -3,9 +3,9 @@ package chat
 import "context"
 
 type Repository interface {
-	Init() error
-	CloseConnection() error
 	Upsert(ctx context.Context, chatID int64, tz string) error
 	GetById(ctx context.Context, chatID int64) (tz string, found bool, err error)
 	DeleteById(ctx context.Context, chatID int64) (bool, error)
+	GetSettings(ctx context.Context, chatID int64) (Settings, error)
+	UpdateSettings(ctx context.Context, chatID int64, s Settings) (bool, error)
 }
@@ -1,6 +1,8 @@
 package sqlite
 
 type Chat struct {
-	ChatID   int64  `db:"chat_id"`
-	TimeZone string `db:"time_zone"`
+	ChatID       int64  `db:"chat_id"`
+	TimeZone     string `db:"time_zone"`
+	RemindBefore int    `db:"remind_before"`
+	DigestTime   string `db:"digest_time"`
 }
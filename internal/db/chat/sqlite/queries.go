@@ -5,15 +5,12 @@ import "fmt"
 const (
 	tableChat = "chat"
 
-	colChatID   = "chat_id"
-	colTimeZone = "time_zone"
+	colChatID       = "chat_id"
+	colTimeZone     = "time_zone"
+	colRemindBefore = "remind_before"
+	colDigestTime   = "digest_time"
 )
 
-var createTable = fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
-  %s INTEGER PRIMARY KEY,
-  %s TEXT NOT NULL
-);`, tableChat, colChatID, colTimeZone)
-
 var upsert = fmt.Sprintf(`
 INSERT INTO %s (%s, %s)
 VALUES (?, ?)
@@ -30,3 +27,9 @@ var selectByChatId = fmt.Sprintf(`SELECT %s FROM %s WHERE %s = ?;`,
 
 var deleteByChatId = fmt.Sprintf(`DELETE FROM %s WHERE %s = ?;`,
 	tableChat, colChatID)
+
+var selectSettingsByChatId = fmt.Sprintf(`SELECT %s, %s FROM %s WHERE %s = ?;`,
+	colRemindBefore, colDigestTime, tableChat, colChatID)
+
+var updateSettings = fmt.Sprintf(`UPDATE %s SET %s = ?, %s = ? WHERE %s = ?;`,
+	tableChat, colRemindBefore, colDigestTime, colChatID)
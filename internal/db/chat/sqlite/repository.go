@@ -1,6 +1,8 @@
 package sqlite
 
 import (
+	"adventBot/internal/db/chat"
+	"adventBot/internal/db/dbtx"
 	"context"
 	"database/sql"
 	"errors"
@@ -8,28 +10,13 @@ import (
 )
 
 type RepositorySQlite struct {
-	db *sql.DB
+	db dbtx.DBTX
 }
 
-func NewRepositorySQlite(db *sql.DB) *RepositorySQlite {
+func NewRepositorySQlite(db dbtx.DBTX) *RepositorySQlite {
 	return &RepositorySQlite{db: db}
 }
 
-func (r *RepositorySQlite) Init() error {
-	_, err := r.db.Exec(createTable)
-	if err != nil {
-		log.Println("[chat/RepositorySQlite.Init] failed to create table:", err)
-		return err
-	}
-	log.Println("[chat/RepositorySQlite.Init] table created or already exists")
-	return nil
-}
-
-func (r *RepositorySQlite) CloseConnection() error {
-	log.Println("[chat/RepositorySQlite.Close] closing db connection")
-	return r.db.Close()
-}
-
 func (r *RepositorySQlite) Upsert(ctx context.Context, chatID int64, tz string) error {
 	_, err := r.db.ExecContext(ctx, upsert, chatID, tz)
 	if err != nil {
@@ -76,3 +63,32 @@ func (r *RepositorySQlite) DeleteById(ctx context.Context, chatID int64) (bool,
 	log.Printf("[chat/RepositorySQlite.DeleteById] no record found chatID=%d", chatID)
 	return false, nil
 }
+
+func (r *RepositorySQlite) GetSettings(ctx context.Context, chatID int64) (chat.Settings, error) {
+	s := chat.DefaultSettings()
+	row := r.db.QueryRowContext(ctx, selectSettingsByChatId, chatID)
+	switch err := row.Scan(&s.RemindBefore, &s.DigestTime); {
+	case err == nil, errors.Is(err, sql.ErrNoRows):
+		return s, nil
+	default:
+		log.Printf("[chat/RepositorySQlite.GetSettings] error chatID=%d err=%v", chatID, err)
+		return chat.DefaultSettings(), err
+	}
+}
+
+func (r *RepositorySQlite) UpdateSettings(ctx context.Context, chatID int64, s chat.Settings) (bool, error) {
+	res, err := r.db.ExecContext(ctx, updateSettings, s.RemindBefore, s.DigestTime, chatID)
+	if err != nil {
+		log.Printf("[chat/RepositorySQlite.UpdateSettings] chatID=%d settings=%+v error=%v", chatID, s, err)
+		return false, err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		log.Printf("[chat/RepositorySQlite.UpdateSettings] chatID=%d error getting RowsAffected=%v", chatID, err)
+		return false, err
+	}
+
+	log.Printf("[chat/RepositorySQlite.UpdateSettings] chatID=%d settings=%+v updated=%t", chatID, s, rows > 0)
+	return rows > 0, nil
+}
@@ -0,0 +1,44 @@
+// Package dbtx позволяет репозиториям одинаково работать через *sql.DB и внутри *sql.Tx.
+package dbtx
+
+import (
+	"context"
+	"database/sql"
+)
+
+// DBTX — общие методы *sql.DB и *sql.Tx.
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// InTx выполняет fn атомарно. Если q уже транзакция, fn выполняется в ней и фиксируется вместе с ней;
+// если q — *sql.DB, открывается и фиксируется отдельная транзакция.
+func InTx(ctx context.Context, q DBTX, fn func(q DBTX) error) error {
+	db, ok := q.(*sql.DB)
+	if !ok {
+		return fn(q)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Driver — имя драйвера database/sql; от него зависят диалект SQL и реализации репозиториев.
+type Driver string
+
+const (
+	SQLite   Driver = "sqlite3"
+	Postgres Driver = "postgres"
+)
@@ -0,0 +1,77 @@
+// Package memdb — общая блокировка и транзакции для in-memory репозиториев.
+// Репозитории разных сущностей регистрируют свои таблицы в одном DB, поэтому InTx
+// может атомарно изменить и при ошибке откатить данные сразу нескольких репозиториев.
+package memdb
+
+import "sync"
+
+// Table — данные одного репозитория. Snapshot вызывается под блокировкой DB и
+// возвращает функцию, восстанавливающую состояние на момент снимка.
+type Table interface {
+	Snapshot() (restore func())
+}
+
+type state struct {
+	mu     sync.RWMutex
+	tables []Table
+}
+
+// DB — блокировка над таблицами. Внутри InTx выдаётся DB, который уже держит блокировку.
+type DB struct {
+	*state
+	inTx bool
+}
+
+func New() *DB {
+	return &DB{state: &state{}}
+}
+
+// Register добавляет таблицу в снимки транзакций.
+func (db *DB) Register(t Table) {
+	db.Write(func() {
+		db.tables = append(db.tables, t)
+	})
+}
+
+// Read выполняет fn под блокировкой на чтение.
+func (db *DB) Read(fn func()) {
+	if !db.inTx {
+		db.mu.RLock()
+		defer db.mu.RUnlock()
+	}
+	fn()
+}
+
+// Write выполняет fn под эксклюзивной блокировкой.
+func (db *DB) Write(fn func()) {
+	if !db.inTx {
+		db.mu.Lock()
+		defer db.mu.Unlock()
+	}
+	fn()
+}
+
+// InTx выполняет fn атомарно: остальные вызовы ждут её завершения, а при ошибке все
+// зарегистрированные таблицы возвращаются к состоянию до начала. Вложенный InTx выполняется
+// в транзакции вызывающего.
+func (db *DB) InTx(fn func(tx *DB) error) error {
+	if db.inTx {
+		return fn(db)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	restore := make([]func(), 0, len(db.tables))
+	for _, t := range db.tables {
+		restore = append(restore, t.Snapshot())
+	}
+
+	if err := fn(&DB{state: db.state, inTx: true}); err != nil {
+		for _, r := range restore {
+			r()
+		}
+		return err
+	}
+	return nil
+}
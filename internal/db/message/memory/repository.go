@@ -0,0 +1,82 @@
+package memory
+
+import (
+	"adventBot/internal/db/memdb"
+	msg "adventBot/internal/db/message"
+	"context"
+	"log"
+	"slices"
+)
+
+type table struct {
+	// messages — сообщения чата в порядке первой записи
+	messages map[int64][]msg.Message
+}
+
+func (t *table) Snapshot() func() {
+	saved := make(map[int64][]msg.Message, len(t.messages))
+	for chatID, m := range t.messages {
+		saved[chatID] = slices.Clone(m)
+	}
+	return func() { t.messages = saved }
+}
+
+type RepositoryMemory struct {
+	db *memdb.DB
+	t  *table
+}
+
+func NewRepositoryMemory(db *memdb.DB) *RepositoryMemory {
+	t := &table{messages: make(map[int64][]msg.Message)}
+	db.Register(t)
+	return &RepositoryMemory{db: db, t: t}
+}
+
+// WithTx возвращает репозиторий над теми же данными, работающий внутри транзакции tx.
+func (r *RepositoryMemory) WithTx(tx *memdb.DB) *RepositoryMemory {
+	return &RepositoryMemory{db: tx, t: r.t}
+}
+
+func (r *RepositoryMemory) Upsert(ctx context.Context, chatID int64, role string, text string, timestamp int) error {
+	r.db.Write(func() {
+		messages := r.t.messages[chatID]
+		i := slices.IndexFunc(messages, func(m msg.Message) bool {
+			return m.Timestamp == timestamp && m.Role == role
+		})
+		if i >= 0 {
+			messages[i].Message = text
+			return
+		}
+		r.t.messages[chatID] = append(messages, msg.Message{Role: role, Message: text, Timestamp: timestamp})
+	})
+	log.Printf("[message/RepositoryMemory.Upsert] success chatID=%d role=%s text=%s timestamp=%d", chatID, role, text, timestamp)
+	return nil
+}
+
+func (r *RepositoryMemory) GetById(ctx context.Context, chatID int64, tz string) (messages []msg.Message, found bool, err error) {
+	r.db.Read(func() {
+		for _, m := range r.t.messages[chatID] {
+			if len(m.Role) > 0 && len(m.Message) > 0 {
+				m.TimeZone = tz
+				messages = append(messages, m)
+			}
+		}
+	})
+	slices.SortStableFunc(messages, func(a, b msg.Message) int {
+		return a.Timestamp - b.Timestamp
+	})
+
+	found = len(messages) > 0
+	log.Printf("[message/RepositoryMemory.GetById] found %d messages: %v", len(messages), messages)
+	return messages, found, nil
+}
+
+func (r *RepositoryMemory) DeleteById(ctx context.Context, chatID int64) (bool, error) {
+	var found bool
+	r.db.Write(func() {
+		found = len(r.t.messages[chatID]) > 0
+		delete(r.t.messages, chatID)
+	})
+	log.Printf("[message/RepositoryMemory.DeleteById] chatID=%d deleted=%t", chatID, found)
+	return found, nil
+}
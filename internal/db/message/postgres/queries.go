@@ -0,0 +1,40 @@
+package postgres
+
+import "fmt"
+
+const (
+	tableName    = "messages"
+	colChatId    = "chat_id"
+	colRole      = "role"
+	colMessage   = "message"
+	colTimestamp = "timestamp"
+)
+
+var upsert = fmt.Sprintf(`
+INSERT INTO %s (%s, %s, %s, %s)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT(%s, %s, %s) DO UPDATE SET
+  %s = excluded.%s;`,
+	tableName,
+	colChatId, colRole, colMessage, colTimestamp,
+	colChatId, colTimestamp, colRole,
+	colMessage, colMessage,
+)
+
+var selectByChatId = fmt.Sprintf(`
+SELECT %s, %s, %s, %s
+FROM %s
+WHERE %s = $1
+ORDER BY %s ASC, id ASC;`,
+	colChatId, colRole, colMessage, colTimestamp,
+	tableName,
+	colChatId,
+	colTimestamp,
+)
+
+var deleteByChatId = fmt.Sprintf(`
+DELETE FROM %s
+WHERE %s = $1;`,
+	tableName,
+	colChatId,
+)
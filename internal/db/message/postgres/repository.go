@@ -0,0 +1,92 @@
+package postgres
+
+import (
+	"adventBot/internal/db/dbtx"
+	msg "adventBot/internal/db/message"
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+)
+
+type RepositoryPostgres struct {
+	db dbtx.DBTX
+}
+
+func NewRepositoryPostgres(db dbtx.DBTX) *RepositoryPostgres {
+	return &RepositoryPostgres{db: db}
+}
+
+func (r *RepositoryPostgres) Upsert(ctx context.Context, chatID int64, role string, text string, timestamp int) error {
+	_, err := r.db.ExecContext(ctx, upsert, chatID, role, text, timestamp)
+	if err != nil {
+		log.Printf("[message/RepositoryPostgres.Upsert] chatID=%d role=%s text=%s timestamp=%d err=%v", chatID, role, text, timestamp, err)
+		return err
+	}
+	log.Printf("[message/RepositoryPostgres.Upsert] success chatID=%d role=%s text=%s timestamp=%d", chatID, role, text, timestamp)
+	return nil
+}
+
+func (r *RepositoryPostgres) GetById(ctx context.Context, chatID int64, tz string) (messages []msg.Message, found bool, err error) {
+	rows, err := r.db.QueryContext(ctx, selectByChatId, chatID)
+	if err != nil {
+		log.Printf("[message/RepositoryPostgres.GetById] selectByChatId err=%v", err)
+		return nil, false, fmt.Errorf("select messages by chat_id: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Println("[message/RepositoryPostgres.GetById] failed to close rows:", err)
+		}
+	}(rows)
+
+	for rows.Next() {
+		var m Message
+		if err := rows.Scan(&m.ChatID, &m.Role, &m.Message, &m.Timestamp); err != nil {
+			log.Printf("[message/RepositoryPostgres.GetById] failed to scan rows:%v", err)
+			return nil, false, fmt.Errorf("scan message row: %w", err)
+		}
+		message := msg.Message{
+			Role:      m.Role,
+			Message:   m.Message,
+			TimeZone:  tz,
+			Timestamp: m.Timestamp,
+		}
+		if len(message.Role) > 0 && len(message.Message) > 0 {
+			messages = append(messages, message)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("[message/RepositoryPostgres.GetById] failed to scan rows:%v", err)
+		return nil, false, fmt.Errorf("iterate rows: %w", err)
+	}
+
+	found = len(messages) > 0
+
+	log.Printf("[message/RepositoryPostgres.GetById] found %d messages: %v", len(messages), messages)
+	return messages, found, nil
+}
+
+// TODO убедиться что все сообщения стираются
+func (r *RepositoryPostgres) DeleteById(ctx context.Context, chatID int64) (bool, error) {
+	res, err := r.db.ExecContext(ctx, deleteByChatId, chatID)
+	if err != nil {
+		log.Printf("[message/RepositoryPostgres.DeleteById] chatID=%d error=%v", chatID, err)
+		return false, err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		log.Printf("[message/RepositoryPostgres.DeleteById] chatID=%d error getting RowsAffected=%v", chatID, err)
+		return false, err
+	}
+
+	if rows > 0 {
+		log.Printf("[message/RepositoryPostgres.DeleteById] success deleted chatID=%d", chatID)
+		return true, nil
+	}
+
+	log.Printf("[message/RepositoryPostgres.DeleteById] no record found chatID=%d", chatID)
+	return false, nil
+}
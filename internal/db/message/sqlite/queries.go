@@ -10,23 +10,6 @@ const (
 	colTimestamp = "timestamp"
 )
 
-var createTable = fmt.Sprintf(`
-CREATE TABLE IF NOT EXISTS %s (
-  id INTEGER PRIMARY KEY AUTOINCREMENT,
-  %s INTEGER NOT NULL,
-  %s TEXT NOT NULL,
-  %s TEXT NOT NULL,
-  %s INTEGER NOT NULL,
-  UNIQUE (%s, %s, %s)
-);`,
-	tableName,
-	colChatId,
-	colRole,
-	colMessage,
-	colTimestamp,
-	colChatId, colTimestamp, colRole,
-)
-
 var upsert = fmt.Sprintf(`
 INSERT INTO %s (%s, %s, %s, %s)
 VALUES (?, ?, ?, ?)
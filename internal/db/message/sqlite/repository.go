@@ -1,6 +1,7 @@
 package sqlite
 
 import (
+	"adventBot/internal/db/dbtx"
 	msg "adventBot/internal/db/message"
 	"context"
 	"database/sql"
@@ -9,28 +10,13 @@ import (
 )
 
 type RepositorySQlite struct {
-	db *sql.DB
+	db dbtx.DBTX
 }
 
-func NewRepositorySQlite(db *sql.DB) *RepositorySQlite {
+func NewRepositorySQlite(db dbtx.DBTX) *RepositorySQlite {
 	return &RepositorySQlite{db: db}
 }
 
-func (r *RepositorySQlite) Init() error {
-	_, err := r.db.Exec(createTable)
-	if err != nil {
-		log.Println("[message/RepositorySQlite.Init] failed to create table:", err)
-		return err
-	}
-	log.Println("[message/RepositorySQlite.Init] table created or already exists")
-	return nil
-}
-
-func (r *RepositorySQlite) CloseConnection() error {
-	log.Println("[message/RepositorySQlite.Close] closing db connection")
-	return r.db.Close()
-}
-
 func (r *RepositorySQlite) Upsert(ctx context.Context, chatID int64, role string, text string, timestamp int) error {
 	_, err := r.db.ExecContext(ctx, upsert, chatID, role, text, timestamp)
 	if err != nil {
@@ -0,0 +1,69 @@
+package migrate
+
+import (
+	"adventBot/internal/db/dbtx"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// dialect — различия SQLite и PostgreSQL, которые нужны миграциям.
+// В текстах миграций {{id}} — автоинкрементный первичный ключ, {{int}} — 64-битное целое.
+type dialect struct {
+	driver dbtx.Driver
+	types  *strings.Replacer
+}
+
+func newDialect(driver dbtx.Driver) (dialect, error) {
+	switch driver {
+	case dbtx.SQLite:
+		return dialect{driver, strings.NewReplacer("{{id}}", "INTEGER PRIMARY KEY AUTOINCREMENT", "{{int}}", "INTEGER")}, nil
+	case dbtx.Postgres:
+		return dialect{driver, strings.NewReplacer("{{id}}", "BIGSERIAL PRIMARY KEY", "{{int}}", "BIGINT")}, nil
+	default:
+		return dialect{}, fmt.Errorf("unsupported driver %q", driver)
+	}
+}
+
+// sql подставляет типы и, для PostgreSQL, заменяет плейсхолдеры ? на $1, $2, ...
+func (d dialect) sql(query string) string {
+	query = d.types.Replace(query)
+	if d.driver != dbtx.Postgres {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func (d dialect) hasTable(ctx context.Context, q dbtx.DBTX, table string) (bool, error) {
+	query := `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?;`
+	if d.driver == dbtx.Postgres {
+		query = `SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = ?;`
+	}
+
+	var n int
+	err := q.QueryRowContext(ctx, d.sql(query), table).Scan(&n)
+	return n > 0, err
+}
+
+func (d dialect) hasColumn(ctx context.Context, q dbtx.DBTX, table string, name string) (bool, error) {
+	query := `SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?;`
+	if d.driver == dbtx.Postgres {
+		query = `SELECT COUNT(*) FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = ? AND column_name = ?;`
+	}
+
+	var n int
+	err := q.QueryRowContext(ctx, d.sql(query), table, name).Scan(&n)
+	return n > 0, err
+}
@@ -0,0 +1,130 @@
+// Package migrate применяет версионированные миграции схемы SQLite и PostgreSQL.
+// Применённые версии хранятся в таблице schema_migrations; каждая миграция выполняется
+// в своей транзакции вместе с записью о ней, поэтому прерванный запуск можно просто повторить.
+// После миграций при каждом запуске выполняются идемпотентные шаги startup.
+package migrate
+
+import (
+	"adventBot/internal/db/dbtx"
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Migration — шаг изменения схемы. Версии строго возрастают и никогда не переиспользуются.
+type Migration struct {
+	Version int
+	Name    string
+	Up      step
+}
+
+// step получает диалект, чтобы один и тот же текст миграции работал в обеих СУБД.
+type step func(ctx context.Context, tx *sql.Tx, d dialect) error
+
+const createMigrationsTable = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+	version INTEGER PRIMARY KEY,
+	name TEXT NOT NULL,
+	applied_at TEXT NOT NULL
+);
+`
+
+const selectVersion = `
+SELECT COALESCE(MAX(version), 0) FROM schema_migrations;
+`
+
+const insertMigration = `
+INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?);
+`
+
+// Latest — версия последней известной миграции.
+func Latest() int {
+	return migrations[len(migrations)-1].Version
+}
+
+// Version возвращает текущую версию схемы; 0 — миграции ещё не применялись. Схема не меняется.
+func Version(ctx context.Context, db *sql.DB, driver dbtx.Driver) (int, error) {
+	d, err := newDialect(driver)
+	if err != nil {
+		return 0, err
+	}
+
+	ok, err := d.hasTable(ctx, db, "schema_migrations")
+	if err != nil {
+		return 0, fmt.Errorf("check schema_migrations: %w", err)
+	}
+	if !ok {
+		return 0, nil
+	}
+
+	var version int
+	if err := db.QueryRowContext(ctx, selectVersion).Scan(&version); err != nil {
+		return 0, fmt.Errorf("select schema version: %w", err)
+	}
+	return version, nil
+}
+
+// Up применяет все ещё не применённые миграции по порядку и возвращает итоговую версию схемы.
+func Up(ctx context.Context, db *sql.DB, driver dbtx.Driver) (int, error) {
+	d, err := newDialect(driver)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := db.ExecContext(ctx, createMigrationsTable); err != nil {
+		return 0, fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	current, err := Version(ctx, db, driver)
+	if err != nil {
+		return 0, err
+	}
+	if current > Latest() {
+		return current, fmt.Errorf("schema version %d is newer than supported %d", current, Latest())
+	}
+
+	for _, m := range migrations {
+		if m.Version <= current {
+			continue
+		}
+		if err := apply(ctx, db, d, m); err != nil {
+			return current, fmt.Errorf("migration %d_%s: %w", m.Version, m.Name, err)
+		}
+		current = m.Version
+		log.Printf("[migrate.Up] applied %d_%s", m.Version, m.Name)
+	}
+
+	for _, m := range startup {
+		if err := run(ctx, db, d, m.Up); err != nil {
+			return current, fmt.Errorf("startup %d_%s: %w", m.Version, m.Name, err)
+		}
+	}
+
+	log.Printf("[migrate.Up] schema version %d", current)
+	return current, nil
+}
+
+func apply(ctx context.Context, db *sql.DB, d dialect, m Migration) error {
+	return run(ctx, db, d, chain(m.Up, func(ctx context.Context, tx *sql.Tx, d dialect) error {
+		_, err := tx.ExecContext(ctx, d.sql(insertMigration), m.Version, m.Name, time.Now().UTC().Format(time.RFC3339))
+		return err
+	}))
+}
+
+// run выполняет шаг в отдельной транзакции.
+func run(ctx context.Context, db *sql.DB, d dialect, s step) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if err := s(ctx, tx, d); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,94 @@
+package migrate
+
+import (
+	"adventBot/internal/db/dbtx"
+	"context"
+	"database/sql"
+	_ "github.com/mattn/go-sqlite3"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func openSQLite(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open(string(dbtx.SQLite), filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	return db
+}
+
+func hasFTS5(t *testing.T, db *sql.DB) bool {
+	t.Helper()
+	var fts5 bool
+	if err := db.QueryRow(`SELECT sqlite_compileoption_used('ENABLE_FTS5');`).Scan(&fts5); err != nil {
+		t.Fatal(err)
+	}
+	return fts5
+}
+
+func hasTable(t *testing.T, db *sql.DB, name string) bool {
+	t.Helper()
+	d, _ := newDialect(dbtx.SQLite)
+	ok, err := d.hasTable(context.Background(), db, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ok
+}
+
+func TestUpIsIdempotent(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+
+	for range 2 {
+		version, err := Up(ctx, db, dbtx.SQLite)
+		if err != nil || version != Latest() {
+			t.Fatalf("Up = %d, %v; want %d", version, err, Latest())
+		}
+	}
+	if got := hasTable(t, db, "tasks_fts"); got != hasFTS5(t, db) {
+		t.Errorf("tasks_fts exists = %t, FTS5 = %t", got, hasFTS5(t, db))
+	}
+}
+
+// Миграция 10, применённая сборкой без FTS5, индекс не создаёт; его создаёт следующий запуск сборки с FTS5,
+// а сборка без FTS5 отказывается открывать базу, где индекс уже есть.
+func TestTasksFTSAtStartup(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+	if _, err := Up(ctx, db, dbtx.SQLite); err != nil {
+		t.Fatal(err)
+	}
+
+	if hasFTS5(t, db) {
+		for _, q := range []string{
+			`DROP TRIGGER tasks_fts_insert;`, `DROP TRIGGER tasks_fts_delete;`, `DROP TRIGGER tasks_fts_update;`, `DROP TABLE tasks_fts;`,
+			`INSERT INTO tasks (chat_id, task, location, date_time, created_at, updated_at) VALUES (1, 'Врач', 'Клиника', '', '', '');`,
+		} {
+			if _, err := db.Exec(q); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := Up(ctx, db, dbtx.SQLite); err != nil {
+			t.Fatal(err)
+		}
+		var n int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM tasks_fts WHERE tasks_fts MATCH 'клиника';`).Scan(&n); err != nil || n != 1 {
+			t.Errorf("rebuilt index matches %d tasks, %v; want 1", n, err)
+		}
+		return
+	}
+
+	// Так базу видит сборка без FTS5: таблица tasks_fts есть, модуля нет
+	if _, err := db.Exec(`CREATE TABLE tasks_fts (task TEXT, location TEXT);`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Up(ctx, db, dbtx.SQLite); err == nil || !strings.Contains(err.Error(), "sqlite_fts5") {
+		t.Errorf("Up with tasks_fts and without FTS5: err = %v", err)
+	}
+}
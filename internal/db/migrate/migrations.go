@@ -0,0 +1,329 @@
+package migrate
+
+import (
+	"adventBot/internal/db/dbtx"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// migrations — история схемы. Первые шаги повторяют то, что раньше делали Init() репозиториев,
+// и написаны так, чтобы их можно было применить к базе, уже созданной старым кодом.
+// Существующие миграции не редактируются: любое изменение схемы — новая миграция в конце списка.
+var migrations = []Migration{
+	{1, "create_chat", execAll(`
+CREATE TABLE IF NOT EXISTS chat (
+  chat_id {{int}} PRIMARY KEY,
+  time_zone TEXT NOT NULL
+);`)},
+	{2, "create_messages", execAll(`
+CREATE TABLE IF NOT EXISTS messages (
+  id {{id}},
+  chat_id {{int}} NOT NULL,
+  role TEXT NOT NULL,
+  message TEXT NOT NULL,
+  timestamp {{int}} NOT NULL,
+  UNIQUE (chat_id, timestamp, role)
+);`)},
+	{3, "create_tasks", execAll(`
+CREATE TABLE IF NOT EXISTS tasks (
+	chat_id {{int}} NOT NULL,
+	task TEXT NOT NULL,
+	location TEXT NOT NULL,
+	date_time TEXT NOT NULL,
+	PRIMARY KEY (chat_id, date_time)
+);`)},
+	{4, "tasks_ids_and_status", tasksIdsAndStatus},
+	{5, "tasks_reminders", addColumns("tasks",
+		column{"remind_before", "{{int}}"},
+		column{"reminded_at", "TEXT"},
+	)},
+	{6, "chat_settings", addColumns("chat",
+		column{"remind_before", "{{int}} NOT NULL DEFAULT 15"},
+		column{"digest_time", "TEXT NOT NULL DEFAULT '08:00'"},
+	)},
+	{7, "create_subscriptions", execAll(`
+CREATE TABLE IF NOT EXISTS subscriptions (
+  chat_id {{int}} PRIMARY KEY,
+  created_at TEXT NOT NULL,
+  last_digest_at TEXT
+);`)},
+	{8, "tasks_recurrence", chain(
+		addColumns("tasks", column{"rrule", "TEXT NOT NULL DEFAULT ''"}),
+		execAll(`
+CREATE TABLE IF NOT EXISTS task_occurrences (
+	task_id {{int}} NOT NULL,
+	occurs_at TEXT NOT NULL,
+	status TEXT NOT NULL DEFAULT 'open',
+	reminded_at TEXT,
+	PRIMARY KEY (task_id, occurs_at)
+);`),
+	)},
+	{9, "tasks_due_at", chain(
+		addColumns("tasks", column{"due_at", "TEXT NOT NULL DEFAULT ''"}),
+		backfillDueAt,
+		execAll(`CREATE INDEX IF NOT EXISTS idx_tasks_chat_due_at ON tasks (chat_id, due_at);`),
+	)},
+	{10, "tasks_fts", tasksFTS},
+	{11, "create_pending_tasks", execAll(`
+CREATE TABLE IF NOT EXISTS pending_tasks (
+  chat_id {{int}} PRIMARY KEY,
+  tasks TEXT NOT NULL,
+  created_at TEXT NOT NULL
+);`)},
+	{12, "tasks_time_zone", chain(
+		addColumns("tasks", column{"time_zone", "TEXT NOT NULL DEFAULT ''"}),
+		execAll(`
+UPDATE tasks
+SET time_zone = COALESCE((SELECT chat.time_zone FROM chat WHERE chat.chat_id = tasks.chat_id), '')
+WHERE time_zone = '';`),
+	)},
+	{13, "tasks_search_text", tasksSearchText},
+}
+
+// startup — шаги, которые Up выполняет при каждом запуске после миграций. Их результат зависит не только
+// от схемы, но и от сборки, поэтому запись в schema_migrations его не гарантирует; шаги идемпотентны.
+var startup = []Migration{
+	{10, "tasks_fts", ensureTasksFTS},
+}
+
+const createTasksWithIds = `
+CREATE TABLE tasks (
+	id {{id}},
+	chat_id {{int}} NOT NULL,
+	task TEXT NOT NULL,
+	location TEXT NOT NULL,
+	date_time TEXT NOT NULL,
+	status TEXT NOT NULL DEFAULT 'open',
+	created_at TEXT NOT NULL,
+	updated_at TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_tasks_chat_date_time ON tasks (chat_id, date_time);
+`
+
+// tasksIdsAndStatus переносит задачи из таблицы с PRIMARY KEY (chat_id, date_time) в таблицу с id и статусом.
+func tasksIdsAndStatus(ctx context.Context, tx *sql.Tx, d dialect) error {
+	ok, err := d.hasColumn(ctx, tx, "tasks", "id")
+	if err != nil || ok {
+		return err
+	}
+
+	rename := []string{`ALTER TABLE tasks RENAME TO tasks_legacy;`}
+	if d.driver == dbtx.Postgres {
+		// В PostgreSQL индекс первичного ключа не переименовывается вместе с таблицей и занял бы имя tasks_pkey
+		rename = append(rename, `ALTER INDEX tasks_pkey RENAME TO tasks_legacy_pkey;`)
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	return chain(
+		execAll(rename...),
+		execAll(createTasksWithIds),
+		func(ctx context.Context, tx *sql.Tx, d dialect) error {
+			_, err := tx.ExecContext(ctx, d.sql(`
+INSERT INTO tasks (chat_id, task, location, date_time, status, created_at, updated_at)
+SELECT chat_id, task, location, date_time, 'open', ?, ?
+FROM tasks_legacy
+ORDER BY date_time;`), now, now)
+			return err
+		},
+		execAll(`DROP TABLE tasks_legacy;`),
+	)(ctx, tx, d)
+}
+
+// backfillDueAt заполняет due_at — момент задачи в UTC в формате RFC 3339, который сравнивается
+// как строка. Задачи с некорректным date_time остаются с пустым due_at и не попадают в диапазоны.
+func backfillDueAt(ctx context.Context, tx *sql.Tx, d dialect) error {
+	rows, err := tx.QueryContext(ctx, `SELECT id, date_time FROM tasks WHERE due_at = '';`)
+	if err != nil {
+		return err
+	}
+	due := make(map[int64]string)
+	for rows.Next() {
+		var (
+			id       int64
+			dateTime string
+		)
+		if err := rows.Scan(&id, &dateTime); err != nil {
+			_ = rows.Close()
+			return err
+		}
+		if t, err := time.Parse(time.RFC3339, dateTime); err == nil {
+			due[id] = t.UTC().Format(time.RFC3339)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		_ = rows.Close()
+		return err
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+
+	for id, at := range due {
+		if _, err := tx.ExecContext(ctx, d.sql(`UPDATE tasks SET due_at = ? WHERE id = ?;`), at, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const createTasksFTS = `
+CREATE VIRTUAL TABLE IF NOT EXISTS tasks_fts USING fts5(task, location, content='tasks', content_rowid='id');
+CREATE TRIGGER IF NOT EXISTS tasks_fts_insert AFTER INSERT ON tasks BEGIN
+	INSERT INTO tasks_fts (rowid, task, location) VALUES (new.id, new.task, new.location);
+END;
+CREATE TRIGGER IF NOT EXISTS tasks_fts_delete AFTER DELETE ON tasks BEGIN
+	INSERT INTO tasks_fts (tasks_fts, rowid, task, location) VALUES ('delete', old.id, old.task, old.location);
+END;
+CREATE TRIGGER IF NOT EXISTS tasks_fts_update AFTER UPDATE OF task, location ON tasks BEGIN
+	INSERT INTO tasks_fts (tasks_fts, rowid, task, location) VALUES ('delete', old.id, old.task, old.location);
+	INSERT INTO tasks_fts (rowid, task, location) VALUES (new.id, new.task, new.location);
+END;
+INSERT INTO tasks_fts (tasks_fts) VALUES ('rebuild');
+`
+
+// tasksFTS создаёт полнотекстовый индекс задач для /find, если SQLite собран с FTS5 (go build -tags sqlite_fts5).
+// Иначе, как и в PostgreSQL, индекса нет и поиск идёт по вхождению подстроки. Индекс поддерживают
+// триггеры, поэтому базу с ним дальше нужно открывать только сборкой с FTS5.
+func tasksFTS(ctx context.Context, tx *sql.Tx, d dialect) error {
+	if d.driver != dbtx.SQLite {
+		return nil
+	}
+
+	var fts5 bool
+	if err := tx.QueryRowContext(ctx, `SELECT sqlite_compileoption_used('ENABLE_FTS5');`).Scan(&fts5); err != nil {
+		return err
+	}
+	if !fts5 {
+		log.Println("[migrate.tasksFTS] SQLite is built without FTS5, task search will not use an index")
+		return nil
+	}
+	return execAll(createTasksFTS)(ctx, tx, d)
+}
+
+// ensureTasksFTS при каждом запуске приводит индекс tasks_fts в соответствие со сборкой. Миграция 10,
+// применённая сборкой без FTS5, индекс не создала — его создаёт первый запуск сборки с FTS5 (go build -tags
+// sqlite_fts5); без индекса поиск идёт по search_text. Индекс поддерживают триггеры, и без FTS5 любая запись
+// в tasks падала бы с «no such module: fts5», поэтому базу с индексом сборка без FTS5 открыть отказывается.
+func ensureTasksFTS(ctx context.Context, tx *sql.Tx, d dialect) error {
+	if d.driver != dbtx.SQLite {
+		return nil
+	}
+
+	exists, err := d.hasTable(ctx, tx, "tasks_fts")
+	if err != nil {
+		return err
+	}
+	var fts5 bool
+	if err := tx.QueryRowContext(ctx, `SELECT sqlite_compileoption_used('ENABLE_FTS5');`).Scan(&fts5); err != nil {
+		return err
+	}
+
+	switch {
+	case exists && fts5:
+		return nil
+	case exists:
+		return errors.New("database has the tasks_fts index, but SQLite is built without FTS5: rebuild with -tags sqlite_fts5")
+	case !fts5:
+		log.Println("[migrate.ensureTasksFTS] SQLite is built without FTS5, task search will not use an index")
+		return nil
+	}
+
+	if err := execAll(createTasksFTS)(ctx, tx, d); err != nil {
+		return err
+	}
+	log.Println("[migrate.ensureTasksFTS] created tasks_fts index")
+	return nil
+}
+
+// tasksSearchText добавляет в SQLite колонку search_text — текст и место задачи в нижнем регистре для поиска
+// через LIKE, когда нет FTS5: LIKE в SQLite без ICU не сворачивает регистр кириллицы. В PostgreSQL есть ILIKE.
+func tasksSearchText(ctx context.Context, tx *sql.Tx, d dialect) error {
+	if d.driver != dbtx.SQLite {
+		return nil
+	}
+	if err := addColumns("tasks", column{"search_text", "TEXT NOT NULL DEFAULT ''"})(ctx, tx, d); err != nil {
+		return err
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT id, task, location FROM tasks;`)
+	if err != nil {
+		return err
+	}
+	text := make(map[int64]string)
+	for rows.Next() {
+		var (
+			id             int64
+			title, address string
+		)
+		if err := rows.Scan(&id, &title, &address); err != nil {
+			_ = rows.Close()
+			return err
+		}
+		text[id] = strings.ToLower(title + "\n" + address)
+	}
+	if err := rows.Err(); err != nil {
+		_ = rows.Close()
+		return err
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+
+	for id, s := range text {
+		if _, err := tx.ExecContext(ctx, `UPDATE tasks SET search_text = ? WHERE id = ?;`, s, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type column struct {
+	name       string
+	definition string
+}
+
+func execAll(queries ...string) step {
+	return func(ctx context.Context, tx *sql.Tx, d dialect) error {
+		for _, q := range queries {
+			if _, err := tx.ExecContext(ctx, d.sql(q)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// addColumns добавляет колонки, которых ещё нет: базы, поднятые старым Init(), могут уже их содержать.
+func addColumns(table string, columns ...column) step {
+	return func(ctx context.Context, tx *sql.Tx, d dialect) error {
+		for _, c := range columns {
+			ok, err := d.hasColumn(ctx, tx, table, c.name)
+			if err != nil {
+				return err
+			}
+			if ok {
+				continue
+			}
+			if _, err := tx.ExecContext(ctx, d.sql(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s;`, table, c.name, c.definition))); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func chain(steps ...step) step {
+	return func(ctx context.Context, tx *sql.Tx, d dialect) error {
+		for _, s := range steps {
+			if err := s(ctx, tx, d); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
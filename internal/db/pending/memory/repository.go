@@ -0,0 +1,68 @@
+package memory
+
+import (
+	"adventBot/internal/db/memdb"
+	"adventBot/internal/db/pending"
+	"context"
+	"log"
+	"maps"
+	"slices"
+	"time"
+)
+
+type table struct {
+	drafts map[int64]pending.Pending
+}
+
+func (t *table) Snapshot() func() {
+	saved := maps.Clone(t.drafts)
+	return func() { t.drafts = saved }
+}
+
+type RepositoryMemory struct {
+	db *memdb.DB
+	t  *table
+}
+
+func NewRepositoryMemory(db *memdb.DB) *RepositoryMemory {
+	t := &table{drafts: make(map[int64]pending.Pending)}
+	db.Register(t)
+	return &RepositoryMemory{db: db, t: t}
+}
+
+// WithTx возвращает репозиторий над теми же данными, работающий внутри транзакции tx.
+func (r *RepositoryMemory) WithTx(tx *memdb.DB) *RepositoryMemory {
+	return &RepositoryMemory{db: tx, t: r.t}
+}
+
+func (r *RepositoryMemory) GetById(ctx context.Context, chatID int64) (pending.Pending, bool, error) {
+	var (
+		p     pending.Pending
+		found bool
+	)
+	r.db.Read(func() {
+		p, found = r.t.drafts[chatID]
+		p.Tasks = slices.Clone(p.Tasks)
+	})
+	return p, found, nil
+}
+
+func (r *RepositoryMemory) Upsert(ctx context.Context, p pending.Pending) error {
+	p.Tasks = slices.Clone(p.Tasks)
+	p.CreatedAt = p.CreatedAt.UTC().Truncate(time.Second)
+	r.db.Write(func() {
+		r.t.drafts[p.ChatID] = p
+	})
+	log.Printf("[pending/RepositoryMemory.Upsert] success chatID=%d tasks=%d", p.ChatID, len(p.Tasks))
+	return nil
+}
+
+func (r *RepositoryMemory) DeleteById(ctx context.Context, chatID int64) (bool, error) {
+	var found bool
+	r.db.Write(func() {
+		_, found = r.t.drafts[chatID]
+		delete(r.t.drafts, chatID)
+	})
+	log.Printf("[pending/RepositoryMemory.DeleteById] chatID=%d deleted=%t", chatID, found)
+	return found, nil
+}
@@ -0,0 +1,14 @@
+package pending
+
+import (
+	"adventBot/internal/db/task"
+	"time"
+)
+
+// Pending — черновик чата: задачи, которые модель разобрала из сообщения и которые ждут подтверждения.
+// У чата не больше одного черновика; новый заменяет прежний.
+type Pending struct {
+	ChatID    int64
+	Tasks     []task.Task
+	CreatedAt time.Time // по нему кнопки черновика отличают его от более нового
+}
@@ -0,0 +1,7 @@
+package postgres
+
+type Pending struct {
+	ChatID    int64  `db:"chat_id"`
+	Tasks     string `db:"tasks"` // JSON-массив task.Task
+	CreatedAt string `db:"created_at"`
+}
@@ -0,0 +1,37 @@
+package postgres
+
+import "fmt"
+
+const (
+	tableName    = "pending_tasks"
+	colChatId    = "chat_id"
+	colTasks     = "tasks"
+	colCreatedAt = "created_at"
+)
+
+var upsert = fmt.Sprintf(`
+INSERT INTO %s (%s, %s, %s)
+VALUES ($1, $2, $3)
+ON CONFLICT(%s) DO UPDATE SET %s = excluded.%s, %s = excluded.%s;`,
+	tableName,
+	colChatId, colTasks, colCreatedAt,
+	colChatId,
+	colTasks, colTasks,
+	colCreatedAt, colCreatedAt,
+)
+
+var selectByChatId = fmt.Sprintf(`
+SELECT %s, %s, %s
+FROM %s
+WHERE %s = $1;`,
+	colChatId, colTasks, colCreatedAt,
+	tableName,
+	colChatId,
+)
+
+var deleteByChatId = fmt.Sprintf(`
+DELETE FROM %s
+WHERE %s = $1;`,
+	tableName,
+	colChatId,
+)
@@ -0,0 +1,10 @@
+package pending
+
+import "context"
+
+type Repository interface {
+	GetById(ctx context.Context, chatID int64) (Pending, bool, error)
+	// Upsert сохраняет черновик чата, заменяя прежний.
+	Upsert(ctx context.Context, p Pending) error
+	DeleteById(ctx context.Context, chatID int64) (bool, error)
+}
@@ -0,0 +1,85 @@
+package sqlite
+
+import (
+	"adventBot/internal/db/dbtx"
+	"adventBot/internal/db/pending"
+	"adventBot/internal/db/task"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+type RepositorySQlite struct {
+	db dbtx.DBTX
+}
+
+func NewRepositorySQlite(db dbtx.DBTX) *RepositorySQlite {
+	return &RepositorySQlite{db: db}
+}
+
+func (r *RepositorySQlite) GetById(ctx context.Context, chatID int64) (pending.Pending, bool, error) {
+	var p Pending
+	row := r.db.QueryRowContext(ctx, selectByChatId, chatID)
+	switch err := row.Scan(&p.ChatID, &p.Tasks, &p.CreatedAt); {
+	case err == nil:
+	case errors.Is(err, sql.ErrNoRows):
+		return pending.Pending{}, false, nil
+	default:
+		log.Printf("[pending/RepositorySQlite.GetById] error chatID=%d err=%v", chatID, err)
+		return pending.Pending{}, false, err
+	}
+
+	var tasks []task.Task
+	if err := json.Unmarshal([]byte(p.Tasks), &tasks); err != nil {
+		log.Printf("[pending/RepositorySQlite.GetById] chatID=%d bad tasks json: %v", chatID, err)
+		return pending.Pending{}, false, fmt.Errorf("decode pending tasks: %w", err)
+	}
+	return pending.Pending{ChatID: p.ChatID, Tasks: tasks, CreatedAt: parseTime(p.CreatedAt)}, true, nil
+}
+
+func (r *RepositorySQlite) Upsert(ctx context.Context, p pending.Pending) error {
+	tasks, err := json.Marshal(p.Tasks)
+	if err != nil {
+		return fmt.Errorf("encode pending tasks: %w", err)
+	}
+
+	if _, err := r.db.ExecContext(ctx, upsert, p.ChatID, string(tasks), formatTime(p.CreatedAt)); err != nil {
+		log.Printf("[pending/RepositorySQlite.Upsert] chatID=%d err=%v", p.ChatID, err)
+		return err
+	}
+	log.Printf("[pending/RepositorySQlite.Upsert] success chatID=%d tasks=%d", p.ChatID, len(p.Tasks))
+	return nil
+}
+
+func (r *RepositorySQlite) DeleteById(ctx context.Context, chatID int64) (bool, error) {
+	res, err := r.db.ExecContext(ctx, deleteByChatId, chatID)
+	if err != nil {
+		log.Printf("[pending/RepositorySQlite.DeleteById] chatID=%d error=%v", chatID, err)
+		return false, err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		log.Printf("[pending/RepositorySQlite.DeleteById] chatID=%d error getting RowsAffected=%v", chatID, err)
+		return false, err
+	}
+
+	log.Printf("[pending/RepositorySQlite.DeleteById] chatID=%d deleted=%t", chatID, rows > 0)
+	return rows > 0, nil
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+func parseTime(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
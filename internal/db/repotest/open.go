@@ -0,0 +1,123 @@
+// Package repotest — общий набор проверок, которому должна соответствовать каждая реализация
+// репозиториев, и хелперы, поднимающие для него чистую базу.
+package repotest
+
+import (
+	"adventBot/internal/db/dbtx"
+	"adventBot/internal/db/migrate"
+	"adventBot/internal/db/store"
+	"context"
+	"database/sql"
+	"fmt"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// PostgresDSNEnv — переменная окружения со строкой подключения к PostgreSQL для тестов.
+const PostgresDSNEnv = "PG_TEST_DSN"
+
+// Factory возвращает репозитории поверх пустой базы, принадлежащей одному тесту.
+type Factory func(t *testing.T) store.Repositories
+
+// SQLite открывает новую базу во временном каталоге теста и применяет миграции.
+func SQLite(t *testing.T) store.Repositories {
+	t.Helper()
+
+	db, err := sql.Open(string(dbtx.SQLite), filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	return migrated(t, db, dbtx.SQLite)
+}
+
+// Memory возвращает репозитории хранилища в памяти процесса.
+func Memory(t *testing.T) store.Repositories {
+	return store.NewMemory().Repositories
+}
+
+// Postgres создаёт для теста отдельную схему в PostgreSQL и применяет в ней миграции.
+// Сервер берётся из PG_TEST_DSN, иначе поднимается временный через pg_tmp; если нет ни того,
+// ни другого, тест пропускается.
+func Postgres(t *testing.T) store.Repositories {
+	t.Helper()
+
+	dsn := postgresDSN(t)
+	admin, err := sql.Open(string(dbtx.Postgres), dsn)
+	if err != nil {
+		t.Fatalf("open postgres: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = admin.Close()
+	})
+
+	schema := fmt.Sprintf("repotest_%d", time.Now().UnixNano())
+	if _, err := admin.Exec(`CREATE SCHEMA ` + schema); err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := admin.Exec(`DROP SCHEMA ` + schema + ` CASCADE`); err != nil {
+			t.Logf("drop schema %s: %v", schema, err)
+		}
+	})
+
+	db, err := sql.Open(string(dbtx.Postgres), withParam(dsn, "search_path", schema))
+	if err != nil {
+		t.Fatalf("open postgres: %v", err)
+	}
+	return migrated(t, db, dbtx.Postgres)
+}
+
+func migrated(t *testing.T, db *sql.DB, driver dbtx.Driver) store.Repositories {
+	t.Helper()
+
+	st, err := store.New(db, driver)
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = st.Close()
+	})
+
+	if _, err := migrate.Up(context.Background(), db, driver); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return st.Repositories
+}
+
+func postgresDSN(t *testing.T) string {
+	t.Helper()
+
+	if dsn := os.Getenv(PostgresDSNEnv); dsn != "" {
+		return dsn
+	}
+
+	path, err := exec.LookPath("pg_tmp")
+	if err != nil {
+		t.Skipf("postgres is not available: set %s or install pg_tmp", PostgresDSNEnv)
+	}
+	// pg_tmp печатает URI временного сервера, который сам удаляется после отключения клиентов
+	out, err := exec.Command(path, "-t").Output()
+	if err != nil {
+		t.Skipf("pg_tmp: %v", err)
+	}
+	// временный сервер слушает только localhost и без TLS
+	return withParam(strings.TrimSpace(string(out)), "sslmode", "disable")
+}
+
+// withParam добавляет параметр подключения к DSN в формате URI или key=value.
+func withParam(dsn string, key string, value string) string {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		sep := "?"
+		if strings.Contains(dsn, "?") {
+			sep = "&"
+		}
+		return dsn + sep + key + "=" + value
+	}
+	return dsn + " " + key + "=" + value
+}
@@ -0,0 +1,19 @@
+package repotest_test
+
+import (
+	"adventBot/internal/db/repotest"
+	"testing"
+)
+
+func TestSQLite(t *testing.T) {
+	repotest.Run(t, repotest.SQLite)
+}
+
+// TestPostgres пропускается, если не задан PG_TEST_DSN и нет pg_tmp.
+func TestPostgres(t *testing.T) {
+	repotest.Run(t, repotest.Postgres)
+}
+
+func TestMemory(t *testing.T) {
+	repotest.Run(t, repotest.Memory)
+}
@@ -0,0 +1,477 @@
+package repotest
+
+import (
+	"adventBot/internal/db/chat"
+	"adventBot/internal/db/pending"
+	"adventBot/internal/db/task"
+	"context"
+	"testing"
+	"time"
+)
+
+// Run прогоняет все проверки репозиториев; open вызывается заново для каждой подпроверки.
+func Run(t *testing.T, open Factory) {
+	t.Run("Chat", func(t *testing.T) { RunChat(t, open) })
+	t.Run("Messages", func(t *testing.T) { RunMessages(t, open) })
+	t.Run("Tasks", func(t *testing.T) { RunTasks(t, open) })
+	t.Run("TaskRanges", func(t *testing.T) { RunTaskRanges(t, open) })
+	t.Run("RecurringTasks", func(t *testing.T) { RunRecurringTasks(t, open) })
+	t.Run("TaskSearch", func(t *testing.T) { RunTaskSearch(t, open) })
+	t.Run("Subscriptions", func(t *testing.T) { RunSubscriptions(t, open) })
+	t.Run("Pending", func(t *testing.T) { RunPending(t, open) })
+}
+
+func RunChat(t *testing.T, open Factory) {
+	ctx := context.Background()
+	repo := open(t).Chats
+
+	if _, found, err := repo.GetById(ctx, 1); err != nil || found {
+		t.Fatalf("GetById on empty repo: found=%t err=%v", found, err)
+	}
+	if s, err := repo.GetSettings(ctx, 1); err != nil || s != chat.DefaultSettings() {
+		t.Fatalf("GetSettings on unknown chat = %+v, %v; want defaults", s, err)
+	}
+	if ok, err := repo.UpdateSettings(ctx, 1, chat.Settings{RemindBefore: 5, DigestTime: "09:00"}); err != nil || ok {
+		t.Fatalf("UpdateSettings on unknown chat = %t, %v; want false", ok, err)
+	}
+
+	mustNoErr(t, "Upsert", repo.Upsert(ctx, 1, "Europe/Moscow"))
+	mustNoErr(t, "Upsert", repo.Upsert(ctx, 2, "Asia/Tokyo"))
+	mustNoErr(t, "Upsert", repo.Upsert(ctx, 1, "Europe/Berlin"))
+
+	if tz, found, err := repo.GetById(ctx, 1); err != nil || !found || tz != "Europe/Berlin" {
+		t.Fatalf("GetById(1) = %q, %t, %v; want Europe/Berlin", tz, found, err)
+	}
+	if s, err := repo.GetSettings(ctx, 1); err != nil || s != chat.DefaultSettings() {
+		t.Fatalf("GetSettings(1) = %+v, %v; want defaults", s, err)
+	}
+
+	want := chat.Settings{RemindBefore: 30, DigestTime: "07:45"}
+	if ok, err := repo.UpdateSettings(ctx, 1, want); err != nil || !ok {
+		t.Fatalf("UpdateSettings(1) = %t, %v; want true", ok, err)
+	}
+	if s, err := repo.GetSettings(ctx, 1); err != nil || s != want {
+		t.Fatalf("GetSettings(1) = %+v, %v; want %+v", s, err, want)
+	}
+	mustNoErr(t, "Upsert", repo.Upsert(ctx, 1, "Europe/Paris"))
+	if s, err := repo.GetSettings(ctx, 1); err != nil || s != want {
+		t.Fatalf("Upsert reset settings: %+v, %v; want %+v", s, err, want)
+	}
+
+	if ok, err := repo.DeleteById(ctx, 1); err != nil || !ok {
+		t.Fatalf("DeleteById(1) = %t, %v; want true", ok, err)
+	}
+	if ok, err := repo.DeleteById(ctx, 1); err != nil || ok {
+		t.Fatalf("second DeleteById(1) = %t, %v; want false", ok, err)
+	}
+	if _, found, err := repo.GetById(ctx, 2); err != nil || !found {
+		t.Fatalf("DeleteById(1) touched chat 2: found=%t err=%v", found, err)
+	}
+}
+
+func RunMessages(t *testing.T, open Factory) {
+	ctx := context.Background()
+	repo := open(t).Messages
+
+	if msgs, found, err := repo.GetById(ctx, 1, "UTC"); err != nil || found || len(msgs) != 0 {
+		t.Fatalf("GetById on empty repo = %v, %t, %v", msgs, found, err)
+	}
+
+	mustNoErr(t, "Upsert", repo.Upsert(ctx, 1, "assistant", "ответ", 20))
+	mustNoErr(t, "Upsert", repo.Upsert(ctx, 1, "user", "вопрос", 10))
+	mustNoErr(t, "Upsert", repo.Upsert(ctx, 2, "user", "чужой чат", 15))
+	// та же пара (timestamp, role) перезаписывает текст
+	mustNoErr(t, "Upsert", repo.Upsert(ctx, 1, "user", "уточнённый вопрос", 10))
+
+	msgs, found, err := repo.GetById(ctx, 1, "Europe/Moscow")
+	if err != nil || !found {
+		t.Fatalf("GetById(1): found=%t err=%v", found, err)
+	}
+	if len(msgs) != 2 {
+		t.Fatalf("GetById(1) returned %d messages, want 2: %v", len(msgs), msgs)
+	}
+	if msgs[0].Message != "уточнённый вопрос" || msgs[0].Timestamp != 10 || msgs[1].Role != "assistant" {
+		t.Fatalf("GetById(1) = %+v; want messages ordered by timestamp with upserted text", msgs)
+	}
+	if msgs[0].TimeZone != "Europe/Moscow" {
+		t.Fatalf("GetById(1) TimeZone = %q, want the one passed in", msgs[0].TimeZone)
+	}
+
+	if ok, err := repo.DeleteById(ctx, 1); err != nil || !ok {
+		t.Fatalf("DeleteById(1) = %t, %v; want true", ok, err)
+	}
+	if _, found, _ := repo.GetById(ctx, 1, "UTC"); found {
+		t.Fatal("messages of chat 1 survived DeleteById")
+	}
+	if _, found, _ := repo.GetById(ctx, 2, "UTC"); !found {
+		t.Fatal("DeleteById(1) removed messages of chat 2")
+	}
+	if ok, err := repo.DeleteById(ctx, 1); err != nil || ok {
+		t.Fatalf("second DeleteById(1) = %t, %v; want false", ok, err)
+	}
+}
+
+func RunTasks(t *testing.T, open Factory) {
+	ctx := context.Background()
+	repo := open(t).Tasks
+
+	remind := 10
+	morning := task.Task{ChatID: 1, Task: "Встреча", Location: "Офис", DateTime: "2030-05-01T09:00:00+03:00", RemindBefore: &remind}
+	id, err := repo.Create(ctx, morning)
+	if err != nil || id == 0 {
+		t.Fatalf("Create = %d, %v", id, err)
+	}
+
+	got, found, err := repo.GetByID(ctx, 1, id)
+	if err != nil || !found {
+		t.Fatalf("GetByID(%d): found=%t err=%v", id, found, err)
+	}
+	if got.Task != morning.Task || got.Location != morning.Location || got.DateTime != morning.DateTime ||
+		got.Status != task.StatusOpen || got.RemindBefore == nil || *got.RemindBefore != remind || got.CreatedAt.IsZero() {
+		t.Fatalf("GetByID(%d) = %+v; want stored copy of %+v", id, got, morning)
+	}
+	if _, found, _ := repo.GetByID(ctx, 2, id); found {
+		t.Fatal("GetByID found a task of another chat")
+	}
+
+	ids, err := repo.CreateAll(ctx, []task.Task{
+		{ChatID: 1, Task: "Ужин", DateTime: "2030-05-01T19:00:00+03:00"},
+		{ChatID: 1, Task: "Зал", DateTime: "2030-04-30T07:00:00+03:00"},
+		{ChatID: 2, Task: "Чужая", DateTime: "2030-05-01T12:00:00+03:00"},
+	})
+	if err != nil || len(ids) != 3 {
+		t.Fatalf("CreateAll = %v, %v", ids, err)
+	}
+	if got, _, _ := repo.GetByID(ctx, 1, ids[1]); got.Task != "Зал" {
+		t.Fatalf("CreateAll ids are not in input order: id %d is %q", ids[1], got.Task)
+	}
+
+	assertTasks(t, "GetAll(1)", tasksOf(t, "GetAll")(repo.GetAll(ctx, 1)), "Зал", "Встреча", "Ужин")
+	// 2030-05-01 по Москве: 2030-04-30T21:00Z — 2030-05-01T21:00Z
+	msk := time.FixedZone("MSK", 3*60*60)
+	from, to := time.Date(2030, 5, 1, 0, 0, 0, 0, msk), time.Date(2030, 5, 2, 0, 0, 0, 0, msk)
+	assertTasks(t, "ListRange(1)", tasksOf(t, "ListRange")(repo.ListRange(ctx, 1, from, to)), "Встреча", "Ужин")
+
+	morning.ID, morning.ChatID = id, 1
+	morning.DateTime = "2030-05-02T10:00:00+03:00"
+	if ok, err := repo.Update(ctx, morning); err != nil || !ok {
+		t.Fatalf("Update = %t, %v", ok, err)
+	}
+	assertTasks(t, "ListRange(1) after Update", tasksOf(t, "ListRange")(repo.ListRange(ctx, 1, from, to)), "Ужин")
+	if ok, _ := repo.Update(ctx, task.Task{ID: id, ChatID: 2, Task: "x", DateTime: morning.DateTime}); ok {
+		t.Fatal("Update changed a task of another chat")
+	}
+
+	if ok, err := repo.Complete(ctx, 1, ids[0]); err != nil || !ok {
+		t.Fatalf("Complete = %t, %v", ok, err)
+	}
+	if ok, err := repo.Cancel(ctx, 1, ids[1]); err != nil || !ok {
+		t.Fatalf("Cancel = %t, %v", ok, err)
+	}
+	assertTasks(t, "GetAll(1) after Complete/Cancel", tasksOf(t, "GetAll")(repo.GetAll(ctx, 1)), "Встреча")
+	if got, _, _ := repo.GetByID(ctx, 1, ids[0]); got.Status != task.StatusDone {
+		t.Fatalf("completed task status = %q", got.Status)
+	}
+
+	pending := tasksOf(t, "ListPendingReminders")(repo.ListPendingReminders(ctx))
+	assertTasks(t, "ListPendingReminders", pending, "Чужая", "Встреча")
+	if ok, err := repo.MarkReminded(ctx, 1, id); err != nil || !ok {
+		t.Fatalf("MarkReminded = %t, %v", ok, err)
+	}
+	assertTasks(t, "ListPendingReminders after MarkReminded", tasksOf(t, "ListPendingReminders")(repo.ListPendingReminders(ctx)), "Чужая")
+	if got, _, _ := repo.GetByID(ctx, 1, id); got.RemindedAt.IsZero() {
+		t.Fatal("MarkReminded did not store RemindedAt")
+	}
+	if _, err := repo.Update(ctx, morning); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if got, _, _ := repo.GetByID(ctx, 1, id); !got.RemindedAt.IsZero() {
+		t.Fatal("Update did not reset RemindedAt")
+	}
+
+	if ok, err := repo.Delete(ctx, 2, id); err != nil || ok {
+		t.Fatalf("Delete from another chat = %t, %v; want false", ok, err)
+	}
+	if ok, err := repo.Delete(ctx, 1, id); err != nil || !ok {
+		t.Fatalf("Delete = %t, %v", ok, err)
+	}
+	if _, found, _ := repo.GetByID(ctx, 1, id); found {
+		t.Fatal("task survived Delete")
+	}
+	if ok, err := repo.Complete(ctx, 1, id); err != nil || ok {
+		t.Fatalf("Complete of deleted task = %t, %v; want false", ok, err)
+	}
+}
+
+// RunTaskRanges проверяет, что ListRange сравнивает фактический момент задачи, а не строку с её смещением.
+func RunTaskRanges(t *testing.T, open Factory) {
+	ctx := context.Background()
+	repo := open(t).Tasks
+
+	for _, tt := range []task.Task{
+		{ChatID: 1, Task: "Нью-Йорк", DateTime: "2030-05-01T23:30:00-04:00"}, // 2030-05-02T03:30Z
+		{ChatID: 1, Task: "Токио", DateTime: "2030-05-02T08:00:00+09:00"},    // 2030-05-01T23:00Z
+		{ChatID: 1, Task: "UTC", DateTime: "2030-05-02T12:00:00Z"},
+		{ChatID: 1, Task: "Граница", DateTime: "2030-05-03T00:00:00Z"},
+		{ChatID: 1, Task: "Без даты", DateTime: "завтра"},
+	} {
+		if _, err := repo.Create(ctx, tt); err != nil {
+			t.Fatalf("Create(%s): %v", tt.Task, err)
+		}
+	}
+
+	from, to := time.Date(2030, 5, 2, 0, 0, 0, 0, time.UTC), time.Date(2030, 5, 3, 0, 0, 0, 0, time.UTC)
+	assertTasks(t, "ListRange(UTC day)", tasksOf(t, "ListRange")(repo.ListRange(ctx, 1, from, to)), "Нью-Йорк", "UTC")
+
+	tokyo := time.FixedZone("JST", 9*60*60)
+	from, to = time.Date(2030, 5, 2, 0, 0, 0, 0, tokyo), time.Date(2030, 5, 3, 0, 0, 0, 0, tokyo)
+	assertTasks(t, "ListRange(Tokyo day)", tasksOf(t, "ListRange")(repo.ListRange(ctx, 1, from, to)), "Токио", "Нью-Йорк", "UTC")
+	assertTasks(t, "ListRange(other chat)", tasksOf(t, "ListRange")(repo.ListRange(ctx, 2, from, to)))
+}
+
+func RunRecurringTasks(t *testing.T, open Factory) {
+	ctx := context.Background()
+	repo := open(t).Tasks
+
+	now := time.Now().UTC()
+	start := time.Date(now.Year(), now.Month(), now.Day(), 10, 0, 0, 0, time.UTC).AddDate(0, 0, -2)
+	dayFrom := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	dayTo := dayFrom.AddDate(0, 0, 1)
+	todayAt := start.AddDate(0, 0, 2)
+
+	id, err := repo.Create(ctx, task.Task{ChatID: 1, Task: "Зарядка", DateTime: start.Format(time.RFC3339), Recurrence: "FREQ=DAILY"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	single, err := repo.Create(ctx, task.Task{ChatID: 1, Task: "Разовая", DateTime: todayAt.Add(time.Hour).Format(time.RFC3339)})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	got, found, err := repo.GetByID(ctx, 1, id)
+	if err != nil || !found || got.Recurrence != "FREQ=DAILY" || got.DateTime != start.Format(time.RFC3339) {
+		t.Fatalf("GetByID(%d) = %+v, %t, %v; want the series with its first occurrence", id, got, found, err)
+	}
+
+	day := tasksOf(t, "ListRange")(repo.ListRange(ctx, 1, dayFrom, dayTo))
+	assertTasks(t, "ListRange", day, "Зарядка", "Разовая")
+	if day[0].ID != id || day[0].DateTime != todayAt.Format(time.RFC3339) {
+		t.Fatalf("ListRange occurrence = %+v; want id=%d at %s", day[0], id, todayAt.Format(time.RFC3339))
+	}
+
+	if ok, err := repo.SetOccurrenceStatus(ctx, 1, single, todayAt, task.StatusDone); err != nil || ok {
+		t.Fatalf("SetOccurrenceStatus on a one-off task = %t, %v; want false", ok, err)
+	}
+	if ok, err := repo.SetOccurrenceStatus(ctx, 2, id, todayAt, task.StatusDone); err != nil || ok {
+		t.Fatalf("SetOccurrenceStatus from another chat = %t, %v; want false", ok, err)
+	}
+	if ok, err := repo.SetOccurrenceStatus(ctx, 1, id, todayAt, task.StatusDone); err != nil || !ok {
+		t.Fatalf("SetOccurrenceStatus = %t, %v", ok, err)
+	}
+	assertTasks(t, "ListRange after SetOccurrenceStatus", tasksOf(t, "ListRange")(repo.ListRange(ctx, 1, dayFrom, dayTo)), "Разовая")
+	if got, _, _ := repo.GetByID(ctx, 1, id); got.Status != task.StatusOpen {
+		t.Fatalf("completing one occurrence changed the series status to %q", got.Status)
+	}
+
+	for _, p := range tasksOf(t, "ListPendingReminders")(repo.ListPendingReminders(ctx)) {
+		if p.ID != id {
+			continue
+		}
+		at, err := time.Parse(time.RFC3339, p.DateTime)
+		if err != nil {
+			t.Fatalf("pending occurrence dateTime %q: %v", p.DateTime, err)
+		}
+		if ok, err := repo.MarkOccurrenceReminded(ctx, 1, id, at); err != nil || !ok {
+			t.Fatalf("MarkOccurrenceReminded = %t, %v", ok, err)
+		}
+		for _, next := range tasksOf(t, "ListPendingReminders")(repo.ListPendingReminders(ctx)) {
+			if next.ID == id && next.DateTime == p.DateTime {
+				t.Fatalf("occurrence %s is still pending after MarkOccurrenceReminded", p.DateTime)
+			}
+		}
+	}
+
+	if ok, err := repo.Delete(ctx, 1, id); err != nil || !ok {
+		t.Fatalf("Delete = %t, %v", ok, err)
+	}
+	assertTasks(t, "ListRange after Delete", tasksOf(t, "ListRange")(repo.ListRange(ctx, 1, dayFrom, dayTo)), "Разовая")
+
+	// Повторения идут по часовому поясу чата: после перехода на летнее время остаются в 9:00
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		return
+	}
+	weekly, err := repo.Create(ctx, task.Task{ChatID: 3, Task: "Планёрка", DateTime: "2026-03-23T09:00:00+01:00", Recurrence: "FREQ=WEEKLY", TimeZone: "Europe/Berlin"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if got, _, err := repo.GetByID(ctx, 3, weekly); err != nil || got.TimeZone != "Europe/Berlin" {
+		t.Fatalf("GetByID(%d) = %+v, %v; want TimeZone Europe/Berlin", weekly, got, err)
+	}
+	monday := time.Date(2026, time.March, 30, 0, 0, 0, 0, berlin)
+	occ := tasksOf(t, "ListRange")(repo.ListRange(ctx, 3, monday, monday.AddDate(0, 0, 1)))
+	if len(occ) != 1 || occ[0].DateTime != "2026-03-30T09:00:00+02:00" {
+		t.Fatalf("ListRange after DST = %+v; want one occurrence at 2026-03-30T09:00:00+02:00", occ)
+	}
+}
+
+// RunTaskSearch проверяет только то, что одинаково для поиска по индексу и по подстроке:
+// слова запроса совпадают с началом слов задачи.
+func RunTaskSearch(t *testing.T, open Factory) {
+	ctx := context.Background()
+	repo := open(t).Tasks
+
+	ids := make(map[string]int64)
+	for _, tt := range []task.Task{
+		{ChatID: 1, Task: "Записаться к врачу", Location: "Поликлиника №3", DateTime: "2030-05-02T10:00:00Z"},
+		{ChatID: 1, Task: "Купить молоко", Location: "Магазин", DateTime: "2030-05-01T10:00:00Z"},
+		{ChatID: 1, Task: "Врач-стоматолог", DateTime: "2030-05-03T10:00:00Z"},
+		{ChatID: 1, Task: "Выполненная у врача", DateTime: "2030-05-04T10:00:00Z"},
+		{ChatID: 1, Task: "Зарядка у врача", DateTime: time.Now().UTC().Format(time.RFC3339), Recurrence: "FREQ=DAILY"},
+		{ChatID: 2, Task: "Врач в другом чате", DateTime: "2030-05-01T10:00:00Z"},
+	} {
+		id, err := repo.Create(ctx, tt)
+		if err != nil {
+			t.Fatalf("Create(%s): %v", tt.Task, err)
+		}
+		ids[tt.Task] = id
+	}
+	if ok, err := repo.Complete(ctx, 1, ids["Выполненная у врача"]); err != nil || !ok {
+		t.Fatalf("Complete = %t, %v", ok, err)
+	}
+
+	assertTasks(t, "Search(врач)", tasksOf(t, "Search")(repo.Search(ctx, 1, "врач")), "Зарядка у врача", "Записаться к врачу", "Врач-стоматолог")
+	assertTasks(t, "Search(ВРАЧ)", tasksOf(t, "Search")(repo.Search(ctx, 2, "ВРАЧ")), "Врач в другом чате")
+	assertTasks(t, "Search(location)", tasksOf(t, "Search")(repo.Search(ctx, 1, "врач поликлиника")), "Записаться к врачу")
+	assertTasks(t, "Search(no match)", tasksOf(t, "Search")(repo.Search(ctx, 1, "молоко аптека")))
+	assertTasks(t, "Search(empty)", tasksOf(t, "Search")(repo.Search(ctx, 1, "  ")))
+	if _, err := repo.Search(ctx, 1, `"молоко" OR * 50%`); err != nil {
+		t.Fatalf("Search(special chars): %v", err)
+	}
+
+	milk := task.Task{ID: ids["Купить молоко"], ChatID: 1, Task: "Купить кефир", Location: "Рынок", DateTime: "2030-05-01T10:00:00Z"}
+	if ok, err := repo.Update(ctx, milk); err != nil || !ok {
+		t.Fatalf("Update = %t, %v", ok, err)
+	}
+	assertTasks(t, "Search(after update, old)", tasksOf(t, "Search")(repo.Search(ctx, 1, "молоко")))
+	assertTasks(t, "Search(after update, new)", tasksOf(t, "Search")(repo.Search(ctx, 1, "рынок")), "Купить кефир")
+
+	if ok, err := repo.Delete(ctx, 1, milk.ID); err != nil || !ok {
+		t.Fatalf("Delete = %t, %v", ok, err)
+	}
+	assertTasks(t, "Search(after delete)", tasksOf(t, "Search")(repo.Search(ctx, 1, "кефир")))
+}
+
+func RunSubscriptions(t *testing.T, open Factory) {
+	ctx := context.Background()
+	repo := open(t).Subscriptions
+
+	mustNoErr(t, "Add", repo.Add(ctx, 2))
+	mustNoErr(t, "Add", repo.Add(ctx, 1))
+	mustNoErr(t, "Add", repo.Add(ctx, 1))
+
+	subs, err := repo.GetAll(ctx)
+	mustNoErr(t, "GetAll", err)
+	if len(subs) != 2 || subs[0].ChatID != 1 || subs[1].ChatID != 2 {
+		t.Fatalf("GetAll = %+v; want chats 1 and 2 once each", subs)
+	}
+	if subs[0].CreatedAt.IsZero() || !subs[0].LastDigestAt.IsZero() {
+		t.Fatalf("new subscription = %+v; want CreatedAt set and no digest yet", subs[0])
+	}
+
+	at := time.Date(2030, 5, 1, 8, 0, 0, 0, time.UTC)
+	mustNoErr(t, "MarkDelivered", repo.MarkDelivered(ctx, 1, at))
+	if subs, err := repo.GetAll(ctx); err != nil || !subs[0].LastDigestAt.Equal(at) {
+		t.Fatalf("LastDigestAt = %s, want %s", subs[0].LastDigestAt, at)
+	}
+
+	if ok, err := repo.Remove(ctx, 1); err != nil || !ok {
+		t.Fatalf("Remove(1) = %t, %v", ok, err)
+	}
+	if ok, err := repo.Remove(ctx, 1); err != nil || ok {
+		t.Fatalf("second Remove(1) = %t, %v; want false", ok, err)
+	}
+	if subs, err := repo.GetAll(ctx); err != nil || len(subs) != 1 || subs[0].ChatID != 2 {
+		t.Fatalf("GetAll after Remove = %+v", subs)
+	}
+}
+
+func RunPending(t *testing.T, open Factory) {
+	ctx := context.Background()
+	repo := open(t).Pending
+
+	if _, found, err := repo.GetById(ctx, 1); err != nil || found {
+		t.Fatalf("GetById on empty = %t, %v; want not found", found, err)
+	}
+
+	remind := 30
+	first := pending.Pending{
+		ChatID: 1,
+		Tasks: []task.Task{
+			{ChatID: 1, Task: "врач", DateTime: "2030-01-01T10:00:00+03:00", Location: "поликлиника", RemindBefore: &remind},
+			{ChatID: 1, Task: "йога", DateTime: "2030-01-02T19:00:00+03:00", Recurrence: "FREQ=WEEKLY"},
+		},
+		CreatedAt: time.Date(2030, 1, 1, 7, 0, 0, 0, time.UTC),
+	}
+	mustNoErr(t, "Upsert", repo.Upsert(ctx, first))
+
+	got, found, err := repo.GetById(ctx, 1)
+	if err != nil || !found {
+		t.Fatalf("GetById = %t, %v", found, err)
+	}
+	assertTasks(t, "GetById", got.Tasks, "врач", "йога")
+	if !got.CreatedAt.Equal(first.CreatedAt) || got.Tasks[0].RemindBefore == nil || *got.Tasks[0].RemindBefore != remind || got.Tasks[1].Recurrence != "FREQ=WEEKLY" {
+		t.Fatalf("GetById = %+v; want the draft as stored", got)
+	}
+
+	second := pending.Pending{ChatID: 1, Tasks: []task.Task{{ChatID: 1, Task: "кино"}}, CreatedAt: first.CreatedAt.Add(time.Minute)}
+	mustNoErr(t, "Upsert", repo.Upsert(ctx, second))
+	if got, _, err := repo.GetById(ctx, 1); err != nil || len(got.Tasks) != 1 || !got.CreatedAt.Equal(second.CreatedAt) {
+		t.Fatalf("GetById after second Upsert = %+v, %v; want the draft replaced", got, err)
+	}
+	if _, found, err := repo.GetById(ctx, 2); err != nil || found {
+		t.Fatalf("GetById(2) = %t, %v; want drafts isolated per chat", found, err)
+	}
+
+	if ok, err := repo.DeleteById(ctx, 1); err != nil || !ok {
+		t.Fatalf("DeleteById(1) = %t, %v", ok, err)
+	}
+	if ok, err := repo.DeleteById(ctx, 1); err != nil || ok {
+		t.Fatalf("second DeleteById(1) = %t, %v; want false", ok, err)
+	}
+}
+
+func assertTasks(t *testing.T, op string, got []task.Task, want ...string) {
+	t.Helper()
+
+	names := make([]string, 0, len(got))
+	for _, g := range got {
+		names = append(names, g.Task)
+	}
+	if len(names) != len(want) {
+		t.Fatalf("%s = %v, want %v", op, names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("%s = %v, want %v", op, names, want)
+		}
+	}
+}
+
+// tasksOf проверяет ошибку выборки задач: tasksOf(t, "GetAll")(repo.GetAll(chatID)).
+func tasksOf(t *testing.T, op string) func([]task.Task, error) []task.Task {
+	return func(tasks []task.Task, err error) []task.Task {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("%s: %v", op, err)
+		}
+		return tasks
+	}
+}
+
+func mustNoErr(t *testing.T, op string, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("%s: %v", op, err)
+	}
+}
@@ -0,0 +1,184 @@
+// Package store владеет единственным подключением к базе и выдаёт репозитории поверх него.
+package store
+
+import (
+	"adventBot/internal/db/chat"
+	chatmemory "adventBot/internal/db/chat/memory"
+	chatpostgres "adventBot/internal/db/chat/postgres"
+	chatsqlite "adventBot/internal/db/chat/sqlite"
+	"adventBot/internal/db/dbtx"
+	"adventBot/internal/db/memdb"
+	"adventBot/internal/db/message"
+	msgmemory "adventBot/internal/db/message/memory"
+	msgpostgres "adventBot/internal/db/message/postgres"
+	msgsqlite "adventBot/internal/db/message/sqlite"
+	"adventBot/internal/db/pending"
+	pendingmemory "adventBot/internal/db/pending/memory"
+	pendingpostgres "adventBot/internal/db/pending/postgres"
+	pendingsqlite "adventBot/internal/db/pending/sqlite"
+	"adventBot/internal/db/subscription"
+	submemory "adventBot/internal/db/subscription/memory"
+	subpostgres "adventBot/internal/db/subscription/postgres"
+	subsqlite "adventBot/internal/db/subscription/sqlite"
+	"adventBot/internal/db/task"
+	taskmemory "adventBot/internal/db/task/memory"
+	taskpostgres "adventBot/internal/db/task/postgres"
+	tasksqlite "adventBot/internal/db/task/sqlite"
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// Repositories — набор репозиториев, работающих через одно подключение или одну транзакцию.
+type Repositories struct {
+	Chats         chat.Repository
+	Messages      message.Repository
+	Tasks         task.Repository
+	Subscriptions subscription.Repository
+	Pending       pending.Repository
+}
+
+// Store — общий *sql.DB и репозитории над ним. Закрывается один раз через Close.
+// Хранилище из NewMemory держит данные в памяти процесса; DB у него nil.
+type Store struct {
+	Repositories
+
+	db       *sql.DB
+	driver   dbtx.Driver
+	mem      *memdb.DB
+	memRepos memoryRepositories
+	mu       sync.RWMutex
+	onCommit []func()
+}
+
+// New создаёт хранилище с реализациями репозиториев для driver.
+func New(db *sql.DB, driver dbtx.Driver) (*Store, error) {
+	repos, err := newRepositories(db, driver)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{
+		Repositories: repos,
+		db:           db,
+		driver:       driver,
+	}, nil
+}
+
+// NewMemory создаёт хранилище без базы данных: всё теряется при остановке процесса.
+// Подходит для тестов и эфемерного запуска с DB_PATH=:memory:.
+func NewMemory() *Store {
+	mem := memdb.New()
+	repos := memoryRepositories{
+		chats:         chatmemory.NewRepositoryMemory(mem),
+		messages:      msgmemory.NewRepositoryMemory(mem),
+		tasks:         taskmemory.NewRepositoryMemory(mem),
+		subscriptions: submemory.NewRepositoryMemory(mem),
+		pending:       pendingmemory.NewRepositoryMemory(mem),
+	}
+	return &Store{
+		Repositories: repos.bind(mem),
+		mem:          mem,
+		memRepos:     repos,
+	}
+}
+
+type memoryRepositories struct {
+	chats         *chatmemory.RepositoryMemory
+	messages      *msgmemory.RepositoryMemory
+	tasks         *taskmemory.RepositoryMemory
+	subscriptions *submemory.RepositoryMemory
+	pending       *pendingmemory.RepositoryMemory
+}
+
+func (m memoryRepositories) bind(tx *memdb.DB) Repositories {
+	return Repositories{
+		Chats:         m.chats.WithTx(tx),
+		Messages:      m.messages.WithTx(tx),
+		Tasks:         m.tasks.WithTx(tx),
+		Subscriptions: m.subscriptions.WithTx(tx),
+		Pending:       m.pending.WithTx(tx),
+	}
+}
+
+func newRepositories(q dbtx.DBTX, driver dbtx.Driver) (Repositories, error) {
+	switch driver {
+	case dbtx.SQLite:
+		return Repositories{
+			Chats:         chatsqlite.NewRepositorySQlite(q),
+			Messages:      msgsqlite.NewRepositorySQlite(q),
+			Tasks:         tasksqlite.NewRepositorySQlite(q),
+			Subscriptions: subsqlite.NewRepositorySQlite(q),
+			Pending:       pendingsqlite.NewRepositorySQlite(q),
+		}, nil
+	case dbtx.Postgres:
+		return Repositories{
+			Chats:         chatpostgres.NewRepositoryPostgres(q),
+			Messages:      msgpostgres.NewRepositoryPostgres(q),
+			Tasks:         taskpostgres.NewRepositoryPostgres(q),
+			Subscriptions: subpostgres.NewRepositoryPostgres(q),
+			Pending:       pendingpostgres.NewRepositoryPostgres(q),
+		}, nil
+	default:
+		return Repositories{}, fmt.Errorf("unsupported driver %q", driver)
+	}
+}
+
+// DB возвращает подключение, например для миграций.
+func (s *Store) DB() *sql.DB {
+	return s.db
+}
+
+// Driver возвращает драйвер, под который созданы репозитории.
+func (s *Store) Driver() dbtx.Driver {
+	return s.driver
+}
+
+// OnCommit регистрирует fn, которая вызывается после каждой успешной транзакции WithTx.
+func (s *Store) OnCommit(fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.onCommit = append(s.onCommit, fn)
+}
+
+// WithTx выполняет fn с репозиториями, привязанными к одной транзакции: при ошибке fn
+// все изменения откатываются, иначе фиксируются вместе.
+func (s *Store) WithTx(ctx context.Context, fn func(tx Repositories) error) error {
+	if s.mem != nil {
+		return s.commit(s.mem.InTx(func(tx *memdb.DB) error {
+			return fn(s.memRepos.bind(tx))
+		}))
+	}
+
+	return s.commit(dbtx.InTx(ctx, s.db, func(q dbtx.DBTX) error {
+		repos, err := newRepositories(q, s.driver)
+		if err != nil {
+			return err
+		}
+		return fn(repos)
+	}))
+}
+
+// commit запускает хуки OnCommit, если транзакция завершилась без ошибки.
+func (s *Store) commit(err error) error {
+	if err != nil {
+		return err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, f := range s.onCommit {
+		f()
+	}
+	return nil
+}
+
+func (s *Store) Close() error {
+	if s.db == nil {
+		return nil
+	}
+	log.Println("[Store.Close] closing db connection")
+	return s.db.Close()
+}
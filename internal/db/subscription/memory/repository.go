@@ -0,0 +1,81 @@
+package memory
+
+import (
+	"adventBot/internal/db/memdb"
+	"adventBot/internal/db/subscription"
+	"context"
+	"log"
+	"maps"
+	"slices"
+	"time"
+)
+
+type table struct {
+	subs map[int64]subscription.Subscription
+}
+
+func (t *table) Snapshot() func() {
+	saved := maps.Clone(t.subs)
+	return func() { t.subs = saved }
+}
+
+type RepositoryMemory struct {
+	db *memdb.DB
+	t  *table
+}
+
+func NewRepositoryMemory(db *memdb.DB) *RepositoryMemory {
+	t := &table{subs: make(map[int64]subscription.Subscription)}
+	db.Register(t)
+	return &RepositoryMemory{db: db, t: t}
+}
+
+// WithTx возвращает репозиторий над теми же данными, работающий внутри транзакции tx.
+func (r *RepositoryMemory) WithTx(tx *memdb.DB) *RepositoryMemory {
+	return &RepositoryMemory{db: tx, t: r.t}
+}
+
+func (r *RepositoryMemory) Add(ctx context.Context, chatID int64) error {
+	r.db.Write(func() {
+		if _, ok := r.t.subs[chatID]; !ok {
+			r.t.subs[chatID] = subscription.Subscription{ChatID: chatID, CreatedAt: now()}
+		}
+	})
+	log.Printf("[subscription/RepositoryMemory.Add] success chatID=%d", chatID)
+	return nil
+}
+
+func (r *RepositoryMemory) Remove(ctx context.Context, chatID int64) (bool, error) {
+	var found bool
+	r.db.Write(func() {
+		_, found = r.t.subs[chatID]
+		delete(r.t.subs, chatID)
+	})
+	log.Printf("[subscription/RepositoryMemory.Remove] chatID=%d deleted=%t", chatID, found)
+	return found, nil
+}
+
+func (r *RepositoryMemory) GetAll(ctx context.Context) ([]subscription.Subscription, error) {
+	var subs []subscription.Subscription
+	r.db.Read(func() {
+		for _, chatID := range slices.Sorted(maps.Keys(r.t.subs)) {
+			subs = append(subs, r.t.subs[chatID])
+		}
+	})
+	return subs, nil
+}
+
+func (r *RepositoryMemory) MarkDelivered(ctx context.Context, chatID int64, at time.Time) error {
+	r.db.Write(func() {
+		if s, ok := r.t.subs[chatID]; ok {
+			s.LastDigestAt = at.UTC().Truncate(time.Second)
+			r.t.subs[chatID] = s
+		}
+	})
+	return nil
+}
+
+// now — текущее время с точностью, с которой его хранят SQL-репозитории.
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Second)
+}
@@ -0,0 +1,10 @@
+package subscription
+
+import "time"
+
+// Subscription — подписка чата на ежедневную сводку задач.
+type Subscription struct {
+	ChatID       int64
+	CreatedAt    time.Time
+	LastDigestAt time.Time // нулевое значение — сводка ещё не отправлялась
+}
@@ -0,0 +1,42 @@
+package postgres
+
+import "fmt"
+
+const (
+	tableName       = "subscriptions"
+	colChatId       = "chat_id"
+	colCreatedAt    = "created_at"
+	colLastDigestAt = "last_digest_at"
+)
+
+var insert = fmt.Sprintf(`
+INSERT INTO %s (%s, %s)
+VALUES ($1, $2)
+ON CONFLICT(%s) DO NOTHING;`,
+	tableName,
+	colChatId, colCreatedAt,
+	colChatId,
+)
+
+var selectAll = fmt.Sprintf(`
+SELECT %s, %s, %s
+FROM %s
+ORDER BY %s;`,
+	colChatId, colCreatedAt, colLastDigestAt,
+	tableName,
+	colChatId,
+)
+
+var updateLastDigest = fmt.Sprintf(`
+UPDATE %s SET %s = $1
+WHERE %s = $2;`,
+	tableName, colLastDigestAt,
+	colChatId,
+)
+
+var deleteByChatId = fmt.Sprintf(`
+DELETE FROM %s
+WHERE %s = $1;`,
+	tableName,
+	colChatId,
+)
@@ -0,0 +1,106 @@
+package postgres
+
+import (
+	"adventBot/internal/db/dbtx"
+	"adventBot/internal/db/subscription"
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+type RepositoryPostgres struct {
+	db dbtx.DBTX
+}
+
+func NewRepositoryPostgres(db dbtx.DBTX) *RepositoryPostgres {
+	return &RepositoryPostgres{db: db}
+}
+
+func (r *RepositoryPostgres) Add(ctx context.Context, chatID int64) error {
+	_, err := r.db.ExecContext(ctx, insert, chatID, formatTime(time.Now()))
+	if err != nil {
+		log.Printf("[subscription/RepositoryPostgres.Add] chatID=%d err=%v", chatID, err)
+		return err
+	}
+	log.Printf("[subscription/RepositoryPostgres.Add] success chatID=%d", chatID)
+	return nil
+}
+
+func (r *RepositoryPostgres) Remove(ctx context.Context, chatID int64) (bool, error) {
+	res, err := r.db.ExecContext(ctx, deleteByChatId, chatID)
+	if err != nil {
+		log.Printf("[subscription/RepositoryPostgres.Remove] chatID=%d error=%v", chatID, err)
+		return false, err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		log.Printf("[subscription/RepositoryPostgres.Remove] chatID=%d error getting RowsAffected=%v", chatID, err)
+		return false, err
+	}
+
+	log.Printf("[subscription/RepositoryPostgres.Remove] chatID=%d deleted=%t", chatID, rows > 0)
+	return rows > 0, nil
+}
+
+func (r *RepositoryPostgres) GetAll(ctx context.Context) ([]subscription.Subscription, error) {
+	rows, err := r.db.QueryContext(ctx, selectAll)
+	if err != nil {
+		log.Printf("[subscription/RepositoryPostgres.GetAll] selectAll err=%v", err)
+		return nil, fmt.Errorf("select subscriptions: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Println("[subscription/RepositoryPostgres.GetAll] failed to close rows:", err)
+		}
+	}(rows)
+
+	var subs []subscription.Subscription
+	for rows.Next() {
+		var s Subscription
+		if err := rows.Scan(&s.ChatID, &s.CreatedAt, &s.LastDigestAt); err != nil {
+			log.Printf("[subscription/RepositoryPostgres.GetAll] failed to scan rows:%v", err)
+			return nil, fmt.Errorf("scan subscription row: %w", err)
+		}
+
+		sub := subscription.Subscription{
+			ChatID:    s.ChatID,
+			CreatedAt: parseTime(s.CreatedAt),
+		}
+		if s.LastDigestAt.Valid {
+			sub.LastDigestAt = parseTime(s.LastDigestAt.String)
+		}
+		subs = append(subs, sub)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("[subscription/RepositoryPostgres.GetAll] failed to scan rows:%v", err)
+		return nil, fmt.Errorf("iterate rows: %w", err)
+	}
+
+	return subs, nil
+}
+
+func (r *RepositoryPostgres) MarkDelivered(ctx context.Context, chatID int64, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, updateLastDigest, formatTime(at), chatID)
+	if err != nil {
+		log.Printf("[subscription/RepositoryPostgres.MarkDelivered] chatID=%d err=%v", chatID, err)
+		return err
+	}
+	return nil
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+func parseTime(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
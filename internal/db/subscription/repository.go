@@ -0,0 +1,13 @@
+package subscription
+
+import (
+	"context"
+	"time"
+)
+
+type Repository interface {
+	Add(ctx context.Context, chatID int64) error
+	Remove(ctx context.Context, chatID int64) (bool, error)
+	GetAll(ctx context.Context) ([]Subscription, error)
+	MarkDelivered(ctx context.Context, chatID int64, at time.Time) error
+}
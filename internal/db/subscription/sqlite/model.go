@@ -0,0 +1,9 @@
+package sqlite
+
+import "database/sql"
+
+type Subscription struct {
+	ChatID       int64          `db:"chat_id"`
+	CreatedAt    string         `db:"created_at"`
+	LastDigestAt sql.NullString `db:"last_digest_at"`
+}
@@ -0,0 +1,42 @@
+package sqlite
+
+import "fmt"
+
+const (
+	tableName       = "subscriptions"
+	colChatId       = "chat_id"
+	colCreatedAt    = "created_at"
+	colLastDigestAt = "last_digest_at"
+)
+
+var insert = fmt.Sprintf(`
+INSERT INTO %s (%s, %s)
+VALUES (?, ?)
+ON CONFLICT(%s) DO NOTHING;`,
+	tableName,
+	colChatId, colCreatedAt,
+	colChatId,
+)
+
+var selectAll = fmt.Sprintf(`
+SELECT %s, %s, %s
+FROM %s
+ORDER BY %s;`,
+	colChatId, colCreatedAt, colLastDigestAt,
+	tableName,
+	colChatId,
+)
+
+var updateLastDigest = fmt.Sprintf(`
+UPDATE %s SET %s = ?
+WHERE %s = ?;`,
+	tableName, colLastDigestAt,
+	colChatId,
+)
+
+var deleteByChatId = fmt.Sprintf(`
+DELETE FROM %s
+WHERE %s = ?;`,
+	tableName,
+	colChatId,
+)
@@ -0,0 +1,310 @@
+package memory
+
+import (
+	"adventBot/internal/db/memdb"
+	"adventBot/internal/db/task"
+	"context"
+	"log"
+	"maps"
+	"slices"
+	"time"
+)
+
+type table struct {
+	tasks map[int64]task.Task
+	// occurrences — состояния повторений по ID задачи и Unix-времени повторения
+	occurrences map[int64]map[int64]task.Occurrence
+	nextID      int64
+}
+
+func (t *table) Snapshot() func() {
+	tasks := maps.Clone(t.tasks)
+	occurrences := make(map[int64]map[int64]task.Occurrence, len(t.occurrences))
+	for id, marks := range t.occurrences {
+		occurrences[id] = maps.Clone(marks)
+	}
+	nextID := t.nextID
+	return func() {
+		t.tasks, t.occurrences, t.nextID = tasks, occurrences, nextID
+	}
+}
+
+type RepositoryMemory struct {
+	db *memdb.DB
+	t  *table
+}
+
+func NewRepositoryMemory(db *memdb.DB) *RepositoryMemory {
+	t := &table{
+		tasks:       make(map[int64]task.Task),
+		occurrences: make(map[int64]map[int64]task.Occurrence),
+		nextID:      1,
+	}
+	db.Register(t)
+	return &RepositoryMemory{db: db, t: t}
+}
+
+// WithTx возвращает репозиторий над теми же данными, работающий внутри транзакции tx.
+func (r *RepositoryMemory) WithTx(tx *memdb.DB) *RepositoryMemory {
+	return &RepositoryMemory{db: tx, t: r.t}
+}
+
+func (r *RepositoryMemory) ListRange(ctx context.Context, chatID int64, from time.Time, to time.Time) ([]task.Task, error) {
+	var tasks []task.Task
+	r.db.Read(func() {
+		tasks = r.selectOpen(func(t task.Task) bool {
+			due, err := t.Due()
+			return t.ChatID == chatID && !t.IsRecurring() && err == nil && !due.Before(from) && due.Before(to)
+		})
+		r.eachRecurring("ListRange", func(t task.Task) bool { return t.ChatID == chatID }, func(t task.Task, marks map[int64]task.Occurrence) error {
+			occ, err := task.Expand(t, t.Zone(), marks, from, to, 0)
+			tasks = append(tasks, occ...)
+			return err
+		})
+	})
+
+	task.SortByDateTime(tasks)
+	return tasks, nil
+}
+
+func (r *RepositoryMemory) GetAll(ctx context.Context, chatID int64) ([]task.Task, error) {
+	var tasks []task.Task
+	now := time.Now()
+	r.db.Read(func() {
+		tasks = r.selectOpen(func(t task.Task) bool {
+			return t.ChatID == chatID && !t.IsRecurring()
+		})
+		r.eachRecurring("GetAll", func(t task.Task) bool { return t.ChatID == chatID }, func(t task.Task, marks map[int64]task.Occurrence) error {
+			occ, ok, err := task.NextOpen(t, t.Zone(), marks, now)
+			if ok {
+				tasks = append(tasks, occ)
+			}
+			return err
+		})
+	})
+
+	task.SortByDateTime(tasks)
+	return tasks, nil
+}
+
+func (r *RepositoryMemory) Search(ctx context.Context, chatID int64, query string) ([]task.Task, error) {
+	terms := task.SearchTerms(query)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	tasks, err := r.GetAll(ctx, chatID)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(tasks, func(t task.Task) bool { return !t.Matches(terms) }), nil
+}
+
+func (r *RepositoryMemory) GetByID(ctx context.Context, chatID int64, id int64) (task.Task, bool, error) {
+	var (
+		t     task.Task
+		found bool
+	)
+	r.db.Read(func() {
+		t, found = r.t.tasks[id]
+		found = found && t.ChatID == chatID
+	})
+	if !found {
+		return task.Task{}, false, nil
+	}
+	return clone(t), true, nil
+}
+
+func (r *RepositoryMemory) Create(ctx context.Context, t task.Task) (int64, error) {
+	var id int64
+	r.db.Write(func() {
+		id = r.insert(t)
+	})
+	log.Printf("[task/RepositoryMemory.Create] created id=%d task=%v", id, t)
+	return id, nil
+}
+
+func (r *RepositoryMemory) CreateAll(ctx context.Context, tasks []task.Task) ([]int64, error) {
+	ids := make([]int64, 0, len(tasks))
+	r.db.Write(func() {
+		for _, t := range tasks {
+			ids = append(ids, r.insert(t))
+		}
+	})
+	log.Printf("[task/RepositoryMemory.CreateAll] created ids=%v", ids)
+	return ids, nil
+}
+
+func (r *RepositoryMemory) insert(t task.Task) int64 {
+	if t.Status == "" {
+		t.Status = task.StatusOpen
+	}
+	t.ID = r.t.nextID
+	r.t.nextID++
+	t.CreatedAt, t.UpdatedAt = now(), now()
+	t.RemindedAt = time.Time{}
+
+	r.t.tasks[t.ID] = clone(t)
+	return t.ID
+}
+
+func (r *RepositoryMemory) Update(ctx context.Context, t task.Task) (bool, error) {
+	return r.update("Update", t.ChatID, t.ID, func(stored *task.Task) {
+		stored.Task = t.Task
+		stored.Location = t.Location
+		stored.DateTime = t.DateTime
+		stored.RemindBefore = clone(t).RemindBefore
+		stored.Recurrence = t.Recurrence
+		stored.RemindedAt = time.Time{}
+		stored.UpdatedAt = now()
+	})
+}
+
+func (r *RepositoryMemory) Complete(ctx context.Context, chatID int64, id int64) (bool, error) {
+	return r.update("Complete", chatID, id, func(stored *task.Task) {
+		stored.Status, stored.UpdatedAt = task.StatusDone, now()
+	})
+}
+
+func (r *RepositoryMemory) Cancel(ctx context.Context, chatID int64, id int64) (bool, error) {
+	return r.update("Cancel", chatID, id, func(stored *task.Task) {
+		stored.Status, stored.UpdatedAt = task.StatusCancelled, now()
+	})
+}
+
+func (r *RepositoryMemory) Delete(ctx context.Context, chatID int64, id int64) (bool, error) {
+	var found bool
+	r.db.Write(func() {
+		var t task.Task
+		if t, found = r.t.tasks[id]; found && t.ChatID == chatID {
+			delete(r.t.tasks, id)
+			delete(r.t.occurrences, id)
+		} else {
+			found = false
+		}
+	})
+	log.Printf("[task/RepositoryMemory.Delete] chatID=%d id=%d deleted=%t", chatID, id, found)
+	return found, nil
+}
+
+func (r *RepositoryMemory) ListPendingReminders(ctx context.Context) ([]task.Task, error) {
+	var tasks []task.Task
+	now := time.Now()
+	r.db.Read(func() {
+		tasks = r.selectOpen(func(t task.Task) bool {
+			return !t.IsRecurring() && t.RemindedAt.IsZero()
+		})
+		r.eachRecurring("ListPendingReminders", func(task.Task) bool { return true }, func(t task.Task, marks map[int64]task.Occurrence) error {
+			occ, ok, err := task.NextUnreminded(t, t.Zone(), marks, now)
+			if ok {
+				tasks = append(tasks, occ)
+			}
+			return err
+		})
+	})
+
+	task.SortByDateTime(tasks)
+	return tasks, nil
+}
+
+func (r *RepositoryMemory) MarkReminded(ctx context.Context, chatID int64, id int64) (bool, error) {
+	return r.update("MarkReminded", chatID, id, func(stored *task.Task) {
+		stored.RemindedAt = now()
+	})
+}
+
+func (r *RepositoryMemory) SetOccurrenceStatus(ctx context.Context, chatID int64, id int64, at time.Time, status task.Status) (bool, error) {
+	return r.mark("SetOccurrenceStatus", chatID, id, at, func(o *task.Occurrence) {
+		o.Status = status
+	})
+}
+
+func (r *RepositoryMemory) MarkOccurrenceReminded(ctx context.Context, chatID int64, id int64, at time.Time) (bool, error) {
+	return r.mark("MarkOccurrenceReminded", chatID, id, at, func(o *task.Occurrence) {
+		o.RemindedAt = now()
+	})
+}
+
+// update применяет fn к задаче чата; false — такой задачи в чате нет.
+func (r *RepositoryMemory) update(op string, chatID int64, id int64, fn func(stored *task.Task)) (bool, error) {
+	var found bool
+	r.db.Write(func() {
+		t, ok := r.t.tasks[id]
+		if !ok || t.ChatID != chatID {
+			return
+		}
+		fn(&t)
+		r.t.tasks[id] = t
+		found = true
+	})
+	log.Printf("[task/RepositoryMemory.%s] chatID=%d id=%d updated=%t", op, chatID, id, found)
+	return found, nil
+}
+
+// mark меняет состояние повторения; как и в SQL-реализациях, только у регулярной задачи этого чата.
+func (r *RepositoryMemory) mark(op string, chatID int64, id int64, at time.Time, fn func(o *task.Occurrence)) (bool, error) {
+	var found bool
+	r.db.Write(func() {
+		t, ok := r.t.tasks[id]
+		if !ok || t.ChatID != chatID || !t.IsRecurring() {
+			return
+		}
+
+		marks := r.t.occurrences[id]
+		if marks == nil {
+			marks = make(map[int64]task.Occurrence)
+			r.t.occurrences[id] = marks
+		}
+		at = at.UTC().Truncate(time.Second)
+		o, ok := marks[at.Unix()]
+		if !ok {
+			o = task.Occurrence{TaskID: id, At: at, Status: task.StatusOpen}
+		}
+		fn(&o)
+		marks[at.Unix()] = o
+		found = true
+	})
+	log.Printf("[task/RepositoryMemory.%s] chatID=%d id=%d at=%s updated=%t", op, chatID, id, at.Format(time.RFC3339), found)
+	return found, nil
+}
+
+// selectOpen возвращает копии открытых задач, подходящих под filter, по времени.
+// Вызывается под блокировкой.
+func (r *RepositoryMemory) selectOpen(filter func(task.Task) bool) []task.Task {
+	var tasks []task.Task
+	for _, t := range r.t.tasks {
+		if t.Status == task.StatusOpen && filter(t) {
+			tasks = append(tasks, clone(t))
+		}
+	}
+	slices.SortFunc(tasks, func(a, b task.Task) int {
+		return int(a.ID - b.ID)
+	})
+	task.SortByDateTime(tasks)
+	return tasks
+}
+
+// eachRecurring вызывает fn для каждой открытой регулярной задачи, подходящей под filter,
+// вместе с состояниями её повторений. Задачи с некорректным правилом или датой пропускаются
+// с записью в лог. Вызывается под блокировкой.
+func (r *RepositoryMemory) eachRecurring(op string, filter func(task.Task) bool, fn func(task.Task, map[int64]task.Occurrence) error) {
+	for _, t := range r.selectOpen(func(t task.Task) bool { return t.IsRecurring() && filter(t) }) {
+		if err := fn(t, maps.Clone(r.t.occurrences[t.ID])); err != nil {
+			log.Printf("[task/RepositoryMemory.%s] skip recurring task id=%d: %v", op, t.ID, err)
+		}
+	}
+}
+
+// clone копирует задачу вместе с RemindBefore, чтобы вызывающий не мог изменить хранимое значение.
+func clone(t task.Task) task.Task {
+	if t.RemindBefore != nil {
+		minutes := *t.RemindBefore
+		t.RemindBefore = &minutes
+	}
+	return t
+}
+
+// now — текущее время с точностью, с которой его хранят SQL-репозитории.
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Second)
+}
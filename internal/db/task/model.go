@@ -1,8 +1,62 @@
 package task
 
+import "time"
+
+type Status string
+
+const (
+	StatusOpen      Status = "open"
+	StatusDone      Status = "done"
+	StatusCancelled Status = "cancelled"
+)
+
 type Task struct {
-	ChatID   int64  `json:"chat_id"`
-	Task     string `json:"task"`
-	Location string `json:"location"`
-	DateTime string `json:"dateTime"`
+	ID        int64     `json:"id"`
+	ChatID    int64     `json:"chat_id"`
+	Task      string    `json:"task"`
+	Location  string    `json:"location"`
+	DateTime  string    `json:"dateTime"`
+	Status    Status    `json:"status"`
+	CreatedAt time.Time `json:"-"`
+	UpdatedAt time.Time `json:"-"`
+
+	RemindBefore *int      `json:"remindBefore,omitempty"` // nil — использовать настройку чата
+	RemindedAt   time.Time `json:"-"`                      // нулевое значение — напоминание ещё не отправлено
+
+	// Recurrence — правило повторения в формате RRULE; пусто — разовая задача.
+	// У регулярной задачи DateTime хранит первое наступление, а в выборках — время конкретного повторения.
+	Recurrence string `json:"recurrence,omitempty"`
+	// TimeZone — IANA-зона чата на момент создания задачи; в ней повторения сохраняют время суток при переходе
+	// на летнее время. Пусто — у задач, созданных до появления колонки в чатах без часового пояса.
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+// Due — момент задачи (у регулярной — первого наступления) из DateTime в формате RFC 3339.
+func (t Task) Due() (time.Time, error) {
+	return time.Parse(time.RFC3339, t.DateTime)
+}
+
+// Zone — часовой пояс задачи из TimeZone; nil, если он не задан или неизвестен.
+func (t Task) Zone() *time.Location {
+	if t.TimeZone == "" {
+		return nil
+	}
+	loc, err := time.LoadLocation(t.TimeZone)
+	if err != nil {
+		return nil
+	}
+	return loc
+}
+
+// IsRecurring сообщает, что задача повторяется по правилу Recurrence.
+func (t Task) IsRecurring() bool {
+	return t.Recurrence != ""
+}
+
+// Occurrence — состояние одного повторения регулярной задачи. Повторения без записи считаются открытыми.
+type Occurrence struct {
+	TaskID     int64
+	At         time.Time
+	Status     Status
+	RemindedAt time.Time
 }
@@ -0,0 +1,89 @@
+package task
+
+import (
+	"adventBot/internal/recurrence"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ExpandHorizon — как далеко вперёд ищутся повторения для списка задач и напоминаний.
+const ExpandHorizon = 366 * 24 * time.Hour
+
+// Expand раскрывает регулярную задачу в открытые повторения из полуинтервала [from, to).
+// marks — сохранённые состояния повторений по Unix-времени; limit > 0 ограничивает число результатов.
+// loc — часовой пояс, в котором повторения сохраняют время суток; nil — смещение первого наступления,
+// из-за которого после перехода на летнее время повторения сдвигаются на час.
+// У каждого повторения DateTime — время повторения в зоне loc.
+func Expand(t Task, loc *time.Location, marks map[int64]Occurrence, from time.Time, to time.Time, limit int) ([]Task, error) {
+	rule, err := recurrence.Parse(t.Recurrence)
+	if err != nil {
+		return nil, fmt.Errorf("task id=%d: %w", t.ID, err)
+	}
+	start, err := time.Parse(time.RFC3339, t.DateTime)
+	if err != nil {
+		return nil, fmt.Errorf("task id=%d: invalid dateTime %q: %w", t.ID, t.DateTime, err)
+	}
+	if loc != nil {
+		start = start.In(loc)
+	}
+
+	var res []Task
+	rule.Each(start, func(at time.Time) bool {
+		if !at.Before(to) {
+			return false
+		}
+		if at.Before(from) {
+			return true
+		}
+
+		occ := t
+		occ.DateTime = at.Format(time.RFC3339)
+		occ.RemindedAt = time.Time{}
+		if m, ok := marks[at.Unix()]; ok {
+			if m.Status != StatusOpen {
+				return true
+			}
+			occ.RemindedAt = m.RemindedAt
+		}
+		res = append(res, occ)
+		return limit <= 0 || len(res) < limit
+	})
+
+	return res, nil
+}
+
+// SortByDateTime упорядочивает задачи по моменту наступления; DateTime разных задач может быть в разных зонах.
+func SortByDateTime(tasks []Task) {
+	sort.SliceStable(tasks, func(i, j int) bool {
+		a, errA := time.Parse(time.RFC3339, tasks[i].DateTime)
+		b, errB := time.Parse(time.RFC3339, tasks[j].DateTime)
+		if errA != nil || errB != nil {
+			return tasks[i].DateTime < tasks[j].DateTime
+		}
+		return a.Before(b)
+	})
+}
+
+// NextOpen возвращает ближайшее открытое повторение не раньше now.
+func NextOpen(t Task, loc *time.Location, marks map[int64]Occurrence, now time.Time) (Task, bool, error) {
+	occ, err := Expand(t, loc, marks, now, now.Add(ExpandHorizon), 1)
+	if err != nil || len(occ) == 0 {
+		return Task{}, false, err
+	}
+	return occ[0], true, nil
+}
+
+// NextUnreminded возвращает ближайшее открытое повторение после now, о котором ещё не напоминали.
+func NextUnreminded(t Task, loc *time.Location, marks map[int64]Occurrence, now time.Time) (Task, bool, error) {
+	occ, err := Expand(t, loc, marks, now, now.Add(ExpandHorizon), 0)
+	if err != nil {
+		return Task{}, false, err
+	}
+	for _, o := range occ {
+		if o.RemindedAt.IsZero() {
+			return o, true, nil
+		}
+	}
+	return Task{}, false, nil
+}
@@ -0,0 +1,50 @@
+package task
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExpandInChatZoneAcrossDST(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("no tzdata:", err)
+	}
+	series := Task{ID: 1, Task: "Зарядка", DateTime: "2026-03-27T09:00:00+01:00", Recurrence: "FREQ=DAILY", TimeZone: "Europe/Berlin"}
+	from := time.Date(2026, time.March, 27, 0, 0, 0, 0, berlin)
+	to := from.AddDate(0, 0, 4)
+
+	occ, err := Expand(series, series.Zone(), nil, from, to, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"2026-03-27T09:00:00+01:00", "2026-03-28T09:00:00+01:00", "2026-03-29T09:00:00+02:00", "2026-03-30T09:00:00+02:00"}
+	if len(occ) != len(want) {
+		t.Fatalf("got %d occurrences, want %d", len(occ), len(want))
+	}
+	for i := range want {
+		if occ[i].DateTime != want[i] {
+			t.Errorf("occurrence %d = %s, want %s", i, occ[i].DateTime, want[i])
+		}
+	}
+
+	// Без зоны остаётся смещение первого наступления, и после перехода время по Берлину сдвигается
+	occ, err = Expand(series, nil, nil, from, to, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last, _ := occ[len(occ)-1].Due(); last.In(berlin).Hour() != 10 {
+		t.Errorf("without zone last occurrence = %s", occ[len(occ)-1].DateTime)
+	}
+}
+
+func TestZone(t *testing.T) {
+	if loc := (Task{TimeZone: "Europe/Moscow"}).Zone(); loc == nil || loc.String() != "Europe/Moscow" {
+		t.Errorf("Zone = %v", loc)
+	}
+	for _, tz := range []string{"", "Mars/Olympus"} {
+		if loc := (Task{TimeZone: tz}).Zone(); loc != nil {
+			t.Errorf("Zone(%q) = %v, want nil", tz, loc)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package postgres
+
+import "database/sql"
+
+type Task struct {
+	ID        int64  `db:"id"`
+	ChatID    int64  `db:"chat_id"`
+	Task      string `db:"task"`
+	Location  string `db:"location"`
+	DateTime  string `db:"date_time"`
+	Status    string `db:"status"`
+	CreatedAt string `db:"created_at"`
+	UpdatedAt string `db:"updated_at"`
+
+	RemindBefore sql.NullInt64  `db:"remind_before"`
+	RemindedAt   sql.NullString `db:"reminded_at"`
+	Rrule        string         `db:"rrule"`
+	TimeZone     string         `db:"time_zone"`
+}
+
+type Occurrence struct {
+	TaskID     int64          `db:"task_id"`
+	OccursAt   string         `db:"occurs_at"`
+	Status     string         `db:"status"`
+	RemindedAt sql.NullString `db:"reminded_at"`
+}
@@ -0,0 +1,123 @@
+package postgres
+
+import (
+	"fmt"
+	"strings"
+)
+
+const taskColumns = `id, chat_id, task, location, date_time, status, created_at, updated_at, remind_before, reminded_at, rrule, time_zone`
+
+const listRangeQuery = `
+SELECT ` + taskColumns + `
+FROM tasks
+WHERE chat_id = $1 AND status = 'open' AND rrule = '' AND due_at >= $2 AND due_at < $3
+ORDER BY due_at;
+`
+
+const getTasksQuery = `
+SELECT ` + taskColumns + `
+FROM tasks
+WHERE chat_id = $1 AND status = 'open' AND rrule = ''
+ORDER BY due_at;
+`
+
+const getRecurringTasksQuery = `
+SELECT ` + taskColumns + `
+FROM tasks
+WHERE chat_id = $1 AND status = 'open' AND rrule != ''
+ORDER BY due_at;
+`
+
+const listRecurringTasksQuery = `
+SELECT ` + taskColumns + `
+FROM tasks
+WHERE status = 'open' AND rrule != ''
+ORDER BY due_at;
+`
+
+const getByIdQuery = `
+SELECT ` + taskColumns + `
+FROM tasks
+WHERE chat_id = $1 AND id = $2;
+`
+
+const insertQuery = `
+INSERT INTO tasks (chat_id, task, location, date_time, due_at, status, created_at, updated_at, remind_before, rrule, time_zone)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+RETURNING id;
+`
+
+const updateQuery = `
+UPDATE tasks
+SET task = $1, location = $2, date_time = $3, due_at = $4, remind_before = $5, rrule = $6, reminded_at = NULL, updated_at = $7
+WHERE chat_id = $8 AND id = $9;
+`
+
+const setStatusQuery = `
+UPDATE tasks
+SET status = $1, updated_at = $2
+WHERE chat_id = $3 AND id = $4;
+`
+
+const deleteQuery = `
+DELETE FROM tasks
+WHERE chat_id = $1 AND id = $2;
+`
+
+const listPendingRemindersQuery = `
+SELECT ` + taskColumns + `
+FROM tasks
+WHERE status = 'open' AND rrule = '' AND reminded_at IS NULL
+ORDER BY due_at;
+`
+
+const markRemindedQuery = `
+UPDATE tasks
+SET reminded_at = $1
+WHERE chat_id = $2 AND id = $3;
+`
+
+const getOccurrencesQuery = `
+SELECT task_id, occurs_at, status, reminded_at
+FROM task_occurrences
+WHERE task_id = $1;
+`
+
+// Повторение можно отметить только у регулярной задачи этого чата.
+const setOccurrenceStatusQuery = `
+INSERT INTO task_occurrences (task_id, occurs_at, status)
+SELECT id, $1::text, $2::text FROM tasks WHERE chat_id = $3 AND id = $4 AND rrule != ''
+ON CONFLICT (task_id, occurs_at) DO UPDATE SET status = excluded.status;
+`
+
+const markOccurrenceRemindedQuery = `
+INSERT INTO task_occurrences (task_id, occurs_at, reminded_at)
+SELECT id, $1::text, $2::text FROM tasks WHERE chat_id = $3 AND id = $4 AND rrule != ''
+ON CONFLICT (task_id, occurs_at) DO UPDATE SET reminded_at = excluded.reminded_at;
+`
+
+const deleteOccurrencesQuery = `
+DELETE FROM task_occurrences
+WHERE task_id IN (SELECT id FROM tasks WHERE chat_id = $1 AND id = $2);
+`
+
+// searchQuery — выборка открытых задач чата ($1), у которых каждое из terms слов (образцы $2, $3, ...)
+// есть в тексте или месте. recurring выбирает регулярные задачи вместо разовых.
+func searchQuery(recurring bool, terms int) string {
+	rrule := "rrule = ''"
+	if recurring {
+		rrule = "rrule != ''"
+	}
+
+	var match strings.Builder
+	for i := range terms {
+		fmt.Fprintf(&match, " AND (task ILIKE $%[1]d ESCAPE '\\' OR location ILIKE $%[1]d ESCAPE '\\')", i+2)
+	}
+
+	return `
+SELECT ` + taskColumns + `
+FROM tasks
+WHERE chat_id = $1 AND status = 'open' AND ` + rrule + match.String() + `
+ORDER BY due_at;
+`
+}
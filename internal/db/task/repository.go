@@ -1,12 +1,39 @@
 package task
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
+// Repository хранит задачи чатов. ListRange и GetAll возвращают только открытые задачи;
+// методы, меняющие задачу по ID, возвращают false, если в чате такой задачи нет.
+// ListPendingReminders возвращает открытые задачи всех чатов, по которым ещё не было напоминания;
+// Update сбрасывает отметку о напоминании. ListRange сравнивает момент задачи в UTC, поэтому
+// задачи, сохранённые с разными смещениями, попадают в диапазон по фактическому времени.
+//
+// Регулярные задачи (Recurrence != "") в выборках раскрываются через Expand: ListRange возвращает
+// их повторения из диапазона, GetAll и ListPendingReminders — ближайшее подходящее повторение.
+// Завершение и напоминание отдельного повторения хранятся через SetOccurrenceStatus и
+// MarkOccurrenceReminded; Complete, Cancel и Delete действуют на всю серию.
+//
+// Search ищет открытые задачи, в тексте или месте которых есть все слова запроса (см. SearchTerms),
+// и возвращает их, как GetAll. SQLite со сборкой FTS5 ищет по полнотекстовому индексу с совпадением
+// по началу слова, остальные реализации — по вхождению подстроки без учёта регистра.
 type Repository interface {
-	Init() error
-	GetToday(chatID int64, dateTime string) ([]Task, error)
-	GetAll(chatID int64) ([]Task, error)
-	Upsert(ctx context.Context, task Task) error
-	Delete(ctx context.Context, task Task) error
-	CloseConnection() error
+	// ListRange возвращает задачи и повторения с моментом в полуинтервале [from, to), по времени.
+	ListRange(ctx context.Context, chatID int64, from time.Time, to time.Time) ([]Task, error)
+	GetAll(ctx context.Context, chatID int64) ([]Task, error)
+	Search(ctx context.Context, chatID int64, query string) ([]Task, error)
+	GetByID(ctx context.Context, chatID int64, id int64) (Task, bool, error)
+	Create(ctx context.Context, task Task) (int64, error)
+	// CreateAll сохраняет задачи атомарно и возвращает их ID в том же порядке.
+	CreateAll(ctx context.Context, tasks []Task) ([]int64, error)
+	Update(ctx context.Context, task Task) (bool, error)
+	Complete(ctx context.Context, chatID int64, id int64) (bool, error)
+	Cancel(ctx context.Context, chatID int64, id int64) (bool, error)
+	Delete(ctx context.Context, chatID int64, id int64) (bool, error)
+	ListPendingReminders(ctx context.Context) ([]Task, error)
+	MarkReminded(ctx context.Context, chatID int64, id int64) (bool, error)
+	SetOccurrenceStatus(ctx context.Context, chatID int64, id int64, at time.Time, status Status) (bool, error)
+	MarkOccurrenceReminded(ctx context.Context, chatID int64, id int64, at time.Time) (bool, error)
 }
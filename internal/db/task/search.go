@@ -0,0 +1,25 @@
+package task
+
+import "strings"
+
+// SearchTerms разбивает поисковый запрос на слова в нижнем регистре. Пустой результат — искать нечего.
+func SearchTerms(query string) []string {
+	return strings.Fields(strings.ToLower(query))
+}
+
+// SearchText — текст и место задачи в нижнем регистре, в которых ищут слова из SearchTerms.
+func (t Task) SearchText() string {
+	return strings.ToLower(t.Task + "\n" + t.Location)
+}
+
+// Matches сообщает, что каждое из terms встречается в тексте или месте задачи без учёта регистра.
+// Так ищет хранилище в памяти.
+func (t Task) Matches(terms []string) bool {
+	text := t.SearchText()
+	for _, term := range terms {
+		if !strings.Contains(text, term) {
+			return false
+		}
+	}
+	return true
+}
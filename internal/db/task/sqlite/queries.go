@@ -1,41 +1,132 @@
 package sqlite
 
-const createTableQuery = `
-CREATE TABLE IF NOT EXISTS tasks (
-	chat_id INTEGER NOT NULL,
-	task TEXT NOT NULL,
-	location TEXT NOT NULL,
-	date_time TEXT NOT NULL,
-	PRIMARY KEY (chat_id, date_time)
-);
+import "strings"
+
+const taskColumns = `id, chat_id, task, location, date_time, status, created_at, updated_at, remind_before, reminded_at, rrule, time_zone`
+
+const listRangeQuery = `
+SELECT ` + taskColumns + `
+FROM tasks
+WHERE chat_id = ? AND status = 'open' AND rrule = '' AND due_at >= ? AND due_at < ?
+ORDER BY due_at;
 `
 
-const getTodayQuery = `
-SELECT chat_id, task, location, date_time
+const getTasksQuery = `
+SELECT ` + taskColumns + `
 FROM tasks
-WHERE chat_id = ? AND date(date_time) = date(?);
+WHERE chat_id = ? AND status = 'open' AND rrule = ''
+ORDER BY due_at;
 `
 
-const getTodayTasksQuery = `
-SELECT chat_id, task, location, date_time
+const getRecurringTasksQuery = `
+SELECT ` + taskColumns + `
 FROM tasks
-WHERE chat_id = ? AND date(date_time) = date(?)
-ORDER BY date_time;
+WHERE chat_id = ? AND status = 'open' AND rrule != ''
+ORDER BY due_at;
 `
 
-const getTasksQuery = `
-SELECT chat_id, task, location, date_time
+const listRecurringTasksQuery = `
+SELECT ` + taskColumns + `
 FROM tasks
-WHERE chat_id = ?
-ORDER BY date_time;
+WHERE status = 'open' AND rrule != ''
+ORDER BY due_at;
+`
+
+const getByIdQuery = `
+SELECT ` + taskColumns + `
+FROM tasks
+WHERE chat_id = ? AND id = ?;
+`
+
+const insertQuery = `
+INSERT INTO tasks (chat_id, task, location, date_time, due_at, status, created_at, updated_at, remind_before, rrule, time_zone, search_text)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
 `
 
-const upsertQuery = `
-INSERT OR REPLACE INTO tasks (chat_id, task, location, date_time)
-VALUES (?, ?, ?, ?);
+const updateQuery = `
+UPDATE tasks
+SET task = ?, location = ?, search_text = ?, date_time = ?, due_at = ?, remind_before = ?, rrule = ?, reminded_at = NULL, updated_at = ?
+WHERE chat_id = ? AND id = ?;
+`
+
+const setStatusQuery = `
+UPDATE tasks
+SET status = ?, updated_at = ?
+WHERE chat_id = ? AND id = ?;
 `
 
 const deleteQuery = `
 DELETE FROM tasks
-WHERE chat_id = ? AND date_time = ?;
+WHERE chat_id = ? AND id = ?;
+`
+
+const listPendingRemindersQuery = `
+SELECT ` + taskColumns + `
+FROM tasks
+WHERE status = 'open' AND rrule = '' AND reminded_at IS NULL
+ORDER BY due_at;
+`
+
+const markRemindedQuery = `
+UPDATE tasks
+SET reminded_at = ?
+WHERE chat_id = ? AND id = ?;
+`
+
+const getOccurrencesQuery = `
+SELECT task_id, occurs_at, status, reminded_at
+FROM task_occurrences
+WHERE task_id = ?;
+`
+
+// Повторение можно отметить только у регулярной задачи этого чата.
+const setOccurrenceStatusQuery = `
+INSERT INTO task_occurrences (task_id, occurs_at, status)
+SELECT id, ?, ? FROM tasks WHERE chat_id = ? AND id = ? AND rrule != ''
+ON CONFLICT (task_id, occurs_at) DO UPDATE SET status = excluded.status;
+`
+
+const markOccurrenceRemindedQuery = `
+INSERT INTO task_occurrences (task_id, occurs_at, reminded_at)
+SELECT id, ?, ? FROM tasks WHERE chat_id = ? AND id = ? AND rrule != ''
+ON CONFLICT (task_id, occurs_at) DO UPDATE SET reminded_at = excluded.reminded_at;
+`
+
+const deleteOccurrencesQuery = `
+DELETE FROM task_occurrences
+WHERE task_id IN (SELECT id FROM tasks WHERE chat_id = ? AND id = ?);
+`
+
+const hasFTSQuery = `
+SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'tasks_fts';
+`
+
+const searchQuery = `
+SELECT ` + taskColumns + `
+FROM tasks
+WHERE chat_id = ? AND status = 'open' AND rrule = '' AND id IN (SELECT rowid FROM tasks_fts WHERE tasks_fts MATCH ?)
+ORDER BY due_at;
+`
+
+const searchRecurringQuery = `
+SELECT ` + taskColumns + `
+FROM tasks
+WHERE chat_id = ? AND status = 'open' AND rrule != '' AND id IN (SELECT rowid FROM tasks_fts WHERE tasks_fts MATCH ?)
+ORDER BY due_at;
+`
+
+// likeSearchQuery — выборка открытых задач чата, у которых search_text содержит каждое из terms слов;
+// параметры — chat_id и образцы likePattern. recurring выбирает регулярные задачи вместо разовых.
+func likeSearchQuery(recurring bool, terms int) string {
+	rrule := "rrule = ''"
+	if recurring {
+		rrule = "rrule != ''"
+	}
+
+	return `
+SELECT ` + taskColumns + `
+FROM tasks
+WHERE chat_id = ? AND status = 'open' AND ` + rrule + strings.Repeat(` AND search_text LIKE ? ESCAPE '\'`, terms) + `
+ORDER BY due_at;
 `
+}
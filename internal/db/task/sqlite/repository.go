@@ -1,94 +1,406 @@
 package sqlite
 
 import (
+	"adventBot/internal/db/dbtx"
 	"adventBot/internal/db/task"
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"log"
+	"strings"
+	"time"
 )
 
 type RepositorySQlite struct {
-	db *sql.DB
+	db dbtx.DBTX
 }
 
-func NewRepositorySQlite(db *sql.DB) *RepositorySQlite {
+func NewRepositorySQlite(db dbtx.DBTX) *RepositorySQlite {
 	return &RepositorySQlite{db: db}
 }
 
-func (r *RepositorySQlite) Init() error {
-	_, err := r.db.Exec(createTableQuery)
-	return err
+func (r *RepositorySQlite) ListRange(ctx context.Context, chatID int64, from time.Time, to time.Time) ([]task.Task, error) {
+	rows, err := r.db.QueryContext(ctx, listRangeQuery, chatID, formatTime(from), formatTime(to))
+	if err != nil {
+		log.Printf("[task/RepositorySQlite.ListRange] chatID=%d err=%v", chatID, err)
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		if err := rows.Close(); err != nil {
+			log.Println("[task/RepositorySQlite.ListRange] Error closing rows:", err)
+		}
+	}(rows)
+
+	tasks, err := scanTasks(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.eachRecurring(ctx, "ListRange", getRecurringTasksQuery, func(t task.Task, marks map[int64]task.Occurrence) error {
+		occ, err := task.Expand(t, t.Zone(), marks, from, to, 0)
+		tasks = append(tasks, occ...)
+		return err
+	}, chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	task.SortByDateTime(tasks)
+	return tasks, nil
 }
 
-func (r *RepositorySQlite) GetToday(chatID int64, dateTime string) ([]task.Task, error) {
-	rows, err := r.db.Query(getTodayTasksQuery, chatID, dateTime)
+func (r *RepositorySQlite) GetAll(ctx context.Context, chatID int64) ([]task.Task, error) {
+	rows, err := r.db.QueryContext(ctx, getTasksQuery, chatID)
 	if err != nil {
+		log.Printf("[task/RepositorySQlite.GetAll] chatID=%d err=%v", chatID, err)
 		return nil, err
 	}
 	defer func(rows *sql.Rows) {
-		err := rows.Close()
-		if err != nil {
-			log.Fatal("[GetToday] Error closing rows")
+		if err := rows.Close(); err != nil {
+			log.Println("[task/RepositorySQlite.GetAll] Error closing rows:", err)
 		}
 	}(rows)
 
-	var tasks []task.Task
-	for rows.Next() {
-		var t Task
-		if err := rows.Scan(
-			&chatID,
-			&t.Task,
-			&t.Location,
-			&t.DateTime,
-		); err != nil {
-			return nil, err
+	tasks, err := scanTasks(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	err = r.eachRecurring(ctx, "GetAll", getRecurringTasksQuery, func(t task.Task, marks map[int64]task.Occurrence) error {
+		occ, ok, err := task.NextOpen(t, t.Zone(), marks, now)
+		if ok {
+			tasks = append(tasks, occ)
 		}
+		return err
+	}, chatID)
+	if err != nil {
+		return nil, err
+	}
 
-		tasks = append(tasks, task.Task{
-			ChatID:   chatID,
-			Task:     t.Task,
-			Location: t.Location,
-			DateTime: t.DateTime,
-		})
+	task.SortByDateTime(tasks)
+	return tasks, nil
+}
+
+// Search ищет по индексу tasks_fts, если SQLite собран с FTS5, иначе — по вхождению слов в search_text.
+func (r *RepositorySQlite) Search(ctx context.Context, chatID int64, query string) ([]task.Task, error) {
+	terms := task.SearchTerms(query)
+	if len(terms) == 0 {
+		return nil, nil
 	}
 
-	if err := rows.Err(); err != nil {
+	var n int
+	if err := r.db.QueryRowContext(ctx, hasFTSQuery).Scan(&n); err != nil {
+		log.Printf("[task/RepositorySQlite.Search] chatID=%d err=%v", chatID, err)
 		return nil, err
 	}
 
+	plain, recurring, args := searchQuery, searchRecurringQuery, []any{chatID, ftsQuery(terms)}
+	if n == 0 {
+		plain, recurring, args = likeSearchQuery(false, len(terms)), likeSearchQuery(true, len(terms)), []any{chatID}
+		for _, term := range terms {
+			args = append(args, likePattern(term))
+		}
+	}
+
+	rows, err := r.db.QueryContext(ctx, plain, args...)
+	if err != nil {
+		log.Printf("[task/RepositorySQlite.Search] chatID=%d terms=%q err=%v", chatID, terms, err)
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		if err := rows.Close(); err != nil {
+			log.Println("[task/RepositorySQlite.Search] Error closing rows:", err)
+		}
+	}(rows)
+
+	tasks, err := scanTasks(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	err = r.eachRecurring(ctx, "Search", recurring, func(t task.Task, marks map[int64]task.Occurrence) error {
+		occ, ok, err := task.NextOpen(t, t.Zone(), marks, now)
+		if ok {
+			tasks = append(tasks, occ)
+		}
+		return err
+	}, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	task.SortByDateTime(tasks)
 	return tasks, nil
 }
 
-func (r *RepositorySQlite) GetAll(chatID int64) ([]task.Task, error) {
-	rows, err := r.db.Query(getTasksQuery, chatID)
+func (r *RepositorySQlite) GetByID(ctx context.Context, chatID int64, id int64) (task.Task, bool, error) {
+	t, err := scanTask(r.db.QueryRowContext(ctx, getByIdQuery, chatID, id))
+	switch {
+	case err == nil:
+		return t, true, nil
+	case errors.Is(err, sql.ErrNoRows):
+		return task.Task{}, false, nil
+	default:
+		log.Printf("[task/RepositorySQlite.GetByID] chatID=%d id=%d err=%v", chatID, id, err)
+		return task.Task{}, false, err
+	}
+}
+
+func (r *RepositorySQlite) Create(ctx context.Context, t task.Task) (int64, error) {
+	id, err := insert(ctx, r.db, t)
+	if err != nil {
+		log.Printf("[task/RepositorySQlite.Create] task=%v err=%v", t, err)
+		return 0, err
+	}
+	log.Printf("[task/RepositorySQlite.Create] created id=%d task=%v", id, t)
+	return id, nil
+}
+
+func (r *RepositorySQlite) CreateAll(ctx context.Context, tasks []task.Task) ([]int64, error) {
+	ids := make([]int64, 0, len(tasks))
+	err := dbtx.InTx(ctx, r.db, func(q dbtx.DBTX) error {
+		for _, t := range tasks {
+			id, err := insert(ctx, q, t)
+			if err != nil {
+				log.Printf("[task/RepositorySQlite.CreateAll] task=%v err=%v", t, err)
+				return err
+			}
+			ids = append(ids, id)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("[task/RepositorySQlite.CreateAll] created ids=%v", ids)
+	return ids, nil
+}
+
+func insert(ctx context.Context, q dbtx.DBTX, t task.Task) (int64, error) {
+	if t.Status == "" {
+		t.Status = task.StatusOpen
+	}
+	now := formatTime(time.Now())
+
+	res, err := q.ExecContext(ctx, insertQuery, t.ChatID, t.Task, t.Location, t.DateTime, dueAt(t), t.Status, now, now, t.RemindBefore, t.Recurrence, t.TimeZone, t.SearchText())
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+func (r *RepositorySQlite) Update(ctx context.Context, t task.Task) (bool, error) {
+	return r.exec(ctx, "Update", updateQuery, t.Task, t.Location, t.SearchText(), t.DateTime, dueAt(t), t.RemindBefore, t.Recurrence, formatTime(time.Now()), t.ChatID, t.ID)
+}
+
+func (r *RepositorySQlite) Complete(ctx context.Context, chatID int64, id int64) (bool, error) {
+	return r.exec(ctx, "Complete", setStatusQuery, task.StatusDone, formatTime(time.Now()), chatID, id)
+}
+
+func (r *RepositorySQlite) Cancel(ctx context.Context, chatID int64, id int64) (bool, error) {
+	return r.exec(ctx, "Cancel", setStatusQuery, task.StatusCancelled, formatTime(time.Now()), chatID, id)
+}
+
+func (r *RepositorySQlite) Delete(ctx context.Context, chatID int64, id int64) (bool, error) {
+	var rows int64
+	err := dbtx.InTx(ctx, r.db, func(q dbtx.DBTX) error {
+		if _, err := q.ExecContext(ctx, deleteOccurrencesQuery, chatID, id); err != nil {
+			return err
+		}
+		res, err := q.ExecContext(ctx, deleteQuery, chatID, id)
+		if err != nil {
+			return err
+		}
+		rows, err = res.RowsAffected()
+		return err
+	})
+	if err != nil {
+		log.Printf("[task/RepositorySQlite.Delete] chatID=%d id=%d err=%v", chatID, id, err)
+		return false, err
+	}
+
+	log.Printf("[task/RepositorySQlite.Delete] chatID=%d id=%d affected=%d", chatID, id, rows)
+	return rows > 0, nil
+}
+
+func (r *RepositorySQlite) ListPendingReminders(ctx context.Context) ([]task.Task, error) {
+	rows, err := r.db.QueryContext(ctx, listPendingRemindersQuery)
 	if err != nil {
 		return nil, err
 	}
 	defer func(rows *sql.Rows) {
-		err := rows.Close()
+		if err := rows.Close(); err != nil {
+			log.Println("[task/RepositorySQlite.ListPendingReminders] Error closing rows:", err)
+		}
+	}(rows)
+
+	tasks, err := scanTasks(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	err = r.eachRecurring(ctx, "ListPendingReminders", listRecurringTasksQuery, func(t task.Task, marks map[int64]task.Occurrence) error {
+		occ, ok, err := task.NextUnreminded(t, t.Zone(), marks, now)
+		if ok {
+			tasks = append(tasks, occ)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	task.SortByDateTime(tasks)
+	return tasks, nil
+}
+
+func (r *RepositorySQlite) MarkReminded(ctx context.Context, chatID int64, id int64) (bool, error) {
+	return r.exec(ctx, "MarkReminded", markRemindedQuery, formatTime(time.Now()), chatID, id)
+}
+
+func (r *RepositorySQlite) SetOccurrenceStatus(ctx context.Context, chatID int64, id int64, at time.Time, status task.Status) (bool, error) {
+	return r.exec(ctx, "SetOccurrenceStatus", setOccurrenceStatusQuery, formatTime(at), status, chatID, id)
+}
+
+func (r *RepositorySQlite) MarkOccurrenceReminded(ctx context.Context, chatID int64, id int64, at time.Time) (bool, error) {
+	return r.exec(ctx, "MarkOccurrenceReminded", markOccurrenceRemindedQuery, formatTime(at), formatTime(time.Now()), chatID, id)
+}
+
+// eachRecurring вызывает fn для каждой регулярной задачи из query вместе с состояниями её повторений.
+// Задачи с некорректным правилом или датой пропускаются с записью в лог.
+func (r *RepositorySQlite) eachRecurring(ctx context.Context, op string, query string, fn func(task.Task, map[int64]task.Occurrence) error, args ...any) error {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	tasks, err := scanTasks(rows)
+	if closeErr := rows.Close(); closeErr != nil {
+		log.Printf("[task/RepositorySQlite.%s] Error closing rows: %v", op, closeErr)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, t := range tasks {
+		marks, err := r.occurrences(ctx, t.ID)
 		if err != nil {
-			log.Fatal("[GetToday] Error closing rows")
+			return err
+		}
+		if err := fn(t, marks); err != nil {
+			log.Printf("[task/RepositorySQlite.%s] skip recurring task id=%d: %v", op, t.ID, err)
+		}
+	}
+	return nil
+}
+
+func (r *RepositorySQlite) occurrences(ctx context.Context, taskID int64) (map[int64]task.Occurrence, error) {
+	rows, err := r.db.QueryContext(ctx, getOccurrencesQuery, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		if err := rows.Close(); err != nil {
+			log.Println("[task/RepositorySQlite.occurrences] Error closing rows:", err)
 		}
 	}(rows)
 
-	var tasks []task.Task
+	marks := make(map[int64]task.Occurrence)
 	for rows.Next() {
-		var t Task
-		if err := rows.Scan(
-			&chatID,
-			&t.Task,
-			&t.Location,
-			&t.DateTime,
-		); err != nil {
-			return nil, err
+		var o Occurrence
+		if err := rows.Scan(&o.TaskID, &o.OccursAt, &o.Status, &o.RemindedAt); err != nil {
+			return nil, fmt.Errorf("scan occurrence row: %w", err)
+		}
+
+		occ := task.Occurrence{
+			TaskID: o.TaskID,
+			At:     parseTime(o.OccursAt),
+			Status: task.Status(o.Status),
+		}
+		if o.RemindedAt.Valid {
+			occ.RemindedAt = parseTime(o.RemindedAt.String)
 		}
+		marks[occ.At.Unix()] = occ
+	}
+
+	return marks, rows.Err()
+}
+
+func (r *RepositorySQlite) exec(ctx context.Context, op string, query string, args ...any) (bool, error) {
+	res, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("[task/RepositorySQlite.%s] args=%v err=%v", op, args, err)
+		return false, err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		log.Printf("[task/RepositorySQlite.%s] error getting RowsAffected=%v", op, err)
+		return false, err
+	}
+
+	log.Printf("[task/RepositorySQlite.%s] args=%v affected=%d", op, args, rows)
+	return rows > 0, nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
 
-		tasks = append(tasks, task.Task{
-			ChatID:   chatID,
-			Task:     t.Task,
-			Location: t.Location,
-			DateTime: t.DateTime,
-		})
+func scanTask(row scanner) (task.Task, error) {
+	var t Task
+	if err := row.Scan(
+		&t.ID,
+		&t.ChatID,
+		&t.Task,
+		&t.Location,
+		&t.DateTime,
+		&t.Status,
+		&t.CreatedAt,
+		&t.UpdatedAt,
+		&t.RemindBefore,
+		&t.RemindedAt,
+		&t.Rrule,
+		&t.TimeZone,
+	); err != nil {
+		return task.Task{}, err
+	}
+
+	res := task.Task{
+		ID:         t.ID,
+		ChatID:     t.ChatID,
+		Task:       t.Task,
+		Location:   t.Location,
+		DateTime:   t.DateTime,
+		Status:     task.Status(t.Status),
+		CreatedAt:  parseTime(t.CreatedAt),
+		UpdatedAt:  parseTime(t.UpdatedAt),
+		Recurrence: t.Rrule,
+		TimeZone:   t.TimeZone,
+	}
+	if t.RemindBefore.Valid {
+		minutes := int(t.RemindBefore.Int64)
+		res.RemindBefore = &minutes
+	}
+	if t.RemindedAt.Valid {
+		res.RemindedAt = parseTime(t.RemindedAt.String)
+	}
+
+	return res, nil
+}
+
+func scanTasks(rows *sql.Rows) ([]task.Task, error) {
+	var tasks []task.Task
+	for rows.Next() {
+		t, err := scanTask(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan task row: %w", err)
+		}
+		tasks = append(tasks, t)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -98,17 +410,37 @@ func (r *RepositorySQlite) GetAll(chatID int64) ([]task.Task, error) {
 	return tasks, nil
 }
 
-func (r *RepositorySQlite) Upsert(ctx context.Context, task task.Task) error {
-	_, err := r.db.ExecContext(ctx, upsertQuery, task.ChatID, task.Task, task.Location, task.DateTime)
-	log.Println("upserted task:", task)
-	return err
+// dueAt — значение колонки due_at: момент задачи в UTC; пусто, если DateTime не разбирается.
+func dueAt(t task.Task) string {
+	due, err := t.Due()
+	if err != nil {
+		return ""
+	}
+	return formatTime(due)
+}
+
+// ftsQuery — выражение MATCH: каждое слово в кавычках как префикс, все слова обязательны.
+func ftsQuery(terms []string) string {
+	quoted := make([]string, 0, len(terms))
+	for _, term := range terms {
+		quoted = append(quoted, `"`+strings.ReplaceAll(term, `"`, `""`)+`"*`)
+	}
+	return strings.Join(quoted, " ")
 }
 
-func (r *RepositorySQlite) Delete(ctx context.Context, task task.Task) error {
-	_, err := r.db.ExecContext(ctx, deleteQuery, task.ChatID, task.DateTime)
-	return err
+// likePattern — образец LIKE для вхождения term; % и _ из запроса ищутся буквально.
+func likePattern(term string) string {
+	return "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(term) + "%"
 }
 
-func (r *RepositorySQlite) CloseConnection() error {
-	return r.db.Close()
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+func parseTime(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
 }
@@ -0,0 +1,319 @@
+// Package recurrence разбирает правила повторения в стиле RRULE (RFC 5545) и раскрывает их в даты.
+// Поддерживаются FREQ=DAILY|WEEKLY|MONTHLY, INTERVAL, BYDAY (для WEEKLY), BYMONTHDAY (для MONTHLY),
+// UNTIL и COUNT. Пример: "FREQ=WEEKLY;BYDAY=MO,WE;UNTIL=20251231".
+package recurrence
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Freq string
+
+const (
+	Daily   Freq = "DAILY"
+	Weekly  Freq = "WEEKLY"
+	Monthly Freq = "MONTHLY"
+)
+
+const (
+	untilDateLayout     = "20060102"
+	untilDateTimeLayout = "20060102T150405Z"
+)
+
+// maxPeriods ограничивает перебор для правил, которые никогда не дают дат (например, BYMONTHDAY=31 каждый февраль).
+const maxPeriods = 100000
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+var weekdayCodes = [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+var weekdayNames = [...]string{"вс", "пн", "вт", "ср", "чт", "пт", "сб"}
+
+// Rule — правило повторения. Время суток и часовой пояс повторений берутся из первого наступления (start).
+type Rule struct {
+	Freq       Freq
+	Interval   int            // шаг в единицах Freq, не меньше 1
+	ByDay      []time.Weekday // дни недели для Weekly; пусто — день недели start
+	ByMonthDay int            // число месяца для Monthly; 0 — число start
+	Until      time.Time      // нулевое значение — без ограничения
+	Count      int            // 0 — без ограничения
+
+	// untilDate — UNTIL задан датой без времени и включает весь этот день.
+	untilDate bool
+}
+
+// Parse разбирает правило вида "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR;COUNT=10". Префикс "RRULE:" допускается.
+func Parse(s string) (Rule, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	if s == "" {
+		return Rule{}, fmt.Errorf("empty rule")
+	}
+
+	r := Rule{Interval: 1}
+	for _, part := range strings.Split(s, ";") {
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return Rule{}, fmt.Errorf("invalid rule part %q", part)
+		}
+
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			r.Freq = Freq(strings.ToUpper(value))
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return Rule{}, fmt.Errorf("invalid INTERVAL %q", value)
+			}
+			r.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return Rule{}, fmt.Errorf("invalid COUNT %q", value)
+			}
+			r.Count = n
+		case "UNTIL":
+			until, date, err := parseUntil(value)
+			if err != nil {
+				return Rule{}, err
+			}
+			r.Until, r.untilDate = until, date
+		case "BYDAY":
+			for _, code := range strings.Split(value, ",") {
+				wd, ok := weekdays[strings.ToUpper(code)]
+				if !ok {
+					return Rule{}, fmt.Errorf("invalid BYDAY %q", code)
+				}
+				r.ByDay = append(r.ByDay, wd)
+			}
+		case "BYMONTHDAY":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > 31 {
+				return Rule{}, fmt.Errorf("invalid BYMONTHDAY %q", value)
+			}
+			r.ByMonthDay = n
+		case "WKST":
+			// Недели всегда начинаются с понедельника.
+		default:
+			return Rule{}, fmt.Errorf("unsupported rule part %q", key)
+		}
+	}
+
+	switch r.Freq {
+	case Daily, Weekly, Monthly:
+	case "":
+		return Rule{}, fmt.Errorf("FREQ is required")
+	default:
+		return Rule{}, fmt.Errorf("unsupported FREQ %q", r.Freq)
+	}
+	if len(r.ByDay) > 0 && r.Freq != Weekly {
+		return Rule{}, fmt.Errorf("BYDAY is supported only with FREQ=WEEKLY")
+	}
+	if r.ByMonthDay > 0 && r.Freq != Monthly {
+		return Rule{}, fmt.Errorf("BYMONTHDAY is supported only with FREQ=MONTHLY")
+	}
+
+	sort.Slice(r.ByDay, func(i, j int) bool { return mondayIndex(r.ByDay[i]) < mondayIndex(r.ByDay[j]) })
+	return r, nil
+}
+
+func parseUntil(value string) (time.Time, bool, error) {
+	if t, err := time.Parse(untilDateTimeLayout, value); err == nil {
+		return t, false, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, false, nil
+	}
+	if t, err := time.Parse(untilDateLayout, value); err == nil {
+		return t, true, nil
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, true, nil
+	}
+	return time.Time{}, false, fmt.Errorf("invalid UNTIL %q", value)
+}
+
+// String возвращает правило в каноническом виде RRULE.
+func (r Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		codes := make([]string, len(r.ByDay))
+		for i, wd := range r.ByDay {
+			codes[i] = weekdayCodes[wd]
+		}
+		parts = append(parts, "BYDAY="+strings.Join(codes, ","))
+	}
+	if r.ByMonthDay > 0 {
+		parts = append(parts, "BYMONTHDAY="+strconv.Itoa(r.ByMonthDay))
+	}
+	if !r.Until.IsZero() {
+		if r.untilDate {
+			parts = append(parts, "UNTIL="+r.Until.Format(untilDateLayout))
+		} else {
+			parts = append(parts, "UNTIL="+r.Until.UTC().Format(untilDateTimeLayout))
+		}
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Describe возвращает описание правила для пользователя, например "каждую неделю: пн, ср".
+func (r Rule) Describe() string {
+	var b strings.Builder
+	interval := max(r.Interval, 1)
+
+	switch r.Freq {
+	case Daily:
+		if interval == 1 {
+			b.WriteString("каждый день")
+		} else {
+			fmt.Fprintf(&b, "каждые %d дн.", interval)
+		}
+	case Weekly:
+		if interval == 1 {
+			b.WriteString("каждую неделю")
+		} else {
+			fmt.Fprintf(&b, "каждые %d нед.", interval)
+		}
+		if len(r.ByDay) > 0 {
+			names := make([]string, len(r.ByDay))
+			for i, wd := range r.ByDay {
+				names[i] = weekdayNames[wd]
+			}
+			b.WriteString(": " + strings.Join(names, ", "))
+		}
+	case Monthly:
+		if interval == 1 {
+			b.WriteString("каждый месяц")
+		} else {
+			fmt.Fprintf(&b, "каждые %d мес.", interval)
+		}
+		if r.ByMonthDay > 0 {
+			fmt.Fprintf(&b, ", %d числа", r.ByMonthDay)
+		}
+	}
+
+	if !r.Until.IsZero() {
+		b.WriteString(", до " + r.Until.Format("02.01.2006"))
+	}
+	if r.Count > 0 {
+		fmt.Fprintf(&b, ", всего повторений: %d", r.Count)
+	}
+	return b.String()
+}
+
+// Each вызывает fn для каждого повторения начиная со start (включительно) по возрастанию,
+// пока fn возвращает true и не исчерпаны UNTIL/COUNT. Повторения сохраняют время суток start
+// в его часовом поясе; чтобы оно не сдвигалось при переходе на летнее время, start должен быть
+// в IANA-зоне, а не с фиксированным смещением из RFC 3339.
+func (r Rule) Each(start time.Time, fn func(at time.Time) bool) {
+	n := 0
+	emit := func(at time.Time) bool {
+		if at.Before(start) {
+			return true
+		}
+		if r.Count > 0 && n >= r.Count {
+			return false
+		}
+		if r.afterUntil(at) {
+			return false
+		}
+		n++
+		return fn(at)
+	}
+
+	interval := max(r.Interval, 1)
+	y, m, d := start.Date()
+	hh, mm, ss := start.Clock()
+	loc := start.Location()
+
+	for k := 0; k < maxPeriods; k++ {
+		switch r.Freq {
+		case Daily:
+			if !emit(time.Date(y, m, d+k*interval, hh, mm, ss, 0, loc)) {
+				return
+			}
+
+		case Weekly:
+			days := r.ByDay
+			if len(days) == 0 {
+				days = []time.Weekday{start.Weekday()}
+			}
+			monday := d - mondayIndex(start.Weekday()) + 7*k*interval
+			for _, wd := range days {
+				if !emit(time.Date(y, m, monday+mondayIndex(wd), hh, mm, ss, 0, loc)) {
+					return
+				}
+			}
+
+		case Monthly:
+			day := r.ByMonthDay
+			if day == 0 {
+				day = d
+			}
+			first := time.Date(y, m+time.Month(k*interval), 1, hh, mm, ss, 0, loc)
+			if day > daysIn(first) {
+				continue
+			}
+			if !emit(time.Date(first.Year(), first.Month(), day, hh, mm, ss, 0, loc)) {
+				return
+			}
+
+		default:
+			return
+		}
+	}
+}
+
+// Between возвращает повторения в полуинтервале [from, to).
+func (r Rule) Between(start time.Time, from time.Time, to time.Time) []time.Time {
+	var res []time.Time
+	r.Each(start, func(at time.Time) bool {
+		if !at.Before(to) {
+			return false
+		}
+		if !at.Before(from) {
+			res = append(res, at)
+		}
+		return true
+	})
+	return res
+}
+
+func (r Rule) afterUntil(at time.Time) bool {
+	if r.Until.IsZero() {
+		return false
+	}
+	if r.untilDate {
+		y, m, d := at.Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC).After(r.Until)
+	}
+	return at.After(r.Until)
+}
+
+func mondayIndex(wd time.Weekday) int {
+	return (int(wd) + 6) % 7
+}
+
+func daysIn(t time.Time) int {
+	return time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
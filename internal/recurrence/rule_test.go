@@ -0,0 +1,35 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEachKeepsLocalTimeAcrossDST(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("no tzdata:", err)
+	}
+	rule, err := Parse("FREQ=WEEKLY;BYDAY=MO")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 29 марта 2026 Берлин переходит с +01:00 на +02:00
+	start := time.Date(2026, time.March, 23, 9, 0, 0, 0, berlin)
+	want := []string{"2026-03-23T09:00:00+01:00", "2026-03-30T09:00:00+02:00", "2026-04-06T09:00:00+02:00"}
+
+	var got []string
+	rule.Each(start, func(at time.Time) bool {
+		got = append(got, at.Format(time.RFC3339))
+		return len(got) < len(want)
+	})
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("occurrence %d = %s, want %s", i, got[i], want[i])
+		}
+	}
+}
@@ -2,23 +2,35 @@ package service
 
 import (
 	"adventBot/internal/ai_model/yandex/summary/tasks"
+	"adventBot/internal/db/chat"
+	"adventBot/internal/db/subscription"
 	"adventBot/internal/db/task"
+	"context"
+	"fmt"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"log"
 	"sync"
+	"time"
 )
 
 type SchedulerManager struct {
 	schedulers map[int64]*DailyTaskScheduler
 	summary    *tasks.SummarizerTask
 	taskRepo   task.Repository
+	chatRepo   chat.Repository
+	subRepo    subscription.Repository
+	catchUp    CatchUpPolicy
 	mu         sync.RWMutex
 }
 
-func NewSchedulerManager(taskRepo task.Repository, s *tasks.SummarizerTask) *SchedulerManager {
+func NewSchedulerManager(taskRepo task.Repository, chatRepo chat.Repository, subRepo subscription.Repository, s *tasks.SummarizerTask, catchUp CatchUpPolicy) *SchedulerManager {
 	return &SchedulerManager{
 		schedulers: make(map[int64]*DailyTaskScheduler),
 		summary:    s,
 		taskRepo:   taskRepo,
+		chatRepo:   chatRepo,
+		subRepo:    subRepo,
+		catchUp:    catchUp,
 	}
 }
 
@@ -26,21 +38,62 @@ func (m *SchedulerManager) AddScheduler(chatID int64, bot *tgbotapi.BotAPI) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if _, exists := m.schedulers[chatID]; !exists {
-		scheduler := NewDailyTaskScheduler(m.taskRepo, chatID, bot, m.summary)
-		m.schedulers[chatID] = scheduler
-		scheduler.Start()
+	if _, exists := m.schedulers[chatID]; exists {
+		return
 	}
+
+	if err := m.subRepo.Add(context.Background(), chatID); err != nil {
+		log.Printf("[SchedulerManager.AddScheduler] persist chat ID %d: %v", chatID, err)
+	}
+	m.startLocked(chatID, time.Now(), bot)
 }
 
+// Restore поднимает планировщики всех сохранённых подписок после перезапуска бота.
+func (m *SchedulerManager) Restore(ctx context.Context, bot *tgbotapi.BotAPI) error {
+	subs, err := m.subRepo.GetAll(ctx)
+	if err != nil {
+		return fmt.Errorf("load subscriptions: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, sub := range subs {
+		if _, exists := m.schedulers[sub.ChatID]; exists {
+			continue
+		}
+
+		since := sub.LastDigestAt
+		if sub.CreatedAt.After(since) {
+			since = sub.CreatedAt
+		}
+		m.startLocked(sub.ChatID, since, bot)
+	}
+
+	log.Printf("[SchedulerManager.Restore] restored %d schedulers", len(subs))
+	return nil
+}
+
+func (m *SchedulerManager) startLocked(chatID int64, since time.Time, bot *tgbotapi.BotAPI) {
+	scheduler := NewDailyTaskScheduler(m.taskRepo, m.chatRepo, m.subRepo, chatID, since, bot, m.summary, m.catchUp)
+	m.schedulers[chatID] = scheduler
+	scheduler.Start()
+}
+
+// RemoveScheduler отписывает чат от сводок. Сводка, которая отправляется прямо сейчас, отменяется, и планировщик
+// удаляется только после выхода — иначе Shutdown его бы не дождался, а чат получил бы сводку уже после сброса.
 func (m *SchedulerManager) RemoveScheduler(chatID int64) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	if scheduler, exists := m.schedulers[chatID]; exists {
-		scheduler.Stop()
+		scheduler.Cancel()
 		delete(m.schedulers, chatID)
 	}
+
+	if _, err := m.subRepo.Remove(context.Background(), chatID); err != nil {
+		log.Printf("[SchedulerManager.RemoveScheduler] chat ID %d: %v", chatID, err)
+	}
 }
 
 func (m *SchedulerManager) GetScheduler(chatID int64) *DailyTaskScheduler {
@@ -50,21 +103,41 @@ func (m *SchedulerManager) GetScheduler(chatID int64) *DailyTaskScheduler {
 	return m.schedulers[chatID]
 }
 
-func (m *SchedulerManager) ProcessAllNow() {
+func (m *SchedulerManager) Reschedule(chatID int64) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if scheduler, exists := m.schedulers[chatID]; exists {
+		scheduler.Reschedule()
+	}
+}
+
+func (m *SchedulerManager) ProcessAllNow(ctx context.Context) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
 	for _, scheduler := range m.schedulers {
-		scheduler.ProcessNow()
+		scheduler.ProcessNow(ctx)
 	}
 }
 
-func (m *SchedulerManager) Shutdown() {
+// Shutdown останавливает планировщики, но оставляет подписки — они восстановятся при следующем запуске.
+// Ждёт сводки, которые отправляются прямо сейчас; если ctx истекает раньше, отменяет их и возвращает ошибку ctx.
+func (m *SchedulerManager) Shutdown(ctx context.Context) error {
 	m.mu.Lock()
-	defer m.mu.Unlock()
+	schedulers := m.schedulers
+	m.schedulers = make(map[int64]*DailyTaskScheduler)
+	m.mu.Unlock()
 
-	for _, scheduler := range m.schedulers {
+	for _, scheduler := range schedulers {
 		scheduler.Stop()
 	}
-	m.schedulers = make(map[int64]*DailyTaskScheduler)
+
+	var err error
+	for _, scheduler := range schedulers {
+		if waitErr := scheduler.Wait(ctx); waitErr != nil {
+			err = waitErr
+		}
+	}
+	return err
 }
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"adventBot/internal/ai_model/llm"
+	"adventBot/internal/ai_model/yandex/summary/tasks"
+	"adventBot/internal/bot/tgtest"
+	"adventBot/internal/db/chat"
+	"adventBot/internal/db/store"
+	"adventBot/internal/db/task"
+	"context"
+	"testing"
+	"time"
+)
+
+// stuckClient отвечает только после отмены запроса; started закрывается, когда запрос пришёл.
+type stuckClient struct {
+	llm.Client
+	started chan struct{}
+}
+
+func (c *stuckClient) Complete(ctx context.Context, req llm.Request) (llm.Response, error) {
+	close(c.started)
+	<-ctx.Done()
+	return llm.Response{}, ctx.Err()
+}
+
+func TestRemoveSchedulerCancelsDigestInFlight(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewMemory()
+	now := time.Now().UTC()
+
+	// Время сводки — текущая минута, подписка старше: планировщик сразу догоняет сводку
+	if err := st.Chats.Upsert(ctx, 42, "UTC"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.Chats.UpdateSettings(ctx, 42, chat.Settings{RemindBefore: 15, DigestTime: now.Format("15:04")}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.Tasks.Create(ctx, task.Task{ChatID: 42, Task: "Врач", DateTime: now.Format(time.RFC3339)}); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := tgtest.NewServer()
+	t.Cleanup(srv.Close)
+	bot, err := srv.Bot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &stuckClient{started: make(chan struct{})}
+	m := NewSchedulerManager(st.Tasks, st.Chats, st.Subscriptions, tasks.NewSummarizerTask(client), CatchUpPolicy{Enabled: true, Window: time.Hour})
+	m.mu.Lock()
+	m.startLocked(42, now.Truncate(time.Minute).Add(-time.Minute), bot)
+	scheduler := m.schedulers[42]
+	m.mu.Unlock()
+
+	select {
+	case <-client.started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("digest did not start")
+	}
+
+	m.RemoveScheduler(42)
+
+	select {
+	case <-scheduler.done:
+	default:
+		t.Fatal("RemoveScheduler returned before the scheduler exited")
+	}
+	if m.GetScheduler(42) != nil {
+		t.Error("scheduler is still registered")
+	}
+	if sent := srv.CallsOf("sendMessage"); len(sent) != 0 {
+		t.Errorf("digest reached the chat after RemoveScheduler: %+v", sent)
+	}
+}
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"adventBot/internal/db/chat"
+	"adventBot/internal/db/task"
+	"context"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"log"
+	"time"
+)
+
+// reminderMaxSleep — как долго движок спит без пробуждения, даже если ближайших напоминаний нет.
+const reminderMaxSleep = 10 * time.Minute
+
+// ReminderEngine присылает напоминание за RemindBefore минут до каждой открытой задачи.
+// Очередь не хранится в памяти: при каждом пробуждении ожидающие напоминания перечитываются
+// из репозитория, поэтому движок переживает рестарт, а правки задач подхватываются через Rearm.
+type ReminderEngine struct {
+	taskRepo task.Repository
+	chatRepo chat.Repository
+	bot      *tgbotapi.BotAPI
+	wakeCh   chan struct{}
+	stopCh   chan struct{}
+	done     chan struct{} // закрывается, когда run завершился
+
+	// ctx передаётся в запросы к репозиториям; отменяется, если Stop не дождался отправки напоминаний
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func NewReminderEngine(taskRepo task.Repository, chatRepo chat.Repository, b *tgbotapi.BotAPI) *ReminderEngine {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ReminderEngine{
+		taskRepo: taskRepo,
+		chatRepo: chatRepo,
+		bot:      b,
+		wakeCh:   make(chan struct{}, 1),
+		stopCh:   make(chan struct{}),
+		done:     make(chan struct{}),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+func (e *ReminderEngine) Start() {
+	log.Println("Starting reminder engine")
+
+	go e.run()
+}
+
+// Stop останавливает движок и ждёт, пока он доотправит уже наступившие напоминания. Если ctx
+// истекает раньше, отправка отменяется; Stop дожидается выхода движка и возвращает ошибку ctx.
+func (e *ReminderEngine) Stop(ctx context.Context) error {
+	close(e.stopCh)
+	defer log.Println("Stopped reminder engine")
+
+	select {
+	case <-e.done:
+		e.cancel()
+		return nil
+	case <-ctx.Done():
+		e.cancel()
+		<-e.done
+		return ctx.Err()
+	}
+}
+
+// Rearm заставляет движок перечитать задачи и пересчитать ближайшее напоминание.
+func (e *ReminderEngine) Rearm() {
+	select {
+	case e.wakeCh <- struct{}{}:
+	default:
+	}
+}
+
+// Watch оборачивает репозиторий задач так, что любое изменение задачи вызывает Rearm.
+func (e *ReminderEngine) Watch(r task.Repository) task.Repository {
+	return &watchedTaskRepository{Repository: r, engine: e}
+}
+
+func (e *ReminderEngine) run() {
+	defer close(e.done)
+
+	for {
+		wait := time.Until(e.processDue(time.Now()))
+		if wait < 0 {
+			wait = 0
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-e.wakeCh:
+			timer.Stop()
+		case <-e.stopCh:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// processDue отправляет наступившие напоминания и возвращает время следующего пробуждения.
+func (e *ReminderEngine) processDue(now time.Time) time.Time {
+	ctx := e.ctx
+	next := now.Add(reminderMaxSleep)
+
+	tasks, err := e.taskRepo.ListPendingReminders(ctx)
+	if err != nil {
+		log.Printf("[ReminderEngine.processDue] Error retrieving pending reminders: %v", err)
+		return next
+	}
+
+	settings := make(map[int64]chat.Settings)
+	for _, t := range tasks {
+		if ctx.Err() != nil {
+			// Остановка — оставшиеся напоминания отправятся при следующем запуске
+			return next
+		}
+
+		due, err := time.Parse(time.RFC3339, t.DateTime)
+		if err != nil {
+			// Напомнить без даты нельзя. Отмечаем задачу, чтобы не перечитывать её при каждом пробуждении;
+			// если дату исправят, Update снимет отметку
+			log.Printf("[ReminderEngine.processDue] invalid dateTime=%q task id=%d, giving up: %v", t.DateTime, t.ID, err)
+			if _, err := e.taskRepo.MarkReminded(ctx, t.ChatID, t.ID); err != nil {
+				log.Printf("[ReminderEngine.processDue] MarkReminded task id=%d: %v", t.ID, err)
+			}
+			continue
+		}
+
+		fireAt := due.Add(-e.remindBefore(ctx, t, settings))
+		if fireAt.After(now) {
+			if fireAt.Before(next) {
+				next = fireAt
+			}
+			continue
+		}
+
+		// Задача уже началась (например, бот был выключен) — напоминать поздно.
+		if due.After(now) {
+			e.send(t, due.Sub(now))
+		} else {
+			log.Printf("[ReminderEngine.processDue] skip stale reminder task id=%d dateTime=%s", t.ID, t.DateTime)
+		}
+
+		if t.IsRecurring() {
+			_, err = e.taskRepo.MarkOccurrenceReminded(ctx, t.ChatID, t.ID, due)
+		} else {
+			_, err = e.taskRepo.MarkReminded(ctx, t.ChatID, t.ID)
+		}
+		if err != nil {
+			log.Printf("[ReminderEngine.processDue] MarkReminded task id=%d: %v", t.ID, err)
+		}
+	}
+
+	return next
+}
+
+func (e *ReminderEngine) remindBefore(ctx context.Context, t task.Task, cache map[int64]chat.Settings) time.Duration {
+	if t.RemindBefore != nil {
+		return time.Duration(*t.RemindBefore) * time.Minute
+	}
+
+	s, ok := cache[t.ChatID]
+	if !ok {
+		var err error
+		if s, err = e.chatRepo.GetSettings(ctx, t.ChatID); err != nil {
+			log.Printf("[ReminderEngine.remindBefore] GetSettings chatID=%d: %v", t.ChatID, err)
+		}
+		cache[t.ChatID] = s
+	}
+	return time.Duration(s.RemindBefore) * time.Minute
+}
+
+func (e *ReminderEngine) send(t task.Task, left time.Duration) {
+	text := fmt.Sprintf("🔔 Напоминание: через %d мин.\nЗадача: %s\nДата: %s\nЛокация: %s",
+		int(left.Round(time.Minute).Minutes()), t.Task, t.DateTime, t.Location)
+
+	if _, err := e.bot.Send(tgbotapi.NewMessage(t.ChatID, text)); err != nil {
+		log.Printf("[ReminderEngine.send] Error sending reminder chatID=%d task id=%d: %v", t.ChatID, t.ID, err)
+	}
+}
+
+type watchedTaskRepository struct {
+	task.Repository
+	engine *ReminderEngine
+}
+
+func (r *watchedTaskRepository) Create(ctx context.Context, t task.Task) (int64, error) {
+	defer r.engine.Rearm()
+	return r.Repository.Create(ctx, t)
+}
+
+func (r *watchedTaskRepository) CreateAll(ctx context.Context, tasks []task.Task) ([]int64, error) {
+	defer r.engine.Rearm()
+	return r.Repository.CreateAll(ctx, tasks)
+}
+
+func (r *watchedTaskRepository) Update(ctx context.Context, t task.Task) (bool, error) {
+	defer r.engine.Rearm()
+	return r.Repository.Update(ctx, t)
+}
+
+func (r *watchedTaskRepository) Complete(ctx context.Context, chatID int64, id int64) (bool, error) {
+	defer r.engine.Rearm()
+	return r.Repository.Complete(ctx, chatID, id)
+}
+
+func (r *watchedTaskRepository) Cancel(ctx context.Context, chatID int64, id int64) (bool, error) {
+	defer r.engine.Rearm()
+	return r.Repository.Cancel(ctx, chatID, id)
+}
+
+func (r *watchedTaskRepository) SetOccurrenceStatus(ctx context.Context, chatID int64, id int64, at time.Time, status task.Status) (bool, error) {
+	defer r.engine.Rearm()
+	return r.Repository.SetOccurrenceStatus(ctx, chatID, id, at, status)
+}
+
+func (r *watchedTaskRepository) Delete(ctx context.Context, chatID int64, id int64) (bool, error) {
+	defer r.engine.Rearm()
+	return r.Repository.Delete(ctx, chatID, id)
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"adventBot/internal/db/store"
+	"adventBot/internal/db/task"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestProcessDueGivesUpOnInvalidDateTime(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewMemory()
+	e := NewReminderEngine(st.Tasks, st.Chats, nil)
+
+	id, err := st.Tasks.Create(ctx, task.Task{ChatID: 1, Task: "Врач", DateTime: "завтра в 10"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e.processDue(time.Now())
+
+	pending, err := st.Tasks.ListPendingReminders(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 0 {
+		t.Fatalf("task with invalid dateTime is still pending: %+v", pending)
+	}
+
+	// Исправленная дата снова ставит напоминание
+	fixed, _, err := st.Tasks.GetByID(ctx, 1, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixed.DateTime = time.Now().Add(48 * time.Hour).Format(time.RFC3339)
+	if _, err := st.Tasks.Update(ctx, fixed); err != nil {
+		t.Fatal(err)
+	}
+	if pending, _ := st.Tasks.ListPendingReminders(ctx); len(pending) != 1 {
+		t.Errorf("after fixing dateTime pending = %+v, want the task", pending)
+	}
+}
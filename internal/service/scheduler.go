@@ -2,8 +2,11 @@ package service
 
 import (
 	summary "adventBot/internal/ai_model/yandex/summary/tasks"
+	"adventBot/internal/db/chat"
+	"adventBot/internal/db/subscription"
 	"adventBot/internal/db/task"
 	"adventBot/internal/utils"
+	"context"
 	"fmt"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"log"
@@ -11,22 +14,46 @@ import (
 	"time"
 )
 
+// CatchUpPolicy определяет, что делать со сводкой, время которой прошло, пока бот был выключен.
+type CatchUpPolicy struct {
+	Enabled bool          // false — пропущенные сводки не отправляются
+	Window  time.Duration // сводка, пропущенная раньше чем Window назад, считается устаревшей
+}
+
 type DailyTaskScheduler struct {
-	taskRepo   task.Repository
-	chatID     int64
-	bot        *tgbotapi.BotAPI
-	summarizer *summary.SummarizerTask
-	ticker     *time.Ticker
-	stopCh     chan struct{}
+	taskRepo     task.Repository
+	chatRepo     chat.Repository
+	subRepo      subscription.Repository
+	chatID       int64
+	since        time.Time // момент последней отправленной сводки или создания подписки
+	bot          *tgbotapi.BotAPI
+	summarizer   *summary.SummarizerTask
+	catchUp      CatchUpPolicy
+	rescheduleCh chan struct{}
+	stopCh       chan struct{}
+	done         chan struct{} // закрывается, когда run завершился
+
+	// ctx передаётся в запросы сводки; отменяется, если остановка не дождалась её отправки
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
-func NewDailyTaskScheduler(taskRepo task.Repository, chatID int64, b *tgbotapi.BotAPI, s *summary.SummarizerTask) *DailyTaskScheduler {
+func NewDailyTaskScheduler(taskRepo task.Repository, chatRepo chat.Repository, subRepo subscription.Repository, chatID int64, since time.Time, b *tgbotapi.BotAPI, s *summary.SummarizerTask, catchUp CatchUpPolicy) *DailyTaskScheduler {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &DailyTaskScheduler{
-		taskRepo:   taskRepo,
-		chatID:     chatID,
-		bot:        b,
-		summarizer: s,
-		stopCh:     make(chan struct{}),
+		taskRepo:     taskRepo,
+		chatRepo:     chatRepo,
+		subRepo:      subRepo,
+		chatID:       chatID,
+		since:        since,
+		bot:          b,
+		summarizer:   s,
+		catchUp:      catchUp,
+		rescheduleCh: make(chan struct{}, 1),
+		stopCh:       make(chan struct{}),
+		done:         make(chan struct{}),
+		ctx:          ctx,
+		cancel:       cancel,
 	}
 }
 
@@ -36,35 +63,140 @@ func (s *DailyTaskScheduler) Start() {
 	go s.run()
 }
 
+// Stop останавливает планировщик, не дожидаясь сводки, которая отправляется прямо сейчас;
+// дождаться её можно через Wait.
 func (s *DailyTaskScheduler) Stop() {
-	if s.ticker != nil {
-		s.ticker.Stop()
-	}
 	close(s.stopCh)
 	log.Printf("Stopped daily task scheduler for chat ID: %d", s.chatID)
 }
 
+// Wait ждёт, пока остановленный планировщик завершится. Если ctx истекает раньше, отправка
+// текущей сводки отменяется; Wait дожидается её выхода и возвращает ошибку ctx.
+func (s *DailyTaskScheduler) Wait(ctx context.Context) error {
+	select {
+	case <-s.done:
+		s.cancel()
+		return nil
+	case <-ctx.Done():
+		s.cancel()
+		<-s.done
+		return ctx.Err()
+	}
+}
+
+// Cancel останавливает планировщик, прерывает сводку, которая отправляется прямо сейчас, и ждёт его выхода.
+func (s *DailyTaskScheduler) Cancel() {
+	s.Stop()
+	s.cancel()
+	<-s.done
+}
+
+// Reschedule пересчитывает время следующей сводки после смены часового пояса или времени сводки.
+func (s *DailyTaskScheduler) Reschedule() {
+	select {
+	case s.rescheduleCh <- struct{}{}:
+	default:
+	}
+}
+
 func (s *DailyTaskScheduler) run() {
-	s.processDailyTasks()
+	defer close(s.done)
 
-	s.ticker = time.NewTicker(2 * time.Minute) //TODO заменить на 24 часа
-	defer s.ticker.Stop()
+	s.catchUpMissed(time.Now())
 
 	for {
+		next := s.nextRun(time.Now())
+		log.Printf("Next daily digest for chat ID %d at %s", s.chatID, next.Format(time.RFC3339))
+
+		timer := time.NewTimer(time.Until(next))
 		select {
-		case <-s.ticker.C:
-			s.processDailyTasks()
+		case <-timer.C:
+			s.processDailyTasks(s.ctx)
+			if s.ctx.Err() != nil {
+				// Остановка прервала сводку — она догонится при следующем запуске
+				return
+			}
+			s.markDelivered(time.Now())
+		case <-s.rescheduleCh:
+			timer.Stop()
 		case <-s.stopCh:
+			timer.Stop()
 			return
 		}
 	}
 }
 
-func (s *DailyTaskScheduler) processDailyTasks() {
-	today := utils.GetToday()
+// catchUpMissed отправляет сводку, время которой прошло, пока бот был выключен.
+// Отправляется не больше одной сводки, и только если она не старше CatchUpPolicy.Window.
+func (s *DailyTaskScheduler) catchUpMissed(now time.Time) {
+	prev := s.prevRun(now)
+	if !prev.After(s.since) {
+		return
+	}
+
+	if !s.catchUp.Enabled {
+		log.Printf("[DailyTaskScheduler.catchUpMissed] chat ID %d: digest at %s missed, catch-up disabled", s.chatID, prev.Format(time.RFC3339))
+		return
+	}
+	if now.Sub(prev) > s.catchUp.Window {
+		log.Printf("[DailyTaskScheduler.catchUpMissed] chat ID %d: digest at %s is stale, skipping", s.chatID, prev.Format(time.RFC3339))
+		return
+	}
+
+	log.Printf("[DailyTaskScheduler.catchUpMissed] chat ID %d: sending missed digest for %s", s.chatID, prev.Format(time.RFC3339))
+	s.processDailyTasks(s.ctx)
+	if s.ctx.Err() == nil {
+		s.markDelivered(now)
+	}
+}
+
+func (s *DailyTaskScheduler) markDelivered(at time.Time) {
+	s.since = at
+	if err := s.subRepo.MarkDelivered(context.Background(), s.chatID, at); err != nil {
+		log.Printf("[DailyTaskScheduler.markDelivered] chat ID %d: %v", s.chatID, err)
+	}
+}
+
+// nextRun — ближайшее наступление времени сводки в часовом поясе чата.
+func (s *DailyTaskScheduler) nextRun(now time.Time) time.Time {
+	hour, minute := s.digestClock()
+	return utils.NextDailyAt(now, s.location(), hour, minute)
+}
+
+// prevRun — последнее уже наступившее время сводки в часовом поясе чата.
+func (s *DailyTaskScheduler) prevRun(now time.Time) time.Time {
+	hour, minute := s.digestClock()
+	return utils.PrevDailyAt(now, s.location(), hour, minute)
+}
+
+func (s *DailyTaskScheduler) digestClock() (hour int, minute int) {
+	settings, err := s.chatRepo.GetSettings(context.Background(), s.chatID)
+	if err != nil {
+		log.Printf("[DailyTaskScheduler.digestClock] GetSettings chat ID %d: %v", s.chatID, err)
+	}
+
+	hour, minute, err = utils.ParseClock(settings.DigestTime)
+	if err != nil {
+		log.Printf("[DailyTaskScheduler.digestClock] chat ID %d: %v, fallback to %s", s.chatID, err, chat.DefaultDigestTime)
+		hour, minute, _ = utils.ParseClock(chat.DefaultDigestTime)
+	}
+	return hour, minute
+}
+
+func (s *DailyTaskScheduler) location() *time.Location {
+	tz, _, err := s.chatRepo.GetById(context.Background(), s.chatID)
+	if err != nil {
+		log.Printf("[DailyTaskScheduler.location] GetById chat ID %d: %v", s.chatID, err)
+	}
+	return utils.LoadLocation(tz)
+}
+
+func (s *DailyTaskScheduler) processDailyTasks(ctx context.Context) {
+	from, to := utils.DayRange(time.Now().In(s.location()))
+	today := from.Format(time.DateOnly)
 	log.Printf("Processing daily tasks for chat ID %d, date: %s", s.chatID, today)
 
-	tasks, err := s.taskRepo.GetToday(s.chatID, today)
+	tasks, err := s.taskRepo.ListRange(ctx, s.chatID, from, to)
 	if err != nil {
 		log.Printf("[DailyTaskScheduler.processDailyTasks] Error retrieving tasks for chat ID %d: %v", s.chatID, err)
 		return
@@ -85,10 +217,14 @@ func (s *DailyTaskScheduler) processDailyTasks() {
 		}
 	}
 	text := b.String()
-	log.Printf(text)
+	log.Print(text)
 
-	reply := s.summarizer.Summarize(text)
-	log.Printf(reply)
+	reply := s.summarizer.Summarize(ctx, text)
+	if ctx.Err() != nil {
+		log.Printf("[DailyTaskScheduler.processDailyTasks] chat ID %d: digest cancelled: %v", s.chatID, ctx.Err())
+		return
+	}
+	log.Print(reply)
 
 	msg := tgbotapi.NewMessage(s.chatID, reply)
 	if _, err := s.bot.Send(msg); err != nil {
@@ -96,6 +232,6 @@ func (s *DailyTaskScheduler) processDailyTasks() {
 	}
 }
 
-func (s *DailyTaskScheduler) ProcessNow() {
-	s.processDailyTasks()
+func (s *DailyTaskScheduler) ProcessNow(ctx context.Context) {
+	s.processDailyTasks(ctx)
 }
@@ -1,7 +1,55 @@
 package utils
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
-func GetToday() string {
-	return time.Now().Format(time.DateOnly)
+// DayRange возвращает начало суток, в которые попадает t, и начало следующих суток в зоне t.
+// Длина суток при переходе на летнее/зимнее время может отличаться от 24 часов.
+func DayRange(t time.Time) (from time.Time, to time.Time) {
+	from = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return from, from.AddDate(0, 0, 1)
+}
+
+// LoadLocation загружает IANA-зону, при ошибке или пустом имени возвращает UTC.
+func LoadLocation(tz string) *time.Location {
+	if tz == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// ParseClock разбирает время суток в формате "15:04".
+func ParseClock(s string) (hour int, minute int, err error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid clock %q: %w", s, err)
+	}
+	return t.Hour(), t.Minute(), nil
+}
+
+// NextDailyAt возвращает ближайший после now момент, когда в зоне loc будет hour:minute.
+// Дни отсчитываются по календарю зоны, поэтому переход на летнее/зимнее время не сдвигает время срабатывания.
+func NextDailyAt(now time.Time, loc *time.Location, hour int, minute int) time.Time {
+	local := now.In(loc)
+	next := time.Date(local.Year(), local.Month(), local.Day(), hour, minute, 0, 0, loc)
+	if !next.After(now) {
+		next = time.Date(local.Year(), local.Month(), local.Day()+1, hour, minute, 0, 0, loc)
+	}
+	return next
+}
+
+// PrevDailyAt возвращает последний не позже now момент, когда в зоне loc было hour:minute.
+func PrevDailyAt(now time.Time, loc *time.Location, hour int, minute int) time.Time {
+	local := now.In(loc)
+	prev := time.Date(local.Year(), local.Month(), local.Day(), hour, minute, 0, 0, loc)
+	if prev.After(now) {
+		prev = time.Date(local.Year(), local.Month(), local.Day()-1, hour, minute, 0, 0, loc)
+	}
+	return prev
 }
@@ -2,51 +2,62 @@ package main
 
 import (
 	"adventBot/internal/ai_model"
+	"adventBot/internal/ai_model/llm"
 	"adventBot/internal/ai_model/yandex"
 	summary "adventBot/internal/ai_model/yandex/summary/tasks"
 	internalbot "adventBot/internal/bot"
 	"adventBot/internal/config"
 	chat "adventBot/internal/db/chat"
-	chat_sqlite "adventBot/internal/db/chat/sqlite"
+	"adventBot/internal/db/dbtx"
 	msg "adventBot/internal/db/message"
-	msg_sqlite "adventBot/internal/db/message/sqlite"
+	"adventBot/internal/db/migrate"
+	"adventBot/internal/db/store"
+	subscription "adventBot/internal/db/subscription"
 	task "adventBot/internal/db/task"
-	task_sqlite "adventBot/internal/db/task/sqlite"
 	"adventBot/internal/service"
 	"adventBot/internal/timezone/geonames"
 	"context"
 	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
 	"github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	_ "github.com/lib/pq"
 	_ "github.com/mattn/go-sqlite3"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 )
 
 var (
-	cmd     internalbot.Handler
-	res     internalbot.Handler
-	txt     internalbot.Handler
-	loc     internalbot.Handler
-	today   internalbot.Handler
-	tasks   internalbot.Handler
-	trigger internalbot.Handler
-	//TODO tmp internalbot.Handler
-
 	model      ai_model.AiModel
 	summarizer *summary.SummarizerTask
 
 	chatRepository chat.Repository
 	msgRepository  msg.Repository
 	taskRepository task.Repository
+	subRepository  subscription.Repository
 
-	manager *service.SchedulerManager
+	manager   *service.SchedulerManager
+	reminders *service.ReminderEngine
 )
 
+var schemaVersion = flag.Bool("schema-version", false, "print the current database schema version and exit")
+
 func main() {
-	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if *schemaVersion {
+		printSchemaVersion(ctx)
+		return
+	}
 
 	// --- config ---
 	cfg, err := config.Load()
@@ -58,111 +69,296 @@ func main() {
 	client := http.Client{Timeout: time.Second * 60}
 	timeZone := geonames.NewApiGeonames(cfg.GeonamesUser, &client)
 
-	// --- db ---
-	db, err := sql.Open("sqlite3", cfg.DbPath)
+	// --- repository ---
+	st := openStore(ctx, &cfg)
+	chatRepository = st.Chats
+	msgRepository = st.Messages
+	taskRepository = st.Tasks
+	subRepository = st.Subscriptions
+
+	// --- bot ---
+	botAPI, err := tgbotapi.NewBotAPI(cfg.BotToken)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	// --- repository ---
-	chatRepository = chat_sqlite.NewRepositorySQlite(db)
-	if chatRepository.Init() != nil {
-		log.Fatal("Cannot initialize chat repository: ", err, cfg.DbPath)
+	botAPI.Debug = true
+
+	// --- reminders ---
+	reminders = service.NewReminderEngine(taskRepository, chatRepository, botAPI)
+	taskRepository = reminders.Watch(taskRepository)
+	st.OnCommit(reminders.Rearm)
+	reminders.Start()
+
+	// --- model ---
+	llmClient, err := llm.NewClient(&cfg, &http.Client{Timeout: time.Second * 60})
+	if err != nil {
+		log.Fatal(err)
 	}
+	model = yandex.NewAiModelYandex(&cfg, llmClient, st)
+	summarizer = summary.NewSummarizerTask(llmClient)
 
-	msgRepository = msg_sqlite.NewRepositorySQlite(db)
-	if msgRepository.Init() != nil {
-		log.Fatal("Cannot initialize message repository: ", err, cfg.DbPath)
+	//--- schedule ---
+	catchUp := service.CatchUpPolicy{
+		Enabled: cfg.DigestCatchUp == config.DigestCatchUpOnce,
+		Window:  cfg.DigestCatchUpWindow,
+	}
+	manager = service.NewSchedulerManager(taskRepository, chatRepository, subRepository, summarizer, catchUp)
+	if err := manager.Restore(ctx, botAPI); err != nil {
+		log.Println(err)
 	}
 
-	taskRepository = task_sqlite.NewRepositorySQlite(db)
-	if taskRepository.Init() != nil {
-		log.Fatal("Cannot initialize task repository: ", err, cfg.DbPath)
+	// --- router ---
+	router := internalbot.NewRouter()
+	router.Use(
+		internalbot.Recover(),
+		internalbot.Logging(),
+		internalbot.Auth(cfg.AllowedChatIDs),
+		internalbot.RateLimit(cfg.RateLimit, time.Minute),
+		internalbot.RequireChat(chatRepository, "start", "restart"),
+	)
+
+	tasks := internalbot.NewTasksHandler(taskRepository)
+	taskRef := internalbot.NewTaskRefHandler(taskRepository)
+	agenda := internalbot.NewAgendaHandler(taskRepository, chatRepository)
+
+	router.Command("start", "Начать и настроить часовой пояс", internalbot.NewCommandHandler(chatRepository, manager))
+	router.Command("restart", "Сбросить настройки и начать заново", internalbot.NewResetHandler(chatRepository, manager))
+	router.Command("today", "Задачи на сегодня", internalbot.NewTodayHandler(taskRepository, chatRepository))
+	router.Command("week", "Задачи на эту неделю", internalbot.NewWeekHandler(taskRepository, chatRepository))
+	router.Command("agenda", "Задачи на N дней вперёд", agenda)
+	router.Command("tasks", "Все открытые задачи", tasks)
+	router.Command("find", "Найти задачу по тексту", internalbot.NewFindHandler(taskRepository))
+	router.Command("done", "Отметить задачу N выполненной", taskRef)
+	router.Command("snooze", "Перенести задачу N на час", taskRef)
+	router.Command("tomorrow", "Перенести задачу N на завтра", taskRef)
+	router.Command("delete", "Удалить задачу N", taskRef)
+	router.Command("remind", "За сколько минут напоминать", internalbot.NewRemindHandler(chatRepository, reminders))
+	router.Command("digest", "Время ежедневной сводки", internalbot.NewDigestHandler(chatRepository, manager))
+	router.Command("trigger", "", internalbot.NewTriggerHandler(manager))
+	//TODO router.Command("temperature", "", internalbot.NewTemperatureHandler(model))
+
+	router.Callback(internalbot.TaskCallbackPrefix, internalbot.NewCallbackHandler(taskRepository))
+	router.Callback(internalbot.TasksCallbackPrefix, tasks)
+	router.Callback(internalbot.AgendaCallbackPrefix, agenda)
+	router.Callback(internalbot.PendingCallbackPrefix, internalbot.NewPendingHandler(model))
+
+	router.Location(internalbot.NewLocationHandler(timeZone, chatRepository, manager))
+	router.Text(internalbot.NewTextHandler(model, chatRepository, msgRepository, cfg.ReplyTimeout))
+
+	if err := router.SetMyCommands(botAPI); err != nil {
+		log.Println(err)
 	}
 
-	defer func() {
-		cancel()
+	// --- updates ---
+	// Разные чаты обрабатываются параллельно, апдейты одного чата — по порядку
+	dispatcher := internalbot.NewDispatcher(router, botAPI, cfg.Workers, cfg.ChatQueueSize)
 
-		if err := chatRepository.CloseConnection(); err != nil {
-			log.Println(err)
-		}
-		if err := msgRepository.CloseConnection(); err != nil {
-			log.Println(err)
-		}
-		if err := taskRepository.CloseConnection(); err != nil {
-			log.Println(err)
-		}
-	}()
+	var stopReceiving func(ctx context.Context)
+	if cfg.WebhookURL != "" {
+		stopReceiving = serveWebhook(ctx, &cfg, botAPI, dispatcher)
+	} else {
+		stopReceiving = pollUpdates(ctx, botAPI, dispatcher)
+	}
 
-	// --- model ---
-	model = yandex.NewAiModelYandex(&cfg, cfg.FolderId, msgRepository, taskRepository)
-	summarizer = summary.NewSummarizerTask(cfg.ApiKey, cfg.FolderId)
+	// Повторный сигнал завершает процесс сразу, не дожидаясь остановки
+	stop()
+	shutdown(cfg.ShutdownTimeout, stopReceiving, dispatcher, st)
+}
 
-	//--- schedule ---
-	manager = service.NewSchedulerManager(taskRepository, summarizer)
-	defer func() {
-		manager.Shutdown()
-	}()
+// shutdown останавливает бота: перестаёт принимать апдейты, ждёт уже принятые, затем текущие сводки
+// и напоминания, и только потом закрывает хранилище. На всю остановку отводится один timeout; всё,
+// что не успело завершиться, отменяется через контекст — например, запросы к модели.
+func shutdown(timeout time.Duration, stopReceiving func(ctx context.Context), dispatcher *internalbot.Dispatcher, st *store.Store) {
+	log.Printf("[main] shutting down, timeout %s", timeout)
 
-	// --- handlers ---
-	cmd = internalbot.NewCommandHandler(chatRepository, manager)
-	res = internalbot.NewResetHandler(chatRepository, manager)
-	txt = internalbot.NewTextHandler(model, chatRepository, msgRepository)
-	loc = internalbot.NewLocationHandler(timeZone, chatRepository)
-	//TODO tmp = internalbot.NewTemperatureHandler(model)
-	today = internalbot.NewTodayHandler(taskRepository)
-	tasks = internalbot.NewTasksHandler(taskRepository)
-	trigger = internalbot.NewTriggerHandler(manager)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
 
-	// --- bot ---
-	botAPI, err := tgbotapi.NewBotAPI(cfg.BotToken)
-	if err != nil {
-		log.Fatal(err)
+	stopReceiving(ctx)
+	if err := dispatcher.Shutdown(ctx); err != nil {
+		log.Println("[main] drain updates:", err)
+	}
+	if err := manager.Shutdown(ctx); err != nil {
+		log.Println("[main] stop schedulers:", err)
+	}
+	if err := reminders.Stop(ctx); err != nil {
+		log.Println("[main] stop reminders:", err)
 	}
 
-	botAPI.Debug = true
+	if err := st.Close(); err != nil {
+		log.Println("[main] close store:", err)
+	}
+	log.Println("[main] stopped")
+}
+
+// pollUpdates получает апдейты через long polling, пока не отменён ctx. Возвращает функцию, которая
+// останавливает polling и передаёт в очередь уже полученные, но ещё не принятые апдейты — до истечения её ctx.
+func pollUpdates(ctx context.Context, botAPI *tgbotapi.BotAPI, dispatcher *internalbot.Dispatcher) func(ctx context.Context) {
+	// getUpdates не работает, пока у бота установлен webhook, например после запуска в режиме webhook
+	if _, err := botAPI.Request(tgbotapi.DeleteWebhookConfig{}); err != nil {
+		log.Println("[main] deleteWebhook:", err)
+	}
 
 	u := tgbotapi.NewUpdate(0)
 	u.Timeout = 60
 
 	updates := botAPI.GetUpdatesChan(u)
 
-	for update := range updates {
-		if update.Message == nil {
-			continue
+	var held []tgbotapi.Update // остановка прервала ожидание места в очереди
+	for ctx.Err() == nil {
+		select {
+		case <-ctx.Done():
+		case update := <-updates:
+			err := dispatcher.Submit(ctx, update)
+			switch {
+			case ctx.Err() != nil:
+				held = append(held, update)
+			case err != nil && !errors.Is(err, internalbot.ErrChatQueueFull):
+				log.Println("[main] Submit:", err)
+			}
 		}
+	}
+
+	return func(ctx context.Context) {
+		log.Println("[main] stop polling updates")
+		botAPI.StopReceivingUpdates()
+		submitReceived(ctx, held, updates, dispatcher)
+	}
+}
 
-		if update.Message.IsCommand() {
-			switch update.Message.Command() {
-			case "start":
-				cmd.Handle(ctx, botAPI, &update)
-			case "restart":
-				res.Handle(ctx, botAPI, &update)
-			case "today":
-				today.Handle(ctx, botAPI, &update)
-			case "tasks":
-				tasks.Handle(ctx, botAPI, &update)
-			case "trigger":
-				trigger.Handle(ctx, botAPI, &update)
-			default:
-				handleText(ctx, botAPI, &update)
+// submitReceived передаёт в очередь апдейты held и те, что уже лежат в канале: Telegram мог посчитать
+// их доставленными и больше не пришлёт.
+func submitReceived(ctx context.Context, held []tgbotapi.Update, updates tgbotapi.UpdatesChannel, dispatcher *internalbot.Dispatcher) {
+	for more := true; more; {
+		select {
+		case update, ok := <-updates:
+			if ok {
+				held = append(held, update)
 			}
-		} else {
-			handleText(ctx, botAPI, &update)
+			more = ok
+		default:
+			more = false
+		}
+	}
+
+	for _, update := range held {
+		if err := dispatcher.Submit(ctx, update); err != nil {
+			log.Printf("[main] Submit update_id=%d: %v", update.UpdateID, err)
 		}
 	}
 }
 
-func handleText(ctx context.Context, b *tgbotapi.BotAPI, update *tgbotapi.Update) {
-	if update.Message == nil {
-		return
+// serveWebhook принимает апдейты через webhook, пока не отменён ctx. Возвращает функцию, которая
+// останавливает сервер, дожидаясь текущих запросов до истечения её ctx.
+func serveWebhook(ctx context.Context, cfg *config.Config, botAPI *tgbotapi.BotAPI, dispatcher *internalbot.Dispatcher) func(ctx context.Context) {
+	webhook := internalbot.NewWebhook(internalbot.WebhookConfig{
+		URL:         cfg.WebhookURL,
+		Listen:      cfg.WebhookListen,
+		Path:        cfg.WebhookPath,
+		SecretToken: cfg.WebhookSecretToken,
+		CertFile:    cfg.WebhookTLSCert,
+		KeyFile:     cfg.WebhookTLSKey,
+	}, dispatcher)
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- webhook.ListenAndServe() }()
+
+	stopServer := func(ctx context.Context) {
+		if err := webhook.Shutdown(ctx); err != nil {
+			log.Println("[main] webhook shutdown:", err)
+		}
 	}
-	if update.Message.Location != nil {
-		loc.Handle(ctx, b, update)
-		return
+
+	if err := webhook.Register(botAPI); err != nil {
+		log.Println("[main]", err)
+		return stopServer
+	}
+
+	select {
+	case <-ctx.Done():
+		return stopServer
+	case err := <-errCh:
+		log.Println("[main] webhook server stopped:", err)
+		return func(context.Context) {}
+	}
+}
+
+// openStore подключается к базе из конфига и применяет миграции; с DB_PATH=:memory: данные
+// хранятся только в памяти процесса.
+func openStore(ctx context.Context, cfg *config.Config) *store.Store {
+	if cfg.DbDriver == config.DbDriverSQLite && cfg.DbPath == config.DbPathMemory {
+		log.Println("[openStore] in-memory store: data is lost on exit")
+		return store.NewMemory()
+	}
+
+	db, driver := openDB(cfg)
+
+	// --- migrations ---
+	if _, err := migrate.Up(ctx, db, driver); err != nil {
+		log.Fatal("Cannot migrate database: ", err, driver)
+	}
+
+	st, err := store.New(db, driver)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return st
+}
+
+// printSchemaVersion обслуживает флаг -schema-version: печатает версию схемы, ничего не меняя.
+// Нужны только настройки базы, поэтому остальной конфиг не читается и не проверяется.
+func printSchemaVersion(ctx context.Context) {
+	cfg, err := config.LoadDB()
+	if err != nil {
+		log.Fatal(err)
 	}
-	if update.Message.Text != "" {
-		txt.Handle(ctx, b, update)
+	if cfg.DbDriver == config.DbDriverSQLite && cfg.DbPath == config.DbPathMemory {
+		fmt.Println("schema version: none (in-memory store)")
 		return
 	}
+
+	db, driver := openDB(&cfg)
+	defer func() {
+		_ = db.Close()
+	}()
+
+	version, err := migrate.Version(ctx, db, driver)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("schema version: %d (latest: %d)\n", version, migrate.Latest())
+}
+
+func openDB(cfg *config.Config) (*sql.DB, dbtx.Driver) {
+	driver, dsn := dbtx.Driver(cfg.DbDriver), cfg.DbPath
+	if driver == dbtx.Postgres {
+		dsn = cfg.DbDsn
+	}
+	if driver == dbtx.SQLite {
+		dsn = sqliteDSN(dsn)
+	}
+	db, err := sql.Open(string(driver), dsn)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return db, driver
+}
+
+// sqliteDSN добавляет к пути базы параметры для конкурентной записи: апдейты разных чатов обрабатываются
+// параллельно, и писатель должен подождать другого, а не сразу получить SQLITE_BUSY. Параметры,
+// уже заданные в DB_PATH, не переопределяются.
+func sqliteDSN(path string) string {
+	for _, param := range []string{"_busy_timeout=5000", "_txlock=immediate"} {
+		name, _, _ := strings.Cut(param, "=")
+		if strings.Contains(path, name+"=") {
+			continue
+		}
+		if strings.Contains(path, "?") {
+			path += "&" + param
+		} else {
+			path += "?" + param
+		}
+	}
+	return path
 }